@@ -0,0 +1,267 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/EndFirstCorp/onedb"
+	"github.com/EndFirstCorp/onedb/redis"
+	ldap "gopkg.in/ldap.v2"
+)
+
+type user struct {
+	ID   int    `db:"id"`
+	Name string `db:"name"`
+}
+
+func mockBackend(t *testing.T, data ...interface{}) {
+	original := openBackend
+	openBackend = func(cfg *config) (onedb.Backender, io.Closer, error) {
+		return onedb.NewMock(nil, nil, data...), closerFunc(func() error { return nil }), nil
+	}
+	t.Cleanup(func() { openBackend = original })
+}
+
+func TestRunFormats(t *testing.T) {
+	users := []user{{1, "alice"}, {2, "bob\tsmith"}}
+	tests := []struct {
+		format   string
+		expected string
+	}{
+		{"table", "id  name\n--  ----\n1   alice\n2   bob smith\n(2 rows)\n"},
+		{"csv", "id,name\n1,alice\n2,bob\tsmith\n"},
+		{"json", `[{"id":1,"name":"alice"},{"id":2,"name":"bob\tsmith"}]` + "\n"},
+		{"ndjson", `{"id":1,"name":"alice"}` + "\n" + `{"id":2,"name":"bob\tsmith"}` + "\n"},
+	}
+	for _, test := range tests {
+		mockBackend(t, users)
+		var stdout, stderr bytes.Buffer
+		code := run([]string{"-dsn", "mock", "-format", test.format, "-query", "select * from users"}, nil, &stdout, &stderr)
+		if code != 0 || stdout.String() != test.expected {
+			t.Errorf("%s: expected %q. Actual: %d %q %s", test.format, test.expected, code, stdout.String(), stderr.String())
+		}
+	}
+
+	mockBackend(t, []user{})
+	var stdout bytes.Buffer
+	if code := run([]string{"-dsn", "mock", "select 1"}, nil, &stdout, io.Discard); code != 0 || !strings.HasSuffix(stdout.String(), "(0 rows)\n") {
+		t.Error("expected empty table", code, stdout.String())
+	}
+}
+
+func TestRunQuerySources(t *testing.T) {
+	var opened *config
+	original := openBackend
+	openBackend = func(cfg *config) (onedb.Backender, io.Closer, error) {
+		opened = cfg
+		return onedb.NewMock(nil, nil, []user{{1, "alice"}}), closerFunc(func() error { return nil }), nil
+	}
+	defer func() { openBackend = original }()
+
+	file := filepath.Join(t.TempDir(), "query.sql")
+	os.WriteFile(file, []byte("select *\nfrom users;\n"), 0600)
+	tests := []struct {
+		args     []string
+		stdin    string
+		expected string
+	}{
+		{[]string{"-query", " select 1 "}, "", "select 1"},
+		{[]string{"HGETALL", "user:1"}, "", "HGETALL user:1"},
+		{[]string{"-file", file}, "", "select *\nfrom users;"},
+		{[]string{"-file", "-"}, "select 2\n", "select 2"},
+	}
+	for _, test := range tests {
+		args := append([]string{"-dsn", "mock", "-backend", "redis", "-timeout", "1m"}, test.args...)
+		if code := run(args, strings.NewReader(test.stdin), io.Discard, io.Discard); code != 0 || opened.query != test.expected || opened.backend != "redis" {
+			t.Errorf("%v: expected %q. Actual: %d %+v", test.args, test.expected, code, opened)
+		}
+	}
+}
+
+func TestRunDrivers(t *testing.T) {
+	var opened *config
+	original := openBackend
+	openBackend = func(cfg *config) (onedb.Backender, io.Closer, error) {
+		opened = cfg
+		return onedb.NewMock(nil, nil, []user{{1, "alice"}}), closerFunc(func() error { return nil }), nil
+	}
+	defer func() { openBackend = original }()
+
+	tests := []struct {
+		args    []string
+		backend string
+		driver  string
+	}{
+		{[]string{}, "sql", "mysql"},
+		{[]string{"-driver", "sqlserver"}, "sql", "sqlserver"},
+		{[]string{"-driver", "mssql"}, "sql", "mssql"},
+		{[]string{"-driver", "pgx"}, "pgx", "pgx"},
+		{[]string{"-driver", "redis"}, "redis", "redis"},
+		{[]string{"-driver", "ldap", "-backend", "ldap"}, "ldap", "ldap"},
+	}
+	for _, test := range tests {
+		args := append([]string{"-dsn", "mock", "-query", "select 1"}, test.args...)
+		if code := run(args, nil, io.Discard, io.Discard); code != 0 || opened.backend != test.backend || opened.driver != test.driver {
+			t.Errorf("%v: expected %s backend with %s driver. Actual: %d %+v", test.args, test.backend, test.driver, code, opened)
+		}
+	}
+
+	var stderr bytes.Buffer
+	if code := run([]string{"-dsn", "mock", "-driver", "pgx", "-backend", "sql", "select 1"}, nil, io.Discard, &stderr); code != 2 || !strings.Contains(stderr.String(), "-driver pgx") {
+		t.Error("expected conflicting driver and backend error", code, stderr.String())
+	}
+	stderr.Reset()
+	run([]string{"-help"}, nil, io.Discard, &stderr)
+	help := stderr.String()[strings.Index(stderr.String(), "-driver"):strings.Index(stderr.String(), "-dsn")]
+	for _, driver := range []string{"mysql", "mssql", "sqlserver", "pgx", "redis", "ldap"} {
+		if !strings.Contains(help, driver) {
+			t.Error("expected -driver help to list", driver, help)
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	mockBackend(t)
+	usage := [][]string{
+		{"-dsn", "mock"},
+		{"-dsn", "mock", "-query", "select 1", "select 2"},
+		{"-query", "select 1"},
+		{"-dsn", "mock", "-format", "xml", "select 1"},
+		{"-dsn", "mock", "-file", "/does/not/exist"},
+		{"-unknown"},
+	}
+	for _, args := range usage {
+		if code := run(args, nil, io.Discard, io.Discard); code != 2 {
+			t.Errorf("%v: expected usage error. Actual: %d", args, code)
+		}
+	}
+
+	var stderr bytes.Buffer
+	if code := run([]string{"-dsn", "mock", "select 1"}, nil, io.Discard, &stderr); code != 1 || !strings.Contains(stderr.String(), "no mock data") {
+		t.Error("expected query error", code, stderr.String())
+	}
+	stderr.Reset()
+	if code := run([]string{"-dsn", "mock", "-backend", "mongo", "select 1"}, nil, io.Discard, &stderr); code != 1 {
+		t.Error("expected unknown backend error", code, stderr.String())
+	}
+}
+
+func TestParseDSN(t *testing.T) {
+	host, port, password, err := parseDSN("redis://:secret@cache:6380", 6379)
+	if err != nil || host != "cache" || port != 6380 || password != "secret" {
+		t.Error("expected redis URI", host, port, password, err)
+	}
+	host, port, password, err = parseDSN("cache", 6379)
+	if err != nil || host != "cache" || port != 6379 || password != "" {
+		t.Error("expected default port", host, port, password, err)
+	}
+	if _, _, _, err := parseDSN("redis://:secret@cache:port", 6379); err == nil {
+		t.Error("expected invalid port")
+	}
+	if _, _, _, err := parseDSN("redis://", 6379); err == nil {
+		t.Error("expected missing host")
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	fields, err := splitCommand(`SET  greeting "hello \"world\"" ""`)
+	if err != nil || !reflect.DeepEqual(fields, []string{"SET", "greeting", `hello "world"`, ""}) {
+		t.Error("expected fields", fields, err)
+	}
+	if _, err := splitCommand(`GET "key`); err == nil {
+		t.Error("expected unterminated quote")
+	}
+}
+
+type fakeRedis struct {
+	redis.Rediser
+	args  []interface{}
+	reply interface{}
+	err   error
+}
+
+func (f *fakeRedis) DoContext(ctx context.Context, command string, args ...interface{}) (interface{}, error) {
+	f.args = append([]interface{}{command}, args...)
+	return f.reply, f.err
+}
+
+func TestRedisBackend(t *testing.T) {
+	db := &fakeRedis{reply: []interface{}{[]byte("name"), []byte("alice"), []byte("age"), int64(30)}}
+	backend := &redisBackend{db}
+	var b bytes.Buffer
+	if err := writeResults(context.Background(), &b, "csv", backend, "hgetall user:1"); err != nil || b.String() != "field,value\nname,alice\nage,30\n" {
+		t.Error("expected hash rows", b.String(), err)
+	}
+	if !reflect.DeepEqual(db.args, []interface{}{"HGETALL", "user:1"}) {
+		t.Error("expected command", db.args)
+	}
+
+	rows := getRedisRows("KEYS", []interface{}{[]byte("a"), []interface{}{[]byte("b"), int64(1)}})
+	if !reflect.DeepEqual(rows.rows, [][]interface{}{{"a"}, {"b 1"}}) {
+		t.Error("expected one row per element", rows.rows)
+	}
+	if rows := getRedisRows("GET", nil); len(rows.rows) != 0 {
+		t.Error("expected no rows for nil reply", rows.rows)
+	}
+
+	var value string
+	backend = &redisBackend{&fakeRedis{reply: []byte("PONG")}}
+	if err := backend.QueryRow("PING").Scan(&value); err != nil || value != "PONG" {
+		t.Error("expected single value", value, err)
+	}
+	backend = &redisBackend{&fakeRedis{err: errors.New("fail")}}
+	if err := backend.QueryRow("PING").Scan(&value); err == nil {
+		t.Error("expected error")
+	}
+	if _, err := backend.Query("  "); err != onedb.ErrQueryIsNil {
+		t.Error("expected empty query error", err)
+	}
+}
+
+type fakeSearcher struct {
+	request *ldap.SearchRequest
+	result  *ldap.SearchResult
+	err     error
+}
+
+func (f *fakeSearcher) QueryContext(ctx context.Context, request *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	f.request = request
+	return f.result, f.err
+}
+
+func TestLDAPBackend(t *testing.T) {
+	searcher := &fakeSearcher{result: &ldap.SearchResult{Entries: []*ldap.Entry{
+		ldap.NewEntry("uid=bob,dc=example", map[string][]string{"mail": {"bob@example.com", "b@example.com"}, "cn": {"Bob"}}),
+		ldap.NewEntry("uid=amy,dc=example", map[string][]string{"cn": {"Amy"}, "title": {"CTO"}}),
+	}}}
+	backend := &ldapBackend{searcher, "dc=example", nil}
+	var b bytes.Buffer
+	if err := writeResults(context.Background(), &b, "csv", backend, "(objectClass=person)"); err != nil ||
+		b.String() != `dn,cn,mail,title`+"\n"+`"uid=bob,dc=example",Bob,bob@example.com; b@example.com,`+"\n"+`"uid=amy,dc=example",Amy,,CTO`+"\n" {
+		t.Error("expected entry rows", b.String(), err)
+	}
+	if searcher.request.BaseDN != "dc=example" || searcher.request.Filter != "(objectClass=person)" {
+		t.Error("expected search request", searcher.request)
+	}
+
+	backend.attributes = []string{"cn"}
+	var cn string
+	var dn string
+	if err := backend.QueryRow("(uid=bob)").Scan(&dn, &cn); err != nil || dn != "uid=bob,dc=example" || cn != "Bob" {
+		t.Error("expected first entry", dn, cn, err)
+	}
+	if _, err := backend.Query("(uid=bob)", 1); err == nil {
+		t.Error("expected arguments error")
+	}
+	searcher.err = errors.New("fail")
+	if _, err := backend.Query("(uid=bob)"); err == nil {
+		t.Error("expected search error")
+	}
+}
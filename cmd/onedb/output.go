@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/EndFirstCorp/onedb"
+)
+
+var formats = []string{"table", "json", "ndjson", "csv"}
+
+func isFormat(format string) bool {
+	for _, f := range formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// writeResults runs query and writes its rows to w in the requested format
+func writeResults(ctx context.Context, w io.Writer, format string, backend onedb.Backender, query string) error {
+	switch format {
+	case "json":
+		if err := onedb.QueryWriteJSONContext(ctx, w, onedb.JSONOptions{}, backend, query); err != nil {
+			return err
+		}
+		_, err := io.WriteString(w, "\n")
+		return err
+	case "ndjson":
+		return onedb.QueryWriteJSONContext(ctx, w, onedb.JSONOptions{NewlineDelimited: true}, backend, query)
+	case "csv":
+		return onedb.QueryWriteCSVContext(ctx, w, onedb.CSVOptions{}, backend, query)
+	default:
+		return writeTable(ctx, w, backend, query)
+	}
+}
+
+// writeTable writes the rows as aligned columns under a header, followed by the row count. The rows are rendered
+// by the CSV writer so that values look the same in both formats
+func writeTable(ctx context.Context, w io.Writer, backend onedb.Backender, query string) error {
+	var b bytes.Buffer
+	if err := onedb.QueryWriteCSVContext(ctx, &b, onedb.CSVOptions{Null: "NULL"}, backend, query); err != nil {
+		return err
+	}
+	records, err := csv.NewReader(&b).ReadAll()
+	if err != nil {
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for i, record := range records {
+		for j := range record {
+			record[j] = tableCell.Replace(record[j])
+		}
+		fmt.Fprintln(tw, strings.Join(record, "\t"))
+		if i == 0 {
+			separators := make([]string, len(record))
+			for j, header := range record {
+				separators[j] = strings.Repeat("-", len(header))
+			}
+			fmt.Fprintln(tw, strings.Join(separators, "\t"))
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	count := 0
+	if len(records) > 0 {
+		count = len(records) - 1
+	}
+	noun := "rows"
+	if count == 1 {
+		noun = "row"
+	}
+	_, err = fmt.Fprintf(w, "(%d %s)\n", count, noun)
+	return err
+}
+
+// tableCell keeps each value on one line so that the columns stay aligned
+var tableCell = strings.NewReplacer("\t", " ", "\r\n", " ", "\n", " ", "\r", " ")
@@ -0,0 +1,331 @@
+package onedb
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// defaultBatchSize is the number of rows per Parquet row group or Arrow record batch when the options don't set one
+const defaultBatchSize = 10000
+
+// maxDecimal128Precision is the largest decimal precision that fits in the 128 bit decimals used by Arrow
+const maxDecimal128Precision = 38
+
+// ColumnType describes the database type of a result column
+type ColumnType struct {
+	Name         string
+	DatabaseType string // type name reported by the database such as INT4, VARCHAR or NUMERIC
+	Precision    int64  // precision of decimal columns. Zero when unknown
+	Scale        int64  // scale of decimal columns
+}
+
+// ColumnDescriber is implemented by RowsScanner values that can describe their columns before any row is read.
+// Columnar writers use it to choose the type of each column
+type ColumnDescriber interface {
+	DescribeColumns() ([]ColumnType, error)
+}
+
+// sqlColumnTyper is implemented by database/sql's Rows
+type sqlColumnTyper interface {
+	ColumnTypes() ([]*sql.ColumnType, error)
+}
+
+// columnKind is the storage type of a column in a columnar file
+type columnKind int
+
+const (
+	kindString columnKind = iota
+	kindBool
+	kindInt64
+	kindFloat64
+	kindBytes
+	kindTimestamp // microseconds since the Unix epoch in UTC
+	kindDecimal   // unscaled integer with the precision and scale of the column
+)
+
+type columnSchema struct {
+	Name      string
+	Kind      columnKind
+	Precision int
+	Scale     int
+}
+
+// getColumnarSchema chooses the type of each column from the column metadata of the rows when it is available or
+// from the first non NULL value of each column in sample otherwise. Columns without either are stored as strings
+func getColumnarSchema(rows RowsScanner, columns []string, sample [][]interface{}) []columnSchema {
+	types := describeColumns(rows)
+	schema := make([]columnSchema, len(columns))
+	for i, name := range columns {
+		schema[i] = columnSchema{Name: name, Kind: kindString}
+		if i < len(types) && types[i].DatabaseType != "" {
+			schema[i].Kind, schema[i].Precision, schema[i].Scale = getDatabaseKind(types[i])
+			continue
+		}
+		for _, row := range sample {
+			if row[i] != nil {
+				schema[i].Kind = getValueKind(row[i])
+				break
+			}
+		}
+	}
+	return schema
+}
+
+func describeColumns(rows RowsScanner) []ColumnType {
+	switch r := rows.(type) {
+	case ColumnDescriber:
+		types, _ := r.DescribeColumns()
+		return types
+	case sqlColumnTyper:
+		sqlTypes, err := r.ColumnTypes()
+		if err != nil {
+			return nil
+		}
+		types := make([]ColumnType, len(sqlTypes))
+		for i, t := range sqlTypes {
+			types[i] = ColumnType{Name: t.Name(), DatabaseType: t.DatabaseTypeName()}
+			types[i].Precision, types[i].Scale, _ = t.DecimalSize()
+		}
+		return types
+	}
+	return nil
+}
+
+func getDatabaseKind(t ColumnType) (columnKind, int, int) {
+	switch strings.ToUpper(t.DatabaseType) {
+	case "BOOL", "BOOLEAN", "BIT":
+		return kindBool, 0, 0
+	case "INT2", "INT4", "INT8", "SMALLINT", "INT", "INTEGER", "BIGINT", "TINYINT", "MEDIUMINT", "SERIAL", "BIGSERIAL", "YEAR":
+		return kindInt64, 0, 0
+	case "FLOAT4", "FLOAT8", "REAL", "FLOAT", "DOUBLE", "DOUBLE PRECISION":
+		return kindFloat64, 0, 0
+	case "NUMERIC", "DECIMAL", "MONEY", "SMALLMONEY":
+		if t.Precision > 0 && t.Precision <= maxDecimal128Precision && t.Scale >= 0 && t.Scale <= t.Precision {
+			return kindDecimal, int(t.Precision), int(t.Scale)
+		}
+	case "DATE", "TIMESTAMP", "TIMESTAMPTZ", "DATETIME", "DATETIME2", "SMALLDATETIME", "DATETIMEOFFSET":
+		return kindTimestamp, 0, 0
+	case "BYTEA", "BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY", "IMAGE":
+		return kindBytes, 0, 0
+	}
+	return kindString, 0, 0
+}
+
+func getValueKind(value interface{}) columnKind {
+	switch value.(type) {
+	case bool:
+		return kindBool
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return kindInt64
+	case float32, float64:
+		return kindFloat64
+	case time.Time:
+		return kindTimestamp
+	case []byte:
+		return kindBytes
+	}
+	return kindString
+}
+
+// readColumnarBatch scans up to size rows. The values are copied so that vals can be reused
+func readColumnarBatch(rows RowsScanner, vals []interface{}, size int) ([][]interface{}, error) {
+	var batch [][]interface{}
+	for len(batch) < size && rows.Next() {
+		if err := rows.Scan(vals...); err != nil {
+			return nil, err
+		}
+		row := make([]interface{}, len(vals))
+		for i, value := range vals {
+			val := value.(*interface{})
+			if err := resolveValuer(val); err != nil {
+				return nil, err
+			}
+			row[i] = *val
+		}
+		batch = append(batch, row)
+	}
+	return batch, rows.Err()
+}
+
+// columnBuffer holds the values of one column of a batch. Every row has a slot, NULL rows hold the zero value
+type columnBuffer struct {
+	schema    columnSchema
+	valid     []bool
+	nullCount int
+	bools     []bool
+	ints      []int64
+	floats    []float64
+	bytes     [][]byte
+	decimals  []*big.Int
+}
+
+func newColumnBuffers(schema []columnSchema, batch [][]interface{}) ([]*columnBuffer, error) {
+	buffers := make([]*columnBuffer, len(schema))
+	for i := range schema {
+		buffers[i] = &columnBuffer{schema: schema[i], valid: make([]bool, 0, len(batch))}
+		for _, row := range batch {
+			if err := buffers[i].append(row[i]); err != nil {
+				return nil, fmt.Errorf("onedb: unable to store column %q: %v", schema[i].Name, err)
+			}
+		}
+	}
+	return buffers, nil
+}
+
+func (c *columnBuffer) append(value interface{}) error {
+	if value == nil {
+		c.nullCount++
+	}
+	c.valid = append(c.valid, value != nil)
+	switch c.schema.Kind {
+	case kindBool:
+		var b bool
+		if value != nil {
+			if err := convertAssign(reflect.ValueOf(&b).Elem(), value); err != nil {
+				return err
+			}
+		}
+		c.bools = append(c.bools, b)
+	case kindInt64:
+		var i int64
+		if value != nil {
+			if err := convertAssign(reflect.ValueOf(&i).Elem(), value); err != nil {
+				return err
+			}
+		}
+		c.ints = append(c.ints, i)
+	case kindFloat64:
+		var f float64
+		if value != nil {
+			if err := convertAssign(reflect.ValueOf(&f).Elem(), value); err != nil {
+				return err
+			}
+		}
+		c.floats = append(c.floats, f)
+	case kindTimestamp:
+		var t time.Time
+		if value != nil {
+			if err := convertAssign(reflect.ValueOf(&t).Elem(), value); err != nil {
+				return err
+			}
+		}
+		c.ints = append(c.ints, t.Unix()*1e6+int64(t.Nanosecond()/1e3))
+	case kindDecimal:
+		d := new(big.Int)
+		if value != nil {
+			var err error
+			if d, err = getUnscaledDecimal(value, c.schema.Scale); err != nil {
+				return err
+			}
+			if new(big.Int).Abs(d).Cmp(pow10(c.schema.Precision)) >= 0 {
+				return fmt.Errorf("%v has more than %d digits", value, c.schema.Precision)
+			}
+		}
+		c.decimals = append(c.decimals, d)
+	case kindBytes:
+		var b []byte
+		if value != nil {
+			if err := convertAssign(reflect.ValueOf(&b).Elem(), value); err != nil {
+				return err
+			}
+		}
+		c.bytes = append(c.bytes, b)
+	default:
+		var s string
+		if value != nil {
+			s = getColumnarString(value)
+		}
+		c.bytes = append(c.bytes, []byte(s))
+	}
+	return nil
+}
+
+func getColumnarString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case fmt.Stringer:
+		return v.String()
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// getUnscaledDecimal returns value multiplied by 10^scale, which must be a whole number
+func getUnscaledDecimal(value interface{}, scale int) (*big.Int, error) {
+	r := new(big.Rat)
+	switch v := value.(type) {
+	case float32, float64:
+		f := reflect.ValueOf(v).Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("%v can't be stored as a decimal", f)
+		}
+		r.SetFloat64(f)
+	case int, int8, int16, int32, int64:
+		r.SetInt64(reflect.ValueOf(v).Int())
+	case uint, uint8, uint16, uint32, uint64:
+		r.SetInt(new(big.Int).SetUint64(reflect.ValueOf(v).Uint()))
+	default:
+		text := strings.TrimSpace(getColumnarString(value))
+		if _, ok := r.SetString(text); !ok {
+			return nil, fmt.Errorf("%q is not a decimal", text)
+		}
+	}
+	r.Mul(r, new(big.Rat).SetInt(pow10(scale)))
+	if !r.IsInt() {
+		return nil, fmt.Errorf("%v has more than %d decimal places", value, scale)
+	}
+	return r.Num(), nil
+}
+
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// twosComplement returns the big endian two's complement representation of i in size bytes
+func twosComplement(i *big.Int, size int) []byte {
+	b := make([]byte, size)
+	v := i
+	if i.Sign() < 0 {
+		v = new(big.Int).Add(i, new(big.Int).Lsh(big.NewInt(1), uint(size*8)))
+	}
+	v.FillBytes(b)
+	return b
+}
+
+// offsetWriter tracks the number of bytes written so that file offsets can be recorded
+type offsetWriter struct {
+	w      io.Writer
+	offset int64
+}
+
+func (o *offsetWriter) Write(p []byte) (int, error) {
+	n, err := o.w.Write(p)
+	o.offset += int64(n)
+	return n, err
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	return append(b, byte(v), byte(v>>8), byte(v>>16), byte(v>>24))
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	return appendUint32(appendUint32(b, uint32(v)), uint32(v>>32))
+}
+
+func appendUvarint(b []byte, v uint64) []byte {
+	for v >= 0x80 {
+		b = append(b, byte(v)|0x80)
+		v >>= 7
+	}
+	return append(b, byte(v))
+}
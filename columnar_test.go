@@ -0,0 +1,153 @@
+package onedb
+
+import (
+	"bytes"
+	"database/sql"
+	"flag"
+	"math/big"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// columnarGolden adds decimal columns and NULL numeric and timestamp columns to columnarData for the golden files
+type columnarGolden struct {
+	ID       int
+	Name     sql.NullString
+	Price    float64
+	Active   bool
+	Created  time.Time
+	Data     []byte
+	Amount   string
+	Balance  sql.NullString
+	Quantity sql.NullInt64
+	Ratio    sql.NullFloat64
+	Updated  sql.NullTime
+}
+
+// getColumnarGoldenRows returns the rows written to the golden files. The column types are described so that
+// Amount and Balance are stored as decimals
+func getColumnarGoldenRows() RowsScanner {
+	updated := sql.NullTime{Time: time.Date(2021, 6, 7, 8, 9, 10, 11000, time.UTC), Valid: true}
+	var rows []columnarGolden
+	for i, data := range getColumnarData() {
+		rows = append(rows, columnarGolden{ID: data.ID, Name: data.Name, Price: data.Price, Active: data.Active, Created: data.Created, Data: data.Data})
+		switch i {
+		case 0:
+			rows[i].Amount, rows[i].Balance = "12.34", sql.NullString{String: "-1234567890123456.7890", Valid: true}
+			rows[i].Ratio = sql.NullFloat64{Float64: 0.25, Valid: true}
+		case 1:
+			rows[i].Amount, rows[i].Quantity, rows[i].Updated = "-0.05", sql.NullInt64{Int64: 7, Valid: true}, updated
+		case 2:
+			rows[i].Amount, rows[i].Balance = "100", sql.NullString{String: "0.0001", Valid: true}
+		}
+	}
+	types := []ColumnType{{DatabaseType: "INT8"}, {DatabaseType: "TEXT"}, {DatabaseType: "FLOAT8"}, {DatabaseType: "BOOL"},
+		{DatabaseType: "TIMESTAMP"}, {DatabaseType: "BYTEA"}, {DatabaseType: "NUMERIC", Precision: 10, Scale: 2},
+		{DatabaseType: "NUMERIC", Precision: 20, Scale: 4}, {DatabaseType: "INT8"}, {DatabaseType: "FLOAT8"}, {DatabaseType: "TIMESTAMP"}}
+	return &describedRows{RowsScanner: NewRowsScanner(rows), types: types}
+}
+
+// checkGolden compares a written file with testdata/name. testdata/check_golden.py reads the golden files with
+// pyarrow to confirm that the reference implementation decodes them to getColumnarGoldenRows. It is run by
+// TestGoldenPyarrow
+func checkGolden(t *testing.T, name string, actual []byte) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *updateGolden {
+		if err := os.WriteFile(path, actual, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	expected, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal("expected golden file", err)
+	}
+	if !bytes.Equal(actual, expected) {
+		t.Errorf("expected %s to match. Run go test -run %s -update and check the result with testdata/check_golden.py", path, t.Name())
+	}
+}
+
+// TestGoldenPyarrow runs testdata/check_golden.py. It is skipped when python3 or pyarrow isn't installed
+func TestGoldenPyarrow(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 is not installed. The golden files weren't checked with pyarrow")
+	}
+	if err := exec.Command(python, "-c", "import pyarrow.parquet, pyarrow.ipc").Run(); err != nil {
+		t.Skip("pyarrow is not installed. Run pip install pyarrow to check the golden files with it")
+	}
+	if out, err := exec.Command(python, filepath.Join("testdata", "check_golden.py")).CombinedOutput(); err != nil {
+		t.Errorf("expected pyarrow to read the golden files. %v\n%s", err, out)
+	}
+}
+
+type describedRows struct {
+	RowsScanner
+	types []ColumnType
+}
+
+func (r *describedRows) DescribeColumns() ([]ColumnType, error) {
+	return r.types, nil
+}
+
+func TestGetColumnarSchema(t *testing.T) {
+	sample := [][]interface{}{{nil, int64(1), "a", nil}, {true, nil, "b", nil}}
+	schema := getColumnarSchema(NewRowsScanner([]SimpleData{}), []string{"a", "b", "c", "d"}, sample)
+	kinds := []columnKind{kindBool, kindInt64, kindString, kindString}
+	for i, kind := range kinds {
+		if schema[i].Kind != kind {
+			t.Errorf("expected column %d to be %v. Actual: %v", i, kind, schema[i].Kind)
+		}
+	}
+
+	rows := &describedRows{types: []ColumnType{{DatabaseType: "numeric", Precision: 10, Scale: 2}, {DatabaseType: "timestamptz"}, {DatabaseType: "NUMERIC"}, {DatabaseType: "bytea"}}}
+	schema = getColumnarSchema(rows, []string{"a", "b", "c", "d"}, nil)
+	if schema[0].Kind != kindDecimal || schema[0].Precision != 10 || schema[0].Scale != 2 || schema[1].Kind != kindTimestamp || schema[2].Kind != kindString || schema[3].Kind != kindBytes {
+		t.Error("expected database types to be used", schema)
+	}
+}
+
+func TestColumnBuffer(t *testing.T) {
+	schema := []columnSchema{{Name: "d", Kind: kindDecimal, Precision: 5, Scale: 2}, {Name: "t", Kind: kindTimestamp}, {Name: "s", Kind: kindString}}
+	date := time.Date(1969, 12, 31, 23, 59, 59, 500000000, time.UTC)
+	buffers, err := newColumnBuffers(schema, [][]interface{}{{"-12.3", date, 5}, {[]byte("1.25"), "1970-01-01 00:00:01", nil}})
+	if err != nil {
+		t.Fatal("expected success", err)
+	}
+	if buffers[0].decimals[0].Int64() != -1230 || buffers[0].decimals[1].Int64() != 125 {
+		t.Error("expected unscaled decimals", buffers[0].decimals)
+	}
+	if buffers[1].ints[0] != -500000 || buffers[1].ints[1] != 1000000 {
+		t.Error("expected microseconds", buffers[1].ints)
+	}
+	if string(buffers[2].bytes[0]) != "5" || buffers[2].valid[1] || buffers[2].nullCount != 1 {
+		t.Error("expected formatted string and NULL")
+	}
+
+	for _, value := range []interface{}{"1.234", "1000", "abc"} {
+		if _, err := newColumnBuffers(schema[:1], [][]interface{}{{value}}); err == nil {
+			t.Error("expected decimal error", value)
+		}
+	}
+	if _, err := newColumnBuffers([]columnSchema{{Name: "i", Kind: kindInt64}}, [][]interface{}{{"abc"}}); err == nil {
+		t.Error("expected int error")
+	}
+}
+
+func TestTwosComplement(t *testing.T) {
+	tests := []struct {
+		value    int64
+		size     int
+		expected []byte
+	}{{1, 1, []byte{1}}, {-1, 1, []byte{0xff}}, {-1230, 2, []byte{0xfb, 0x32}}, {128, 2, []byte{0, 0x80}}}
+	for _, test := range tests {
+		if actual := twosComplement(big.NewInt(test.value), test.size); string(actual) != string(test.expected) {
+			t.Errorf("expected %d to be %v. Actual: %v", test.value, test.expected, actual)
+		}
+	}
+}
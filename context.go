@@ -0,0 +1,77 @@
+package onedb
+
+import "context"
+
+// queryContext runs the query through QueryContext when the backend supports it. Backends that don't are
+// checked for cancellation before the query runs and between rows
+func queryContext(ctx context.Context, backend Backender, query string, args ...interface{}) (RowsScanner, error) {
+	if ctx.Done() == nil { // context can never be cancelled
+		return backend.Query(query, args...)
+	}
+	if b, ok := backend.(ContextBackender); ok {
+		return b.QueryContext(ctx, query, args...)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	rows, err := backend.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return &contextRows{ctx: ctx, RowsScanner: rows}, nil
+}
+
+func queryRowContext(ctx context.Context, backend Backender, query string, args ...interface{}) Scanner {
+	if ctx.Done() == nil {
+		return backend.QueryRow(query, args...)
+	}
+	if b, ok := backend.(ContextBackender); ok {
+		return b.QueryRowContext(ctx, query, args...)
+	}
+	if err := ctx.Err(); err != nil {
+		return &errorScanner{err}
+	}
+	return backend.QueryRow(query, args...)
+}
+
+// contextRows stops iteration over the wrapped rows once the context is done
+type contextRows struct {
+	ctx context.Context
+	RowsScanner
+}
+
+func (r *contextRows) Next() bool {
+	if r.ctx.Err() != nil {
+		return false
+	}
+	return r.RowsScanner.Next()
+}
+
+func (r *contextRows) Scan(dest ...interface{}) error {
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+	return r.RowsScanner.Scan(dest...)
+}
+
+func (r *contextRows) Err() error {
+	if err := r.ctx.Err(); err != nil {
+		return err
+	}
+	return r.RowsScanner.Err()
+}
+
+// execContext runs the command through ExecContext when the backend supports it. Backends that don't are
+// checked for cancellation before the command runs
+func execContext(ctx context.Context, backend Execer, query string, args ...interface{}) (Result, error) {
+	if ctx.Done() == nil {
+		return backend.Exec(query, args...)
+	}
+	if b, ok := backend.(ContextExecer); ok {
+		return b.ExecContext(ctx, query, args...)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return backend.Exec(query, args...)
+}
@@ -0,0 +1,279 @@
+package onedb
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ConversionError occurs when a database value can't be converted into the struct field it is mapped to
+type ConversionError struct {
+	Column   string
+	Field    string
+	SrcType  reflect.Type
+	DestType reflect.Type
+	Err      error
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("onedb: unable to convert column %q (%v) into field %s (%v): %v", e.Column, e.SrcType, e.Field, e.DestType, e.Err)
+}
+
+// Unwrap returns the underlying conversion error
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// UnmappedError occurs in strict mode when query columns or struct fields are left without a match
+type UnmappedError struct {
+	Columns []string
+	Fields  []string
+}
+
+func (e *UnmappedError) Error() string {
+	return fmt.Sprintf("onedb: unmapped columns %v and fields %v", e.Columns, e.Fields)
+}
+
+// timeLayouts are tried in order when a string column is converted into a time.Time
+var timeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02",
+}
+
+// convertAssign stores src in dest, performing safe numeric widening and narrowing, []byte and string
+// conversion and parsing of strings into numbers, bools and times
+func convertAssign(dest reflect.Value, src interface{}) error {
+	if src == nil {
+		switch dest.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+			dest.Set(reflect.Zero(dest.Type()))
+		}
+		return nil
+	}
+
+	srcValue := reflect.ValueOf(src)
+	destType := dest.Type()
+	if srcValue.Type().AssignableTo(destType) {
+		dest.Set(srcValue)
+		return nil
+	}
+
+	if srcValue.Kind() == reflect.Ptr { // a nil pointer is NULL, otherwise the value it points to is stored
+		if srcValue.IsNil() {
+			return convertAssign(dest, nil)
+		}
+		return convertAssign(dest, srcValue.Elem().Interface())
+	}
+	if destType.Kind() == reflect.Ptr {
+		item := reflect.New(destType.Elem())
+		if err := convertAssign(item.Elem(), src); err != nil {
+			return err
+		}
+		dest.Set(item)
+		return nil
+	}
+
+	switch destType.Kind() {
+	case reflect.Bool:
+		return convertBool(dest, srcValue)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return convertInt(dest, srcValue)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return convertUint(dest, srcValue)
+	case reflect.Float32, reflect.Float64:
+		return convertFloat(dest, srcValue)
+	case reflect.String:
+		switch srcValue.Kind() {
+		case reflect.String:
+			dest.SetString(srcValue.String())
+			return nil
+		case reflect.Slice:
+			if srcValue.Type().Elem().Kind() == reflect.Uint8 {
+				dest.SetString(string(srcValue.Bytes()))
+				return nil
+			}
+		}
+	case reflect.Slice:
+		if destType.Elem().Kind() == reflect.Uint8 {
+			switch srcValue.Kind() {
+			case reflect.String:
+				dest.SetBytes([]byte(srcValue.String()))
+				return nil
+			case reflect.Slice:
+				if srcValue.Type().Elem().Kind() == reflect.Uint8 {
+					dest.SetBytes(append([]byte(nil), srcValue.Bytes()...))
+					return nil
+				}
+			}
+		}
+	case reflect.Struct:
+		if destType == timeType {
+			if s, ok := asString(srcValue); ok {
+				t, err := parseTime(s)
+				if err != nil {
+					return err
+				}
+				dest.Set(reflect.ValueOf(t))
+				return nil
+			}
+		}
+	}
+
+	if srcValue.Kind() == destType.Kind() && srcValue.Type().ConvertibleTo(destType) {
+		dest.Set(srcValue.Convert(destType))
+		return nil
+	}
+	return fmt.Errorf("incompatible types")
+}
+
+func convertBool(dest, src reflect.Value) error {
+	switch src.Kind() {
+	case reflect.Bool:
+		dest.SetBool(src.Bool())
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return setBoolFromNumber(dest, src.Int() != 0, src.Int() == 0 || src.Int() == 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return setBoolFromNumber(dest, src.Uint() != 0, src.Uint() <= 1)
+	}
+	if s, ok := asString(src); ok {
+		b, err := strconv.ParseBool(strings.TrimSpace(s))
+		if err != nil {
+			return err
+		}
+		dest.SetBool(b)
+		return nil
+	}
+	return fmt.Errorf("incompatible types")
+}
+
+func setBoolFromNumber(dest reflect.Value, value, valid bool) error {
+	if !valid {
+		return fmt.Errorf("only 0 and 1 can be converted to bool")
+	}
+	dest.SetBool(value)
+	return nil
+}
+
+func convertInt(dest, src reflect.Value) error {
+	var i int64
+	switch src.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i = src.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u := src.Uint()
+		if u > math.MaxInt64 {
+			return fmt.Errorf("value %d overflows %v", u, dest.Type())
+		}
+		i = int64(u)
+	case reflect.Float32, reflect.Float64:
+		f := src.Float()
+		if f != math.Trunc(f) || f < math.MinInt64 || f >= math.MaxInt64 {
+			return fmt.Errorf("value %v can't be stored in %v without losing precision", f, dest.Type())
+		}
+		i = int64(f)
+	default:
+		s, ok := asString(src)
+		if !ok {
+			return fmt.Errorf("incompatible types")
+		}
+		var err error
+		if i, err = strconv.ParseInt(strings.TrimSpace(s), 10, 64); err != nil {
+			return err
+		}
+	}
+	if dest.OverflowInt(i) {
+		return fmt.Errorf("value %d overflows %v", i, dest.Type())
+	}
+	dest.SetInt(i)
+	return nil
+}
+
+func convertUint(dest, src reflect.Value) error {
+	var u uint64
+	switch src.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i := src.Int()
+		if i < 0 {
+			return fmt.Errorf("negative value %d can't be stored in %v", i, dest.Type())
+		}
+		u = uint64(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u = src.Uint()
+	case reflect.Float32, reflect.Float64:
+		f := src.Float()
+		if f != math.Trunc(f) || f < 0 || f >= math.MaxUint64 {
+			return fmt.Errorf("value %v can't be stored in %v without losing precision", f, dest.Type())
+		}
+		u = uint64(f)
+	default:
+		s, ok := asString(src)
+		if !ok {
+			return fmt.Errorf("incompatible types")
+		}
+		var err error
+		if u, err = strconv.ParseUint(strings.TrimSpace(s), 10, 64); err != nil {
+			return err
+		}
+	}
+	if dest.OverflowUint(u) {
+		return fmt.Errorf("value %d overflows %v", u, dest.Type())
+	}
+	dest.SetUint(u)
+	return nil
+}
+
+func convertFloat(dest, src reflect.Value) error {
+	var f float64
+	switch src.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		f = float64(src.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		f = float64(src.Uint())
+	case reflect.Float32, reflect.Float64:
+		f = src.Float()
+	default:
+		s, ok := asString(src)
+		if !ok {
+			return fmt.Errorf("incompatible types")
+		}
+		var err error
+		if f, err = strconv.ParseFloat(strings.TrimSpace(s), 64); err != nil {
+			return err
+		}
+	}
+	if dest.OverflowFloat(f) {
+		return fmt.Errorf("value %v overflows %v", f, dest.Type())
+	}
+	dest.SetFloat(f)
+	return nil
+}
+
+// asString returns the text of a string or []byte value
+func asString(v reflect.Value) (string, bool) {
+	switch v.Kind() {
+	case reflect.String:
+		return v.String(), true
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes()), true
+		}
+	}
+	return "", false
+}
+
+func parseTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unable to parse time %q", s)
+}
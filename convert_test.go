@@ -0,0 +1,153 @@
+package onedb
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type ConvertStruct struct {
+	Int8    int8
+	Int32   int32
+	Uint16  uint16
+	Float32 float32
+	Float64 float64
+	Bool    bool
+	String  string
+	Bytes   []byte
+	Time    time.Time
+	TimePtr *time.Time
+	IntPtr  *int
+}
+
+func TestConvertAssign(t *testing.T) {
+	date := time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		field    string
+		src      interface{}
+		expected interface{}
+	}{
+		{"Int8", int64(12), int8(12)},
+		{"Int8", float64(12), int8(12)},
+		{"Int8", "12", int8(12)},
+		{"Int32", []byte("-42"), int32(-42)},
+		{"Int32", uint8(7), int32(7)},
+		{"Uint16", int64(65535), uint16(65535)},
+		{"Float32", int64(3), float32(3)},
+		{"Float64", "1.5", float64(1.5)},
+		{"Float64", []byte("2.25"), float64(2.25)},
+		{"Bool", int64(1), true},
+		{"Bool", "true", true},
+		{"Bool", []byte("0"), false},
+		{"String", []byte("mysql string"), "mysql string"},
+		{"Bytes", "bytes", []byte("bytes")},
+		{"Time", "2000-01-02 03:04:05", date},
+		{"Time", []byte("2000-01-02T03:04:05Z"), date},
+		{"TimePtr", "2000-01-02 03:04:05", date},
+		{"IntPtr", int64(5), 5},
+	}
+	for _, test := range tests {
+		item := &ConvertStruct{}
+		dest := reflect.ValueOf(item).Elem().FieldByName(test.field)
+		if err := convertAssign(dest, test.src); err != nil {
+			t.Errorf("expected %v to convert into %s: %v", test.src, test.field, err)
+			continue
+		}
+		if actual := getInterfaceValue(dest); !reflect.DeepEqual(actual, test.expected) {
+			t.Errorf("expected %s to be %v. Actual: %v", test.field, test.expected, actual)
+		}
+	}
+}
+
+func TestConvertAssignPointerSource(t *testing.T) {
+	price, ratio, id := 1.5, float32(0.25), int64(7)
+	item := &ConvertStruct{Float64: 9, IntPtr: new(int)}
+	fields := reflect.ValueOf(item).Elem()
+	if err := convertAssign(fields.FieldByName("Float64"), &price); err != nil || item.Float64 != 1.5 {
+		t.Error("expected *float64 to be stored in a float64 field", item.Float64, err)
+	}
+	if err := convertAssign(fields.FieldByName("Float32"), &ratio); err != nil || item.Float32 != 0.25 {
+		t.Error("expected *float32 to be stored in a float32 field", item.Float32, err)
+	}
+	if err := convertAssign(fields.FieldByName("Int32"), &id); err != nil || item.Int32 != 7 {
+		t.Error("expected *int64 to be converted into an int32 field", item.Int32, err)
+	}
+	if err := convertAssign(fields.FieldByName("IntPtr"), &id); err != nil || item.IntPtr == nil || *item.IntPtr != 7 {
+		t.Error("expected *int64 to be stored in an *int field", item.IntPtr, err)
+	}
+
+	// a nil pointer is NULL
+	if err := convertAssign(fields.FieldByName("Float64"), (*float64)(nil)); err != nil || item.Float64 != 1.5 {
+		t.Error("expected nil pointer to leave a non pointer field alone", item.Float64, err)
+	}
+	if err := convertAssign(fields.FieldByName("IntPtr"), (*int64)(nil)); err != nil || item.IntPtr != nil {
+		t.Error("expected nil pointer to set a pointer field to nil", item.IntPtr, err)
+	}
+}
+
+func TestConvertAssignErrors(t *testing.T) {
+	tests := []struct {
+		field string
+		src   interface{}
+	}{
+		{"Int8", int64(128)},
+		{"Int8", float64(1.5)},
+		{"Int8", "abc"},
+		{"Uint16", int64(-1)},
+		{"Uint16", int64(65536)},
+		{"Float32", float64(1e300)},
+		{"Bool", int64(2)},
+		{"Bool", "maybe"},
+		{"String", 12},
+		{"Time", "not a time"},
+		{"Int32", time.Now()},
+	}
+	for _, test := range tests {
+		item := &ConvertStruct{}
+		dest := reflect.ValueOf(item).Elem().FieldByName(test.field)
+		if err := convertAssign(dest, test.src); err == nil {
+			t.Errorf("expected error converting %v (%T) into %s", test.src, test.src, test.field)
+		}
+	}
+}
+
+func TestScanStructConversionError(t *testing.T) {
+	type source struct {
+		Value string
+	}
+	type destination struct {
+		Value int
+	}
+	result := []destination{}
+	err := getStruct(NewRowsScanner([]source{{"abc"}}), &result)
+	var convErr *ConversionError
+	if !errors.As(err, &convErr) || convErr.Column != "Value" || convErr.Field != "Value" || convErr.SrcType != reflect.TypeOf("") || convErr.DestType != reflect.TypeOf(0) {
+		t.Fatal("expected conversion error naming the column and field", err)
+	}
+}
+
+func TestGetStructStrict(t *testing.T) {
+	type partial struct {
+		IntVal  int
+		Missing string
+	}
+	result := []partial{}
+	rows := NewRowsScanner([]SimpleData{{1, "hello"}})
+	if err := getStructWithOptions(rows, &result, StructOptions{}); err != nil || len(result) != 1 {
+		t.Error("expected unmapped columns to be ignored", err)
+	}
+
+	rows = NewRowsScanner([]SimpleData{{1, "hello"}})
+	err := getStructWithOptions(rows, &result, StructOptions{Strict: true})
+	var unmapped *UnmappedError
+	if !errors.As(err, &unmapped) || len(unmapped.Columns) != 1 || unmapped.Columns[0] != "StringVal" || len(unmapped.Fields) != 1 || unmapped.Fields[0] != "Missing" {
+		t.Error("expected unmapped column and field", err)
+	}
+
+	row := SimpleData{}
+	rows = NewRowsScanner([]SimpleData{{1, "hello"}})
+	if err := getStructRowWithOptions(rows, &row, StructOptions{Strict: true}); err != nil {
+		t.Error("expected fully mapped struct to succeed", err)
+	}
+}
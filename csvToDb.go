@@ -0,0 +1,221 @@
+package onedb
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// defaultImportBatchSize is the number of rows sent in each INSERT when CSVImportOptions.BatchSize isn't set
+const defaultImportBatchSize = 500
+
+// maxImportParameters keeps batched INSERTs under the 2100 parameter limit of SQL Server
+const maxImportParameters = 2000
+
+// maxSQLServerImportRows is the most rows SQL Server accepts in the VALUES list of a single INSERT
+const maxSQLServerImportRows = 1000
+
+// CSVImportOptions contains specifications for how a CSV file is read and loaded into a table
+type CSVImportOptions struct {
+	Delimiter rune                                            // field separator. Defaults to a comma
+	Columns   map[string]string                               // column to load each header into. Headers not listed load into the column of the same name
+	Strict    bool                                            // only load headers listed in Columns. Any other header that isn't skipped is an error
+	Skip      []string                                        // headers whose values aren't loaded
+	Null      string                                          // field text that is loaded as NULL. Empty fields load as empty strings unless this is set
+	BatchSize int                                             // rows per INSERT statement. Defaults to 500
+	Parse     func(column, value string) (interface{}, error) // converts field text into the value loaded into column. Defaults to the text itself
+}
+
+// CSVSource reads the rows of a CSV file with a header row. It has the Next, Values and Err methods required
+// by pgx CopyFrom
+type CSVSource struct {
+	reader  *csv.Reader
+	columns []string
+	indexes []int
+	options CSVImportOptions
+	values  []interface{}
+	line    int
+	err     error
+}
+
+// NewCSVSource reads the header row of r and maps each header to the column it loads into. Column names must be
+// plain identifiers because they are written into the INSERT statement
+func NewCSVSource(r io.Reader, options CSVImportOptions) (*CSVSource, error) {
+	reader := csv.NewReader(r)
+	reader.Comma = getCSVDelimiter(options.Delimiter)
+	reader.ReuseRecord = true
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, ErrNoColumns
+	} else if err != nil {
+		return nil, err
+	}
+
+	s := &CSVSource{reader: reader, options: options, line: 1}
+	for i, name := range header {
+		if indexOfColumn(options.Skip, name) != -1 {
+			continue
+		}
+		column, ok := options.Columns[name]
+		if ok {
+			name = column
+		} else if options.Strict {
+			return nil, fmt.Errorf("onedb: header %q is not in Columns", name)
+		}
+		if !isIdentifier(name) {
+			return nil, fmt.Errorf("onedb: invalid column name %q", name)
+		}
+		s.columns = append(s.columns, name)
+		s.indexes = append(s.indexes, i)
+	}
+	if len(s.columns) == 0 {
+		return nil, ErrNoColumns
+	}
+	return s, nil
+}
+
+// Columns returns the names of the columns that each row loads into
+func (s *CSVSource) Columns() []string {
+	return s.columns
+}
+
+// Next reads the next row so that it is available from Values. It returns false at the end of the file or when
+// an error occurs. Check Err to distinguish between the two
+func (s *CSVSource) Next() bool {
+	if s.err != nil {
+		return false
+	}
+	record, err := s.reader.Read()
+	if err == io.EOF {
+		return false
+	} else if err != nil {
+		s.err = err
+		return false
+	}
+	s.line++
+
+	values := make([]interface{}, len(s.indexes))
+	for i, index := range s.indexes {
+		if index >= len(record) {
+			s.err = fmt.Errorf("onedb: line %d is missing column %s", s.line, s.columns[i])
+			return false
+		}
+		if values[i], err = s.parse(s.columns[i], record[index]); err != nil {
+			s.err = fmt.Errorf("onedb: line %d column %s: %v", s.line, s.columns[i], err)
+			return false
+		}
+	}
+	s.values = values
+	return true
+}
+
+func (s *CSVSource) parse(column, field string) (interface{}, error) {
+	if s.options.Null != "" && field == s.options.Null {
+		return nil, nil
+	}
+	if s.options.Parse != nil {
+		return s.options.Parse(column, field)
+	}
+	return field, nil
+}
+
+// Values returns the values of the row read by the last call to Next
+func (s *CSVSource) Values() ([]interface{}, error) {
+	return s.values, s.err
+}
+
+// Err returns the first error that occurred while reading
+func (s *CSVSource) Err() error {
+	return s.err
+}
+
+// ImportCSV loads the rows of a CSV file with a header row into table using batched INSERT statements built for
+// the backend's Dialect. The table name may be schema qualified but each part must be a plain identifier. It returns
+// the number of rows loaded
+func ImportCSV(backend Execer, table string, r io.Reader, options CSVImportOptions) (int64, error) {
+	return ImportCSVContext(context.Background(), backend, table, r, options)
+}
+
+// ImportCSVContext is ImportCSV with a context that stops the import when done
+func ImportCSVContext(ctx context.Context, backend Execer, table string, r io.Reader, options CSVImportOptions) (int64, error) {
+	if !isTableName(table) {
+		return 0, fmt.Errorf("onedb: invalid table name %q", table)
+	}
+	source, err := NewCSVSource(r, options)
+	if err != nil {
+		return 0, err
+	}
+	dialect := getDialect(backend)
+	batchSize := getImportBatchSize(dialect, options.BatchSize, len(source.Columns()))
+
+	var count int64
+	batch := make([][]interface{}, 0, batchSize)
+	flush := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+		query := insertRowsQuery(dialect, table, source.Columns(), batch)
+		if _, err := execContext(ctx, backend, query.Query, query.Args...); err != nil {
+			return err
+		}
+		count += int64(len(batch))
+		batch = batch[:0]
+		return nil
+	}
+
+	for source.Next() {
+		values, _ := source.Values()
+		batch = append(batch, values)
+		if len(batch) == batchSize {
+			if err := flush(); err != nil {
+				return count, err
+			}
+		}
+	}
+	if err := source.Err(); err != nil {
+		return count, err
+	}
+	return count, flush()
+}
+
+func getImportBatchSize(dialect Dialect, batchSize, columns int) int {
+	if batchSize <= 0 {
+		batchSize = defaultImportBatchSize
+	}
+	if dialect == SQLServer && batchSize > maxSQLServerImportRows {
+		batchSize = maxSQLServerImportRows
+	}
+	if batchSize*columns > maxImportParameters {
+		batchSize = maxImportParameters / columns
+	}
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	return batchSize
+}
+
+// isTableName allows a plain identifier optionally qualified by a schema, such as dbo.people
+func isTableName(name string) bool {
+	for _, part := range strings.Split(name, ".") {
+		if !isIdentifier(part) {
+			return false
+		}
+	}
+	return true
+}
+
+func insertRowsQuery(dialect Dialect, table string, columns []string, rows [][]interface{}) *Query {
+	args := make([]interface{}, 0, len(columns)*len(rows))
+	values := make([]string, len(rows))
+	for i, row := range rows {
+		placeholders := make([]string, len(row))
+		for j, value := range row {
+			args = append(args, value)
+			placeholders[j] = dialect.Placeholder(len(args))
+		}
+		values[i] = "(" + strings.Join(placeholders, ", ") + ")"
+	}
+	return NewQuery(fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", table, strings.Join(columns, ", "), strings.Join(values, ", ")), args...)
+}
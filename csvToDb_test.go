@@ -0,0 +1,94 @@
+package onedb
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestImportCSV(t *testing.T) {
+	db := NewMock(nil, nil)
+	csv := "Name,Age,Skip\nbob,30,x\nsue,NULL,y\ntom,40,z\n"
+	count, err := ImportCSV(db, "people", strings.NewReader(csv), CSVImportOptions{
+		Columns:   map[string]string{"Name": "user_name"},
+		Skip:      []string{"skip"},
+		Null:      "NULL",
+		BatchSize: 2,
+		Parse: func(column, value string) (interface{}, error) {
+			if column == "Age" {
+				return strconv.Atoi(value)
+			}
+			return value, nil
+		},
+	})
+	if err != nil || count != 3 {
+		t.Fatal("expected 3 rows", count, err)
+	}
+	db.VerifyNextCommand(t, "Exec", "INSERT INTO people (user_name, Age) VALUES (?, ?), (?, ?)", "bob", 30, "sue", nil)
+	db.VerifyNextCommand(t, "Exec", "INSERT INTO people (user_name, Age) VALUES (?, ?)", "tom", 40)
+}
+
+func TestImportCSVErrors(t *testing.T) {
+	db := NewMock(nil, nil)
+	if _, err := ImportCSV(db, "people", strings.NewReader(""), CSVImportOptions{}); err != ErrNoColumns {
+		t.Error("expected no columns error", err)
+	}
+	if _, err := ImportCSV(db, "people", strings.NewReader("a\n1\n"), CSVImportOptions{Skip: []string{"a"}}); err != ErrNoColumns {
+		t.Error("expected no columns error", err)
+	}
+	if _, err := ImportCSV(db, "people", strings.NewReader("a,b\n1,2\n3\n"), CSVImportOptions{}); err == nil {
+		t.Error("expected short row error")
+	}
+	parse := func(column, value string) (interface{}, error) { return nil, errors.New("fail") }
+	if _, err := ImportCSV(db, "people", strings.NewReader("a\n1\n"), CSVImportOptions{Parse: parse}); err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Error("expected parse error with line number", err)
+	}
+
+	failDb := NewMock(nil, errors.New("fail"))
+	if count, err := ImportCSV(failDb, "people", strings.NewReader("a\n1\n"), CSVImportOptions{}); err == nil || count != 0 {
+		t.Error("expected exec error", err)
+	}
+}
+
+func TestImportCSVColumnNames(t *testing.T) {
+	db := NewMock(nil, nil)
+	header := "name,\"x) VALUES (1); DROP TABLE people; --\"\nbob,1\n"
+	if _, err := ImportCSV(db, "people", strings.NewReader(header), CSVImportOptions{}); err == nil || !strings.Contains(err.Error(), "invalid column name") {
+		t.Error("expected malicious header to be rejected", err)
+	}
+	if _, err := ImportCSV(db, "people", strings.NewReader("name\nbob\n"), CSVImportOptions{Columns: map[string]string{"name": "a b"}}); err == nil {
+		t.Error("expected invalid mapped column to be rejected")
+	}
+	for _, table := range []string{"people; DROP TABLE people", "", "dbo.", "a b"} {
+		if _, err := ImportCSV(db, table, strings.NewReader("name\nbob\n"), CSVImportOptions{}); err == nil || !strings.Contains(err.Error(), "invalid table name") {
+			t.Error("expected invalid table name to be rejected", table, err)
+		}
+	}
+	if len(db.QueriesRun()) != 0 {
+		t.Error("expected nothing to be inserted", db.QueriesRun())
+	}
+
+	strict := CSVImportOptions{Columns: map[string]string{"Name": "user_name"}, Skip: []string{"Skip"}, Strict: true}
+	if _, err := ImportCSV(db, "people", strings.NewReader("Name,Age\nbob,30\n"), strict); err == nil || !strings.Contains(err.Error(), "Age") {
+		t.Error("expected header missing from Columns to be rejected", err)
+	}
+	if count, err := ImportCSV(db, "people", strings.NewReader("Name,Skip\nbob,x\n"), strict); err != nil || count != 1 {
+		t.Fatal("expected 1 row", count, err)
+	}
+	db.VerifyNextCommand(t, "Exec", "INSERT INTO people (user_name) VALUES (?)", "bob")
+
+	if _, err := ImportCSV(db, "dbo.people", strings.NewReader("name\nbob\n"), CSVImportOptions{}); err != nil {
+		t.Error("expected schema qualified table name", err)
+	}
+	db.VerifyNextCommand(t, "Exec", "INSERT INTO dbo.people (name) VALUES (?)", "bob")
+}
+
+func TestImportBatchSize(t *testing.T) {
+	if getImportBatchSize(MySQL, 0, 2) != defaultImportBatchSize || getImportBatchSize(MySQL, 1000, 10) != 200 || getImportBatchSize(MySQL, 10, 5000) != 1 {
+		t.Error("expected batch sizes to respect the parameter limit")
+	}
+	if getImportBatchSize(SQLServer, 5000, 1) != maxSQLServerImportRows || getImportBatchSize(MySQL, 5000, 1) != 2000 {
+		t.Error("expected SQL Server batches to be capped at 1000 rows")
+	}
+}
@@ -0,0 +1,246 @@
+package onedb
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrNoItems occurs when Insert is called without any structs to insert
+var ErrNoItems = errors.New("at least one struct is required")
+
+// ErrNoKeyFields occurs when Update or Delete are called without any key fields to identify the row
+var ErrNoKeyFields = errors.New("at least one key field is required")
+
+// Insert adds one row to table for each of the provided structs (or pointers to structs) using a single INSERT
+// statement. Columns are named using the same mapping QueryStruct uses and fields tagged `db:"name,auto"` are
+// left for the database to generate. All items must be of the same type
+func Insert(backend Execer, table string, items ...interface{}) (Result, error) {
+	return InsertContext(context.Background(), backend, table, items...)
+}
+
+// InsertContext is Insert with a context that aborts the command when done
+func InsertContext(ctx context.Context, backend Execer, table string, items ...interface{}) (Result, error) {
+	query, err := InsertQuery(getDialect(backend), table, items...)
+	if err != nil {
+		return nil, err
+	}
+	return execContext(ctx, backend, query.Query, query.Args...)
+}
+
+// Update sets every mapped column of the row in table identified by keyFields to the values in item. keyFields
+// are Go field names or column names
+func Update(backend Execer, table string, item interface{}, keyFields ...string) (Result, error) {
+	return UpdateContext(context.Background(), backend, table, item, keyFields...)
+}
+
+// UpdateContext is Update with a context that aborts the command when done
+func UpdateContext(ctx context.Context, backend Execer, table string, item interface{}, keyFields ...string) (Result, error) {
+	query, err := UpdateQuery(getDialect(backend), table, item, keyFields...)
+	if err != nil {
+		return nil, err
+	}
+	return execContext(ctx, backend, query.Query, query.Args...)
+}
+
+// Delete removes the row in table identified by the keyFields of item. keyFields are Go field names or
+// column names
+func Delete(backend Execer, table string, item interface{}, keyFields ...string) (Result, error) {
+	return DeleteContext(context.Background(), backend, table, item, keyFields...)
+}
+
+// DeleteContext is Delete with a context that aborts the command when done
+func DeleteContext(ctx context.Context, backend Execer, table string, item interface{}, keyFields ...string) (Result, error) {
+	query, err := DeleteQuery(getDialect(backend), table, item, keyFields...)
+	if err != nil {
+		return nil, err
+	}
+	return execContext(ctx, backend, query.Query, query.Args...)
+}
+
+// InsertQuery builds the INSERT statement used by Insert without running it
+func InsertQuery(dialect Dialect, table string, items ...interface{}) (*Query, error) {
+	if len(items) == 0 {
+		return nil, ErrNoItems
+	}
+	itemType, err := getWriteType(items[0])
+	if err != nil {
+		return nil, err
+	}
+	columns := getWriteColumns(itemType, true)
+	if len(columns) == 0 {
+		return nil, ErrNoColumns
+	}
+
+	names := make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name
+	}
+	rows := make([][]interface{}, len(items))
+	for i, item := range items {
+		value, err := getWriteValue(item, itemType)
+		if err != nil {
+			return nil, err
+		}
+		rows[i] = make([]interface{}, len(columns))
+		for j, column := range columns {
+			rows[i][j] = getColumnArg(value, column)
+		}
+	}
+	return insertRowsQuery(dialect, table, names, rows), nil
+}
+
+// UpdateQuery builds the UPDATE statement used by Update without running it
+func UpdateQuery(dialect Dialect, table string, item interface{}, keyFields ...string) (*Query, error) {
+	itemType, err := getWriteType(item)
+	if err != nil {
+		return nil, err
+	}
+	value, err := getWriteValue(item, itemType)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := getKeyColumns(itemType, keyFields)
+	if err != nil {
+		return nil, err
+	}
+
+	var args []interface{}
+	var sets []string
+	for _, column := range getWriteColumns(itemType, true) {
+		if isKeyColumn(column, keys) {
+			continue
+		}
+		args = append(args, getColumnArg(value, column))
+		sets = append(sets, column.Name+" = "+dialect.Placeholder(len(args)))
+	}
+	if len(sets) == 0 {
+		return nil, ErrNoColumns
+	}
+	where, args := getWhereClause(dialect, value, keys, args)
+	return NewQuery(fmt.Sprintf("UPDATE %s SET %s WHERE %s", table, strings.Join(sets, ", "), where), args...), nil
+}
+
+// DeleteQuery builds the DELETE statement used by Delete without running it
+func DeleteQuery(dialect Dialect, table string, item interface{}, keyFields ...string) (*Query, error) {
+	itemType, err := getWriteType(item)
+	if err != nil {
+		return nil, err
+	}
+	value, err := getWriteValue(item, itemType)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := getKeyColumns(itemType, keyFields)
+	if err != nil {
+		return nil, err
+	}
+	where, args := getWhereClause(dialect, value, keys, nil)
+	return NewQuery(fmt.Sprintf("DELETE FROM %s WHERE %s", table, where), args...), nil
+}
+
+func getWriteType(item interface{}) (reflect.Type, error) {
+	itemType := reflect.TypeOf(item)
+	if itemType != nil && itemType.Kind() == reflect.Ptr {
+		itemType = itemType.Elem()
+	}
+	if itemType == nil || itemType.Kind() != reflect.Struct {
+		return nil, ErrTypeNotStruct
+	}
+	return itemType, nil
+}
+
+func getWriteValue(item interface{}, itemType reflect.Type) (reflect.Value, error) {
+	value := reflect.ValueOf(item)
+	if value.Kind() == reflect.Ptr {
+		if value.IsNil() {
+			return reflect.Value{}, ErrTypeNotStruct
+		}
+		value = value.Elem()
+	}
+	if value.Type() != itemType {
+		return reflect.Value{}, fmt.Errorf("onedb: all items must be of type %v. Found %v", itemType, value.Type())
+	}
+	return value, nil
+}
+
+// getWriteColumns returns the columns of the struct type with duplicate column names removed. The shallowest
+// field wins, just as it does when reading. Columns are written under the name reads match first: the db tag or the
+// field name, with any prefix= of nested structs in front
+func getWriteColumns(itemType reflect.Type, skipAuto bool) []structColumn {
+	var columns []structColumn
+	seen := make(map[string]bool)
+	for _, column := range getStructColumns(itemType) {
+		name := strings.ToLower(column.Name)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		if skipAuto && column.Auto {
+			continue
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// getKeyColumns finds the columns named by keyFields, matching Go field names first and then column names
+func getKeyColumns(itemType reflect.Type, keyFields []string) ([]structColumn, error) {
+	if len(keyFields) == 0 {
+		return nil, ErrNoKeyFields
+	}
+	columns := getWriteColumns(itemType, false)
+	keys := make([]structColumn, 0, len(keyFields))
+	for _, keyField := range keyFields {
+		column, ok := findColumn(columns, keyField)
+		if !ok {
+			return nil, fmt.Errorf("onedb: key field %s not found in %v", keyField, itemType)
+		}
+		keys = append(keys, column)
+	}
+	return keys, nil
+}
+
+func findColumn(columns []structColumn, name string) (structColumn, bool) {
+	for _, column := range columns {
+		if column.Field == name {
+			return column, true
+		}
+	}
+	for _, column := range columns {
+		if strings.EqualFold(column.Name, name) {
+			return column, true
+		}
+	}
+	return structColumn{}, false
+}
+
+func isKeyColumn(column structColumn, keys []structColumn) bool {
+	for _, key := range keys {
+		if key.Field == column.Field {
+			return true
+		}
+	}
+	return false
+}
+
+func getWhereClause(dialect Dialect, value reflect.Value, keys []structColumn, args []interface{}) (string, []interface{}) {
+	conditions := make([]string, len(keys))
+	for i, key := range keys {
+		args = append(args, getColumnArg(value, key))
+		conditions[i] = key.Name + " = " + dialect.Placeholder(len(args))
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// getColumnArg returns the value of the column's field or nil if a nested struct pointer on the way is nil
+func getColumnArg(value reflect.Value, column structColumn) interface{} {
+	field, ok := readFieldByIndex(value, column.Index)
+	if !ok {
+		return nil
+	}
+	return field.Interface()
+}
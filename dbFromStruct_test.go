@@ -0,0 +1,129 @@
+package onedb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+type writeAddress struct {
+	Street string
+	City   string
+}
+
+type writeData struct {
+	ID      int    `db:"id,auto"`
+	Name    string `db:"user_name"`
+	Email   string
+	Ignored string        `db:"-"`
+	Home    *writeAddress `db:"home,prefix=home_"`
+}
+
+func TestInsertQuery(t *testing.T) {
+	items := []interface{}{
+		writeData{ID: 1, Name: "bob", Email: "bob@example.com", Home: &writeAddress{"Main", "Town"}},
+		&writeData{Name: "sue", Email: "sue@example.com"},
+	}
+	tests := []struct {
+		dialect  Dialect
+		expected string
+	}{
+		{MySQL, "INSERT INTO users (user_name, Email, home_Street, home_City) VALUES (?, ?, ?, ?), (?, ?, ?, ?)"},
+		{PostgreSQL, "INSERT INTO users (user_name, Email, home_Street, home_City) VALUES ($1, $2, $3, $4), ($5, $6, $7, $8)"},
+		{SQLServer, "INSERT INTO users (user_name, Email, home_Street, home_City) VALUES (@p1, @p2, @p3, @p4), (@p5, @p6, @p7, @p8)"},
+	}
+	for _, test := range tests {
+		query, err := InsertQuery(test.dialect, "users", items...)
+		if err != nil || query.Query != test.expected {
+			t.Errorf("expected %s query %q. Actual: %v %v", test.dialect, test.expected, query, err)
+		}
+	}
+
+	query, _ := InsertQuery(MySQL, "users", items...)
+	expected := []interface{}{"bob", "bob@example.com", "Main", "Town", "sue", "sue@example.com", nil, nil}
+	if !reflect.DeepEqual(query.Args, expected) {
+		t.Error("expected args", query.Args)
+	}
+
+	if _, err := InsertQuery(MySQL, "users"); err != ErrNoItems {
+		t.Error("expected no items error", err)
+	}
+	if _, err := InsertQuery(MySQL, "users", "string"); err != ErrTypeNotStruct {
+		t.Error("expected type error", err)
+	}
+	if _, err := InsertQuery(MySQL, "users", writeData{}, SimpleData{}); err == nil {
+		t.Error("expected mixed type error")
+	}
+}
+
+func TestUpdateQuery(t *testing.T) {
+	item := &writeData{ID: 5, Name: "bob", Email: "bob@example.com"}
+	query, err := UpdateQuery(PostgreSQL, "users", item, "ID")
+	if err != nil || query.Query != "UPDATE users SET user_name = $1, Email = $2, home_Street = $3, home_City = $4 WHERE id = $5" ||
+		!reflect.DeepEqual(query.Args, []interface{}{"bob", "bob@example.com", nil, nil, 5}) {
+		t.Error("expected update query", query, err)
+	}
+
+	// key by column name and compound keys
+	query, err = UpdateQuery(MySQL, "users", item, "id", "user_name")
+	if err != nil || query.Query != "UPDATE users SET Email = ?, home_Street = ?, home_City = ? WHERE id = ? AND user_name = ?" {
+		t.Error("expected compound key update", query, err)
+	}
+
+	if _, err := UpdateQuery(MySQL, "users", item); err != ErrNoKeyFields {
+		t.Error("expected key error", err)
+	}
+	if _, err := UpdateQuery(MySQL, "users", item, "Missing"); err == nil {
+		t.Error("expected missing key error")
+	}
+	if _, err := UpdateQuery(MySQL, "users", (*writeData)(nil), "ID"); err == nil {
+		t.Error("expected nil item error")
+	}
+}
+
+func TestDeleteQuery(t *testing.T) {
+	query, err := DeleteQuery(SQLServer, "dbo.users", writeData{ID: 5}, "ID")
+	if err != nil || query.Query != "DELETE FROM dbo.users WHERE id = @p1" || !reflect.DeepEqual(query.Args, []interface{}{5}) {
+		t.Error("expected delete query", query, err)
+	}
+	if _, err := DeleteQuery(MySQL, "users", writeData{}); err != ErrNoKeyFields {
+		t.Error("expected key error", err)
+	}
+}
+
+func TestInsertUpdateDelete(t *testing.T) {
+	db := NewMock(nil, nil, NewResult(7, 1))
+	result, err := Insert(db, "users", writeData{Name: "bob"})
+	if id, _ := result.LastInsertId(); err != nil || id != 7 {
+		t.Error("expected insert result", err)
+	}
+	db.VerifyNextCommand(t, "Exec", "INSERT INTO users (user_name, Email, home_Street, home_City) VALUES (?, ?, ?, ?)", "bob", "", nil, nil)
+
+	if _, err = Update(db, "users", writeData{ID: 7, Name: "bob"}, "ID"); err != nil {
+		t.Error("expected update success", err)
+	}
+	db.VerifyNextCommand(t, "Exec", "UPDATE users SET user_name = ?, Email = ?, home_Street = ?, home_City = ? WHERE id = ?", "bob", "", nil, nil, 7)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	if _, err = DeleteContext(ctx, db, "users", writeData{ID: 7}, "ID"); err != nil {
+		t.Error("expected delete success", err)
+	}
+	db.VerifyNextCommand(t, "ExecContext", "DELETE FROM users WHERE id = ?", 7)
+
+	cancel()
+	if _, err = InsertContext(ctx, db, "users", writeData{}); err == nil {
+		t.Error("expected cancelled context error")
+	}
+	if _, err = Insert(db, "users"); err != ErrNoItems {
+		t.Error("expected error", err)
+	}
+}
+
+func TestDialect(t *testing.T) {
+	if DialectFor("postgres") != PostgreSQL || DialectFor("sqlserver") != SQLServer || DialectFor("mysql") != MySQL {
+		t.Error("expected dialects by driver name")
+	}
+	if getDialect(&mockBackend{}) != MySQL {
+		t.Error("expected MySQL default")
+	}
+}
@@ -0,0 +1,335 @@
+package onedb
+
+import (
+	"io"
+	"math"
+	"sort"
+)
+
+// ArrowOptions contains specifications for how an Arrow IPC stream is written
+type ArrowOptions struct {
+	BatchSize int // rows per record batch. Defaults to 10000
+}
+
+// Arrow flatbuffer enum values from Schema.fbs and Message.fbs
+const (
+	arrowMetadataV5      = 4
+	arrowHeaderSchema    = 1
+	arrowHeaderRecord    = 3
+	arrowTypeInt         = 2
+	arrowTypeFloat       = 3
+	arrowTypeBinary      = 4
+	arrowTypeUtf8        = 5
+	arrowTypeBool        = 6
+	arrowTypeDecimal     = 7
+	arrowTypeTimestamp   = 10
+	arrowPrecisionDouble = 2
+	arrowMicrosecond     = 2
+)
+
+// writeArrow writes the rows in the Arrow IPC streaming format: a schema message followed by one record batch
+// message per BatchSize rows and an end of stream marker
+func writeArrow(rows RowsScanner, w io.Writer, options ArrowOptions) error {
+	columns, vals, err := getColumnNamesAndValues(rows, false)
+	if err != nil {
+		return err
+	}
+	size := options.BatchSize
+	if size <= 0 {
+		size = defaultBatchSize
+	}
+
+	var schema []columnSchema
+	for {
+		batch, err := readColumnarBatch(rows, vals, size)
+		if err != nil {
+			return err
+		}
+		if schema == nil {
+			schema = getColumnarSchema(rows, columns, batch)
+			if err := writeArrowMessage(w, getArrowSchema(schema), nil); err != nil {
+				return err
+			}
+		}
+		if len(batch) == 0 {
+			break
+		}
+		buffers, err := newColumnBuffers(schema, batch)
+		if err != nil {
+			return err
+		}
+		metadata, body := getArrowRecordBatch(buffers, len(batch))
+		if err := writeArrowMessage(w, metadata, body); err != nil {
+			return err
+		}
+		if len(batch) < size {
+			break
+		}
+	}
+	_, err = w.Write([]byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0})
+	return err
+}
+
+// writeArrowMessage writes the continuation marker, the padded metadata length, the metadata and the body
+func writeArrowMessage(w io.Writer, metadata, body []byte) error {
+	for len(metadata)%8 != 0 {
+		metadata = append(metadata, 0)
+	}
+	prefix := appendUint32([]byte{0xff, 0xff, 0xff, 0xff}, uint32(len(metadata)))
+	for _, b := range [][]byte{prefix, metadata, body} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getArrowSchema(schema []columnSchema) []byte {
+	fields := make([]func(b *fbBuilder) int, len(schema))
+	for i, column := range schema {
+		column := column
+		fields[i] = func(b *fbBuilder) int {
+			typeID, typeTable := getArrowType(column)
+			return b.table(
+				fbOffset(0, func(b *fbBuilder) int { return b.string(column.Name) }),
+				fbScalar(1, []byte{1}), // nullable
+				fbScalar(2, []byte{typeID}),
+				fbOffset(3, typeTable),
+				fbOffset(5, func(b *fbBuilder) int { return b.tableVector(nil) }), // children
+			)
+		}
+	}
+	return getArrowMessage(arrowHeaderSchema, 0, func(b *fbBuilder) int {
+		return b.table(
+			fbScalar(0, []byte{0, 0}), // little endian
+			fbOffset(1, func(b *fbBuilder) int { return b.tableVector(fields) }),
+		)
+	})
+}
+
+func getArrowType(column columnSchema) (byte, func(b *fbBuilder) int) {
+	switch column.Kind {
+	case kindBool:
+		return arrowTypeBool, emptyTable
+	case kindInt64:
+		return arrowTypeInt, func(b *fbBuilder) int {
+			return b.table(fbScalar(0, appendUint32(nil, 64)), fbScalar(1, []byte{1}))
+		}
+	case kindFloat64:
+		return arrowTypeFloat, func(b *fbBuilder) int {
+			return b.table(fbScalar(0, []byte{arrowPrecisionDouble, 0}))
+		}
+	case kindTimestamp:
+		return arrowTypeTimestamp, func(b *fbBuilder) int {
+			return b.table(
+				fbScalar(0, []byte{arrowMicrosecond, 0}),
+				fbOffset(1, func(b *fbBuilder) int { return b.string("UTC") }),
+			)
+		}
+	case kindDecimal:
+		return arrowTypeDecimal, func(b *fbBuilder) int {
+			return b.table(
+				fbScalar(0, appendUint32(nil, uint32(column.Precision))),
+				fbScalar(1, appendUint32(nil, uint32(column.Scale))),
+				fbScalar(2, appendUint32(nil, 128)),
+			)
+		}
+	case kindBytes:
+		return arrowTypeBinary, emptyTable
+	}
+	return arrowTypeUtf8, emptyTable
+}
+
+func emptyTable(b *fbBuilder) int {
+	return b.table()
+}
+
+// getArrowRecordBatch lays out the validity, offset and data buffers of each column in the body and returns the
+// RecordBatch metadata that describes them
+func getArrowRecordBatch(buffers []*columnBuffer, length int) ([]byte, []byte) {
+	var body, nodes, layout []byte
+	addBuffer := func(data []byte) {
+		layout = appendUint64(appendUint64(layout, uint64(len(body))), uint64(len(data)))
+		body = append(body, data...)
+		for len(body)%8 != 0 {
+			body = append(body, 0)
+		}
+	}
+	for _, c := range buffers {
+		nodes = appendUint64(appendUint64(nodes, uint64(length)), uint64(c.nullCount))
+		addBuffer(packBits(c.valid, nil))
+		switch c.schema.Kind {
+		case kindBool:
+			addBuffer(packBits(c.bools, nil))
+		case kindInt64, kindTimestamp:
+			data := make([]byte, 0, 8*length)
+			for _, i := range c.ints {
+				data = appendUint64(data, uint64(i))
+			}
+			addBuffer(data)
+		case kindFloat64:
+			data := make([]byte, 0, 8*length)
+			for _, f := range c.floats {
+				data = appendUint64(data, math.Float64bits(f))
+			}
+			addBuffer(data)
+		case kindDecimal:
+			data := make([]byte, 0, 16*length)
+			for _, d := range c.decimals {
+				be := twosComplement(d, 16)
+				for i := len(be) - 1; i >= 0; i-- {
+					data = append(data, be[i])
+				}
+			}
+			addBuffer(data)
+		default:
+			offsets := appendUint32(make([]byte, 0, 4*(length+1)), 0)
+			var data []byte
+			for _, value := range c.bytes {
+				data = append(data, value...)
+				offsets = appendUint32(offsets, uint32(len(data)))
+			}
+			addBuffer(offsets)
+			addBuffer(data)
+		}
+	}
+
+	metadata := getArrowMessage(arrowHeaderRecord, len(body), func(b *fbBuilder) int {
+		return b.table(
+			fbScalar(0, appendUint64(nil, uint64(length))),
+			fbOffset(1, func(b *fbBuilder) int { return b.structVector(nodes, 16) }),
+			fbOffset(2, func(b *fbBuilder) int { return b.structVector(layout, 16) }),
+		)
+	})
+	return metadata, body
+}
+
+func getArrowMessage(headerType byte, bodyLength int, header func(b *fbBuilder) int) []byte {
+	b := &fbBuilder{}
+	b.root(func(b *fbBuilder) int {
+		return b.table(
+			fbScalar(0, []byte{arrowMetadataV5, 0}),
+			fbScalar(1, []byte{headerType}),
+			fbOffset(2, header),
+			fbScalar(3, appendUint64(nil, uint64(bodyLength))),
+		)
+	})
+	return b.buf
+}
+
+// fbBuilder writes FlatBuffers front to back. Tables are written before the objects they reference so that every
+// offset points forward, and each vtable is written immediately before its table
+type fbBuilder struct {
+	buf []byte
+}
+
+// fbField is a table field holding either an inline little endian scalar or an offset to a child object
+type fbField struct {
+	slot   int
+	scalar []byte
+	child  func(b *fbBuilder) int
+}
+
+func fbScalar(slot int, value []byte) fbField {
+	return fbField{slot: slot, scalar: value}
+}
+
+func fbOffset(slot int, child func(b *fbBuilder) int) fbField {
+	return fbField{slot: slot, child: child}
+}
+
+func (f fbField) size() int {
+	if f.child != nil {
+		return 4
+	}
+	return len(f.scalar)
+}
+
+func (b *fbBuilder) align(n int) {
+	for len(b.buf)%n != 0 {
+		b.buf = append(b.buf, 0)
+	}
+}
+
+func (b *fbBuilder) putUint32(pos int, v uint32) {
+	b.buf[pos], b.buf[pos+1], b.buf[pos+2], b.buf[pos+3] = byte(v), byte(v>>8), byte(v>>16), byte(v>>24)
+}
+
+// root writes the offset to the root table at the start of the buffer
+func (b *fbBuilder) root(table func(b *fbBuilder) int) {
+	b.buf = append(b.buf, 0, 0, 0, 0)
+	b.putUint32(0, uint32(table(b)))
+}
+
+func (b *fbBuilder) table(fields ...fbField) int {
+	sorted := append([]fbField(nil), fields...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].size() > sorted[j].size() })
+	slots := 0
+	offsets := make(map[int]int)
+	tableSize := 4 // offset to the vtable
+	for _, field := range sorted {
+		for tableSize%field.size() != 0 {
+			tableSize++
+		}
+		offsets[field.slot] = tableSize
+		tableSize += field.size()
+		if field.slot >= slots {
+			slots = field.slot + 1
+		}
+	}
+
+	b.align(2)
+	vtable := len(b.buf)
+	b.buf = append(b.buf, byte(4+2*slots), byte((4+2*slots)>>8), byte(tableSize), byte(tableSize>>8))
+	for slot := 0; slot < slots; slot++ {
+		offset := offsets[slot]
+		b.buf = append(b.buf, byte(offset), byte(offset>>8))
+	}
+	b.align(8)
+	start := len(b.buf)
+	b.buf = append(b.buf, make([]byte, tableSize)...)
+	b.putUint32(start, uint32(start-vtable))
+	for _, field := range sorted {
+		copy(b.buf[start+offsets[field.slot]:], field.scalar)
+	}
+	for _, field := range sorted {
+		if field.child != nil {
+			pos := start + offsets[field.slot]
+			b.putUint32(pos, uint32(field.child(b)-pos))
+		}
+	}
+	return start
+}
+
+func (b *fbBuilder) string(s string) int {
+	b.align(4)
+	start := len(b.buf)
+	b.buf = appendUint32(b.buf, uint32(len(s)))
+	b.buf = append(b.buf, s...)
+	b.buf = append(b.buf, 0)
+	return start
+}
+
+func (b *fbBuilder) tableVector(tables []func(b *fbBuilder) int) int {
+	b.align(4)
+	start := len(b.buf)
+	b.buf = appendUint32(b.buf, uint32(len(tables)))
+	b.buf = append(b.buf, make([]byte, 4*len(tables))...)
+	for i, table := range tables {
+		pos := start + 4 + 4*i
+		b.putUint32(pos, uint32(table(b)-pos))
+	}
+	return start
+}
+
+// structVector writes a vector of structs whose elements are 8 byte aligned
+func (b *fbBuilder) structVector(data []byte, structSize int) int {
+	b.align(4)
+	if len(b.buf)%8 == 0 {
+		b.buf = append(b.buf, 0, 0, 0, 0)
+	}
+	start := len(b.buf)
+	b.buf = appendUint32(b.buf, uint32(len(data)/structSize))
+	b.buf = append(b.buf, data...)
+	return start
+}
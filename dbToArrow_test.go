@@ -0,0 +1,228 @@
+package onedb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestWriteArrow(t *testing.T) {
+	var b bytes.Buffer
+	if err := writeArrow(NewRowsScanner(getColumnarData()), &b, ArrowOptions{BatchSize: 2}); err != nil {
+		t.Fatal("expected success", err)
+	}
+	stream := b.Bytes()
+	if !bytes.HasSuffix(stream, []byte{0xff, 0xff, 0xff, 0xff, 0, 0, 0, 0}) {
+		t.Fatal("expected end of stream marker")
+	}
+
+	messages := readArrowMessages(t, stream[:len(stream)-8])
+	if len(messages) != 3 {
+		t.Fatal("expected schema and 2 record batches", len(messages))
+	}
+
+	schema := messages[0].metadata
+	if schema.uint16(0) != arrowMetadataV5 || schema.uint8(1) != arrowHeaderSchema {
+		t.Fatal("expected schema message")
+	}
+	fields := schema.table(2).vector(1)
+	if len(fields) != 6 {
+		t.Fatal("expected 6 fields", len(fields))
+	}
+	expected := []struct {
+		name     string
+		typeID   byte
+		children bool
+	}{{"ID", arrowTypeInt, true}, {"Name", arrowTypeUtf8, true}, {"Price", arrowTypeFloat, true}, {"Active", arrowTypeBool, true}, {"Created", arrowTypeTimestamp, true}, {"Data", arrowTypeBinary, true}}
+	for i, e := range expected {
+		if fields[i].string(0) != e.name || fields[i].uint8(1) != 1 || fields[i].uint8(2) != e.typeID || len(fields[i].vector(5)) != 0 {
+			t.Error("expected field", e.name, fields[i].string(0), fields[i].uint8(2))
+		}
+	}
+	if intType := fields[0].table(3); intType.uint32(0) != 64 || intType.uint8(1) != 1 {
+		t.Error("expected signed 64 bit int")
+	}
+	if ts := fields[4].table(3); ts.uint16(0) != arrowMicrosecond || ts.string(1) != "UTC" {
+		t.Error("expected UTC microsecond timestamp")
+	}
+
+	batch := messages[1]
+	if batch.metadata.uint8(1) != arrowHeaderRecord || batch.metadata.uint64(3) != uint64(len(batch.body)) {
+		t.Fatal("expected record batch with body length")
+	}
+	record := batch.metadata.table(2)
+	if record.uint64(0) != 2 {
+		t.Error("expected 2 rows", record.uint64(0))
+	}
+	nodes := record.structs(1, 16)
+	if len(nodes) != 6 || binary.LittleEndian.Uint64(nodes[1][8:]) != 1 {
+		t.Error("expected one NULL in the Name column")
+	}
+	buffers := record.structs(2, 16)
+	body := func(i int) []byte {
+		offset := binary.LittleEndian.Uint64(buffers[i])
+		return batch.body[offset : offset+binary.LittleEndian.Uint64(buffers[i][8:])]
+	}
+	if len(buffers) != 14 {
+		t.Fatal("expected 14 buffers", len(buffers))
+	}
+	if ids := body(1); binary.LittleEndian.Uint64(ids) != 1 || binary.LittleEndian.Uint64(ids[8:]) != 2 {
+		t.Error("expected ids", ids)
+	}
+	if validity, offsets, data := body(2), body(3), body(4); validity[0] != 1 || !bytes.Equal(offsets, []byte{0, 0, 0, 0, 3, 0, 0, 0, 3, 0, 0, 0}) || string(data) != "bob" {
+		t.Error("expected names", validity, offsets, data)
+	}
+	if prices := body(6); math.Float64frombits(binary.LittleEndian.Uint64(prices[8:])) != 2.5 {
+		t.Error("expected prices", prices)
+	}
+	if active := body(8); active[0] != 1 {
+		t.Error("expected bools", active)
+	}
+}
+
+func TestWriteArrowGolden(t *testing.T) {
+	var b bytes.Buffer
+	if err := writeArrow(getColumnarGoldenRows(), &b, ArrowOptions{BatchSize: 2}); err != nil {
+		t.Fatal("expected success", err)
+	}
+	checkGolden(t, "columnar.arrows", b.Bytes())
+}
+
+func TestWriteArrowErrors(t *testing.T) {
+	rows := NewRowsScanner(getColumnarData())
+	rows.(*mockRowsScanner).ScanErr = errors.New("fail")
+	if err := writeArrow(rows, &bytes.Buffer{}, ArrowOptions{}); err == nil {
+		t.Error("expected scan error")
+	}
+	if err := writeArrow(NewRowsScanner(getColumnarData()), &countingWriter{err: errors.New("fail")}, ArrowOptions{}); err == nil {
+		t.Error("expected write error")
+	}
+
+	var b bytes.Buffer
+	if err := writeArrow(NewRowsScanner([]columnarData{}), &b, ArrowOptions{}); err != nil || len(readArrowMessages(t, b.Bytes()[:b.Len()-8])) != 1 {
+		t.Error("expected schema only stream", err)
+	}
+}
+
+func TestQueryWriteArrow(t *testing.T) {
+	db := NewMock(nil, nil, getColumnarData())
+	var b bytes.Buffer
+	if err := QueryWriteArrow(&b, ArrowOptions{}, db, "query"); err != nil || b.Len() == 0 {
+		t.Error("expected arrow stream", err)
+	}
+}
+
+type arrowMessage struct {
+	metadata fbTable
+	body     []byte
+}
+
+func readArrowMessages(t *testing.T, stream []byte) []arrowMessage {
+	var messages []arrowMessage
+	for len(stream) > 0 {
+		if binary.LittleEndian.Uint32(stream) != 0xffffffff {
+			t.Fatal("expected continuation marker")
+		}
+		length := int(binary.LittleEndian.Uint32(stream[4:]))
+		if (8+length)%8 != 0 {
+			t.Fatal("expected padded metadata")
+		}
+		buf := stream[8 : 8+length]
+		metadata := fbTable{buf: buf, pos: int(binary.LittleEndian.Uint32(buf))}
+		bodyLength := int(metadata.uint64(3))
+		messages = append(messages, arrowMessage{metadata, stream[8+length : 8+length+bodyLength]})
+		stream = stream[8+length+bodyLength:]
+	}
+	return messages
+}
+
+// fbTable reads fields of a FlatBuffers table
+type fbTable struct {
+	buf []byte
+	pos int
+}
+
+func (t fbTable) field(slot int) int {
+	vtable := t.pos - int(int32(binary.LittleEndian.Uint32(t.buf[t.pos:])))
+	if t.pos%4 != 0 || vtable%2 != 0 {
+		panic("misaligned table")
+	}
+	if 4+2*slot >= int(binary.LittleEndian.Uint16(t.buf[vtable:])) {
+		return 0
+	}
+	offset := int(binary.LittleEndian.Uint16(t.buf[vtable+4+2*slot:]))
+	if offset == 0 {
+		return 0
+	}
+	return t.pos + offset
+}
+
+func (t fbTable) uint8(slot int) byte {
+	if pos := t.field(slot); pos != 0 {
+		return t.buf[pos]
+	}
+	return 0
+}
+
+func (t fbTable) uint16(slot int) uint16 {
+	if pos := t.field(slot); pos != 0 {
+		return binary.LittleEndian.Uint16(t.buf[pos:])
+	}
+	return 0
+}
+
+func (t fbTable) uint32(slot int) uint32 {
+	if pos := t.field(slot); pos != 0 {
+		return binary.LittleEndian.Uint32(t.buf[pos:])
+	}
+	return 0
+}
+
+func (t fbTable) uint64(slot int) uint64 {
+	if pos := t.field(slot); pos != 0 {
+		if pos%8 != 0 {
+			panic("misaligned int64")
+		}
+		return binary.LittleEndian.Uint64(t.buf[pos:])
+	}
+	return 0
+}
+
+func (t fbTable) indirect(slot int) int {
+	pos := t.field(slot)
+	return pos + int(binary.LittleEndian.Uint32(t.buf[pos:]))
+}
+
+func (t fbTable) table(slot int) fbTable {
+	return fbTable{buf: t.buf, pos: t.indirect(slot)}
+}
+
+func (t fbTable) string(slot int) string {
+	pos := t.indirect(slot)
+	length := int(binary.LittleEndian.Uint32(t.buf[pos:]))
+	return string(t.buf[pos+4 : pos+4+length])
+}
+
+func (t fbTable) vector(slot int) []fbTable {
+	pos := t.indirect(slot)
+	tables := make([]fbTable, binary.LittleEndian.Uint32(t.buf[pos:]))
+	for i := range tables {
+		element := pos + 4 + 4*i
+		tables[i] = fbTable{buf: t.buf, pos: element + int(binary.LittleEndian.Uint32(t.buf[element:]))}
+	}
+	return tables
+}
+
+func (t fbTable) structs(slot, size int) [][]byte {
+	pos := t.indirect(slot)
+	if (pos+4)%8 != 0 {
+		panic("misaligned structs")
+	}
+	structs := make([][]byte, binary.LittleEndian.Uint32(t.buf[pos:]))
+	for i := range structs {
+		structs[i] = t.buf[pos+4+size*i : pos+4+size*(i+1)]
+	}
+	return structs
+}
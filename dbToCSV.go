@@ -1,15 +1,32 @@
 package onedb
 
 import (
+	"bufio"
 	"encoding/csv"
-	"fmt"
 	"io"
+	"strings"
 	"time"
 )
 
 // CSVOptions contains specifications for how text should be formatted in a CSV file
 type CSVOptions struct {
-	DateOnly bool
+	DateOnly    bool
+	Delimiter   rune              // field separator. Defaults to a comma
+	NoHeader    bool              // leave out the header row
+	Headers     map[string]string // header text to write in place of the column name, keyed by column name in any case
+	TimeLayout  string            // layout used to format times. Overrides DateOnly
+	Location    *time.Location    // times are converted to this time zone before they are formatted
+	Null        string            // text written for NULL values. Defaults to an empty field
+	Columns     []string          // columns to write, in this order. Defaults to every column in query order
+	Exclude     []string          // columns to leave out
+	AlwaysQuote bool              // quote every field instead of only those that need it
+}
+
+// csvRecordWriter is the subset of csv.Writer used by writeCSV
+type csvRecordWriter interface {
+	Write(record []string) error
+	Flush()
+	Error() error
 }
 
 func writeCSV(rows RowsScanner, w io.Writer, options CSVOptions) error {
@@ -17,24 +34,78 @@ func writeCSV(rows RowsScanner, w io.Writer, options CSVOptions) error {
 	if err != nil {
 		return err
 	}
-
-	csvWriter := csv.NewWriter(w)
-	if err := csvWriter.Write(headers); err != nil {
+	indexes, err := getColumnIndexes(headers, options.Columns, options.Exclude)
+	if err != nil {
 		return err
 	}
+
+	csvWriter := newCSVWriter(w, options)
+	if !options.NoHeader {
+		header := make([]string, len(indexes))
+		for i, index := range indexes {
+			header[i] = getCSVHeader(headers[index], options)
+		}
+		if err := csvWriter.Write(header); err != nil {
+			return err
+		}
+	}
+	record := make([]string, len(indexes))
 	for rows.Next() {
 		row, err := scanCSV(rows, vals, options)
 		if err != nil {
 			return err
 		}
-		if err = csvWriter.Write(row); err != nil {
+		for i, index := range indexes {
+			record[i] = row[index]
+		}
+		if err = csvWriter.Write(record); err != nil {
 			return err
 		}
 	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
 	csvWriter.Flush()
 	return csvWriter.Error()
 }
 
+func newCSVWriter(w io.Writer, options CSVOptions) csvRecordWriter {
+	if options.AlwaysQuote {
+		return &quotingCSVWriter{w: bufio.NewWriter(w), delimiter: getCSVDelimiter(options.Delimiter)}
+	}
+	csvWriter := csv.NewWriter(w)
+	csvWriter.Comma = getCSVDelimiter(options.Delimiter)
+	return csvWriter
+}
+
+func getCSVDelimiter(delimiter rune) rune {
+	if delimiter == 0 {
+		return ','
+	}
+	return delimiter
+}
+
+func indexOfColumn(columns []string, name string) int {
+	for i, column := range columns {
+		if strings.EqualFold(column, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+func getCSVHeader(column string, options CSVOptions) string {
+	if header, ok := options.Headers[column]; ok {
+		return header
+	}
+	for name, header := range options.Headers {
+		if strings.EqualFold(name, column) {
+			return header
+		}
+	}
+	return column
+}
+
 func scanCSV(s Scanner, vals []interface{}, options CSVOptions) ([]string, error) {
 	err := s.Scan(vals...)
 	if err != nil {
@@ -43,29 +114,43 @@ func scanCSV(s Scanner, vals []interface{}, options CSVOptions) ([]string, error
 
 	row := make([]string, len(vals))
 	for i, value := range vals {
-		row[i] = getCSVValue(value.(*interface{}), options)
+		val := value.(*interface{})
+		if err := resolveValuer(val); err != nil {
+			return nil, err
+		}
+		row[i] = getCSVValue(val, options)
 	}
 	return row, nil
 }
 
 func getCSVValue(pval *interface{}, options CSVOptions) string {
-	switch v := (*pval).(type) {
-	case nil:
-		return ""
-	case bool:
-		if v {
-			return "true"
-		}
-		return "false"
-	case time.Time:
-		timeFormat := "2006-01-02 15:04:05.999"
-		if options.DateOnly {
-			timeFormat = "2006-01-02"
+	return formatText(pval, textFormat{DateOnly: options.DateOnly, TimeLayout: options.TimeLayout, Location: options.Location, Null: options.Null})
+}
+
+// quotingCSVWriter writes every field in double quotes, which csv.Writer has no option for
+type quotingCSVWriter struct {
+	w         *bufio.Writer
+	delimiter rune
+	err       error
+}
+
+func (q *quotingCSVWriter) Write(record []string) error {
+	for i, field := range record {
+		if i > 0 {
+			q.w.WriteRune(q.delimiter)
 		}
-		return v.Format(timeFormat)
-	case string:
-		return string(v)
-	default:
-		return fmt.Sprintf("%v", v)
+		q.w.WriteByte('"')
+		q.w.WriteString(strings.Replace(field, `"`, `""`, -1))
+		q.w.WriteByte('"')
 	}
+	_, err := q.w.WriteString("\n")
+	return err
+}
+
+func (q *quotingCSVWriter) Flush() {
+	q.err = q.w.Flush()
+}
+
+func (q *quotingCSVWriter) Error() error {
+	return q.err
 }
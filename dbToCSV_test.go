@@ -1,6 +1,8 @@
 package onedb
 
 import (
+	"bytes"
+	"database/sql"
 	"testing"
 	"time"
 )
@@ -10,6 +12,7 @@ func TestGetCSVValue(t *testing.T) {
 	checkCSVValue(t, 10, options, "10")
 	checkCSVValue(t, "aaaaaa", options, "aaaaaa")
 	checkCSVValue(t, [3]int{2, 1, 9}, options, "[2 1 9]")
+	checkCSVValue(t, []byte("hi"), options, "hi")
 	date := time.Date(2014, 12, 15, 21, 8, 15, 224336449, time.UTC)
 	checkCSVValue(t, date, options, "2014-12-15 21:08:15.224")
 	options.DateOnly = true
@@ -22,3 +25,67 @@ func checkCSVValue(t *testing.T, value interface{}, options CSVOptions, expected
 		t.Errorf("expected \"%s\", got \"%s\"", expected, actual)
 	}
 }
+
+type testStringer struct {
+	value string
+}
+
+func (s testStringer) String() string {
+	return "stringer:" + s.value
+}
+
+func TestWriteCSVValuers(t *testing.T) {
+	type valuerData struct {
+		Name  sql.NullString
+		Value testStringer
+	}
+	var b bytes.Buffer
+	rows := NewRowsScanner([]valuerData{{sql.NullString{String: "hello", Valid: true}, testStringer{"a"}}, {sql.NullString{}, testStringer{"b"}}})
+	err := writeCSV(rows, &b, CSVOptions{})
+	if err != nil || b.String() != "Name,Value\nhello,stringer:a\n,stringer:b\n" {
+		t.Error("expected driver.Valuer and fmt.Stringer to be used", b.String(), err)
+	}
+}
+
+func TestWriteCSVBytes(t *testing.T) {
+	var b bytes.Buffer
+	if err := writeCSV(NewRows("name").AddRow([]byte("hi, there")), &b, CSVOptions{}); err != nil || b.String() != "name\n\"hi, there\"\n" {
+		t.Error("expected []byte to be written as text", b.String(), err)
+	}
+}
+
+func TestWriteCSVOptions(t *testing.T) {
+	type csvData struct {
+		ID      int
+		Name    sql.NullString
+		Created time.Time
+		Note    string
+	}
+	name := sql.NullString{String: "bob", Valid: true}
+	created := time.Date(2020, 1, 2, 23, 30, 0, 0, time.UTC)
+	data := []csvData{{1, name, created, `say "hi"`}, {2, sql.NullString{}, created, "a;b"}}
+	zone := time.FixedZone("EST", -5*3600)
+
+	tests := []struct {
+		options  CSVOptions
+		expected string
+	}{
+		{CSVOptions{Delimiter: ';'}, "ID;Name;Created;Note\n1;bob;2020-01-02 23:30:00;\"say \"\"hi\"\"\"\n2;;2020-01-02 23:30:00;\"a;b\"\n"},
+		{CSVOptions{NoHeader: true, Columns: []string{"note", "ID"}}, "\"say \"\"hi\"\"\",1\na;b,2\n"},
+		{CSVOptions{Exclude: []string{"Created", "Note"}, Null: "NULL", Headers: map[string]string{"ID": "Identifier"}}, "Identifier,Name\n1,bob\n2,NULL\n"},
+		{CSVOptions{Columns: []string{"id", "name"}, Headers: map[string]string{"name": "Full Name"}}, "ID,Full Name\n1,bob\n2,\n"},
+		{CSVOptions{Columns: []string{"Created"}, TimeLayout: time.RFC3339, Location: zone}, "Created\n2020-01-02T18:30:00-05:00\n2020-01-02T18:30:00-05:00\n"},
+		{CSVOptions{Columns: []string{"ID", "Note"}, AlwaysQuote: true, Delimiter: '\t'}, "\"ID\"\t\"Note\"\n\"1\"\t\"say \"\"hi\"\"\"\n\"2\"\t\"a;b\"\n"},
+	}
+	for i, test := range tests {
+		var b bytes.Buffer
+		if err := writeCSV(NewRowsScanner(data), &b, test.options); err != nil || b.String() != test.expected {
+			t.Errorf("test %d: expected %q. Actual: %q %v", i, test.expected, b.String(), err)
+		}
+	}
+
+	var b bytes.Buffer
+	if err := writeCSV(NewRowsScanner(data), &b, CSVOptions{Columns: []string{"Missing"}}); err == nil {
+		t.Error("expected missing column error")
+	}
+}
@@ -0,0 +1,72 @@
+package onedb
+
+import (
+	"bufio"
+	"html"
+	"io"
+	"time"
+)
+
+// HTMLTableOptions contains specifications for how query results are written as an HTML table
+type HTMLTableOptions struct {
+	Class      string            // class attribute of the table element
+	NoHeader   bool              // leave out the thead row
+	Headers    map[string]string // header text to write in place of the column name, keyed by column name
+	DateOnly   bool              // write times as dates
+	TimeLayout string            // layout used to format times. Overrides DateOnly
+	Location   *time.Location    // times are converted to this time zone before they are formatted
+	Null       string            // text written for NULL values. Defaults to an empty cell
+	Columns    []string          // columns to write, in this order. Defaults to every column in query order
+	Exclude    []string          // columns to leave out
+}
+
+// writeHTMLTable writes the rows as a table fragment that can be embedded in a page. Headers and values are escaped
+func writeHTMLTable(rows RowsScanner, w io.Writer, options HTMLTableOptions) error {
+	columns, vals, err := getColumnNamesAndValues(rows, false)
+	if err != nil {
+		return err
+	}
+	indexes, err := getColumnIndexes(columns, options.Columns, options.Exclude)
+	if err != nil {
+		return err
+	}
+	format := textFormat{DateOnly: options.DateOnly, TimeLayout: options.TimeLayout, Location: options.Location, Null: options.Null}
+
+	b := bufio.NewWriter(w)
+	if options.Class != "" {
+		b.WriteString(`<table class="` + html.EscapeString(options.Class) + "\">\n")
+	} else {
+		b.WriteString("<table>\n")
+	}
+	if !options.NoHeader {
+		b.WriteString("<thead><tr>")
+		for _, index := range indexes {
+			header := columns[index]
+			if text, ok := options.Headers[header]; ok {
+				header = text
+			}
+			b.WriteString("<th>" + html.EscapeString(header) + "</th>")
+		}
+		b.WriteString("</tr></thead>\n")
+	}
+	b.WriteString("<tbody>\n")
+	for rows.Next() {
+		if err := rows.Scan(vals...); err != nil {
+			return err
+		}
+		b.WriteString("<tr>")
+		for _, index := range indexes {
+			val := vals[index].(*interface{})
+			if err := resolveValuer(val); err != nil {
+				return err
+			}
+			b.WriteString("<td>" + html.EscapeString(formatText(val, format)) + "</td>")
+		}
+		b.WriteString("</tr>\n")
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	b.WriteString("</tbody>\n</table>\n")
+	return b.Flush()
+}
@@ -0,0 +1,63 @@
+package onedb
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteHTMLTable(t *testing.T) {
+	type htmlData struct {
+		ID      int
+		Name    sql.NullString
+		Created time.Time
+	}
+	created := time.Date(2020, 1, 2, 23, 30, 0, 0, time.UTC)
+	data := []htmlData{{1, sql.NullString{String: "<b>bob</b>", Valid: true}, created}, {2, sql.NullString{}, created}}
+
+	tests := []struct {
+		options  HTMLTableOptions
+		expected string
+	}{
+		{HTMLTableOptions{}, "<table>\n<thead><tr><th>ID</th><th>Name</th><th>Created</th></tr></thead>\n<tbody>\n" +
+			"<tr><td>1</td><td>&lt;b&gt;bob&lt;/b&gt;</td><td>2020-01-02 23:30:00</td></tr>\n" +
+			"<tr><td>2</td><td></td><td>2020-01-02 23:30:00</td></tr>\n</tbody>\n</table>\n"},
+		{HTMLTableOptions{Class: `grid "x"`, Headers: map[string]string{"ID": "#"}, Null: "-", DateOnly: true, Exclude: []string{"name"}},
+			"<table class=\"grid &#34;x&#34;\">\n<thead><tr><th>#</th><th>Created</th></tr></thead>\n<tbody>\n" +
+				"<tr><td>1</td><td>2020-01-02</td></tr>\n<tr><td>2</td><td>2020-01-02</td></tr>\n</tbody>\n</table>\n"},
+		{HTMLTableOptions{NoHeader: true, Columns: []string{"Name"}, Null: "NULL"},
+			"<table>\n<tbody>\n<tr><td>&lt;b&gt;bob&lt;/b&gt;</td></tr>\n<tr><td>NULL</td></tr>\n</tbody>\n</table>\n"},
+	}
+	for i, test := range tests {
+		var b bytes.Buffer
+		if err := writeHTMLTable(NewRowsScanner(data), &b, test.options); err != nil || b.String() != test.expected {
+			t.Errorf("test %d: expected %q. Actual: %q %v", i, test.expected, b.String(), err)
+		}
+	}
+
+	var b bytes.Buffer
+	rows := NewRowsScanner(data)
+	rows.(*mockRowsScanner).ScanErr = errors.New("fail")
+	if err := writeHTMLTable(rows, &b, HTMLTableOptions{}); err == nil {
+		t.Error("expected scan error")
+	}
+}
+
+func TestWriteHTMLTableBytes(t *testing.T) {
+	var b bytes.Buffer
+	expected := "<table>\n<thead><tr><th>name</th></tr></thead>\n<tbody>\n<tr><td>&lt;hi&gt;</td></tr>\n</tbody>\n</table>\n"
+	if err := writeHTMLTable(NewRows("name").AddRow([]byte("<hi>")), &b, HTMLTableOptions{}); err != nil || b.String() != expected {
+		t.Errorf("expected []byte to be written as text. Actual: %q %v", b.String(), err)
+	}
+}
+
+func TestQueryWriteHTMLTable(t *testing.T) {
+	db := NewMock(nil, nil, []SimpleData{{1, "hello"}})
+	var b bytes.Buffer
+	if err := QueryWriteHTMLTable(&b, HTMLTableOptions{}, db, "query"); err != nil || !strings.Contains(b.String(), "<td>hello</td>") {
+		t.Error("expected row data", b.String(), err)
+	}
+}
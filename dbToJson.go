@@ -3,50 +3,127 @@ package onedb
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"io"
+	"strings"
 	"time"
+	"unicode"
 	"unicode/utf8"
 )
 
+// KeyCase controls how column names are converted into JSON keys
+type KeyCase int
+
+const (
+	// KeyCaseColumn uses column names as they are returned by the database
+	KeyCaseColumn KeyCase = iota
+	// KeyCaseCamel converts column names such as user_name and UserName into userName
+	KeyCaseCamel
+	// KeyCaseSnake converts column names such as userName and UserName into user_name
+	KeyCaseSnake
+)
+
+// JSONOptions contains specifications for how query results should be written as JSON
+type JSONOptions struct {
+	NewlineDelimited bool    // write one JSON object per line (NDJSON) instead of a single array
+	RFC3339          bool    // write times in RFC 3339 format with their time zone instead of "2006-01-02 15:04:05.999"
+	IncludeNulls     bool    // write NULL columns as null instead of leaving them out
+	KeyCase          KeyCase // casing of the keys. Defaults to the column names
+	Int64AsString    bool    // write 64 bit integers as strings so JavaScript doesn't lose precision
+	RawJSON          bool    // embed []byte values holding valid JSON (json and jsonb columns) instead of base64 encoding them
+
+	// Nested folds flat joined rows into hierarchical objects. Columns named like "lines.sku" are collected into
+	// a "lines" array of objects and rows with the same grouping key become a single object. Top level objects
+	// must be on consecutive rows, while array elements are merged wherever they repeat so that sibling arrays
+	// from two joins don't duplicate each other. The key is the GroupBy columns at that level ("id" for the top
+	// level, "lines.id" for lines) or all of the level's own columns when none are given. Array elements whose
+	// columns are all NULL, as produced by a LEFT JOIN without matches, are left out
+	Nested        bool
+	GroupBy       []string
+	NestedObjects []string // dotted prefixes such as "customer" that hold a single object instead of an array
+}
+
 func getJSON(rows RowsScanner) (string, error) {
-	columns, vals, err := getColumnNamesAndValues(rows, true)
-	if err != nil {
+	return getJSONWithOptions(rows, JSONOptions{})
+}
+
+func getJSONWithOptions(rows RowsScanner, options JSONOptions) (string, error) {
+	var b bytes.Buffer
+	if err := writeJSON(rows, &b, options); err != nil {
 		return "", err
 	}
+	return b.String(), nil
+}
+
+// writeJSON writes each row to w as soon as it is scanned so the full result is never held in memory
+func writeJSON(rows RowsScanner, w io.Writer, options JSONOptions) error {
+	columns, vals, err := getColumnNamesAndValues(rows, false)
+	if err != nil {
+		return err
+	}
+	if options.Nested {
+		return writeNestedJSON(rows, w, columns, vals, options)
+	}
+	keys := getJSONKeys(columns, options.KeyCase)
 
 	var b bytes.Buffer
+	if !options.NewlineDelimited {
+		b.WriteByte('[')
+	}
 	writeComma := false
-	b.WriteByte('[')
 	for rows.Next() {
-		err := scanJSON(rows, columns, vals, writeComma, &b)
-		if err != nil {
-			return "", err
+		if err := scanJSON(rows, keys, vals, writeComma, &b, options); err != nil {
+			return err
+		}
+		if options.NewlineDelimited {
+			b.WriteByte('\n')
+		} else {
+			writeComma = true
+		}
+		if _, err := b.WriteTo(w); err != nil {
+			return err
 		}
-		writeComma = true
 	}
-	b.WriteByte(']')
-
-	return b.String(), nil
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	if !options.NewlineDelimited {
+		b.WriteByte(']')
+	}
+	_, err = b.WriteTo(w)
+	return err
 }
 
 func getJSONRow(rows RowsScanner) (string, error) {
-	columns, vals, err := getColumnNamesAndValues(rows, true)
+	return getJSONRowWithOptions(rows, JSONOptions{})
+}
+
+func getJSONRowWithOptions(rows RowsScanner, options JSONOptions) (string, error) {
+	columns, vals, err := getColumnNamesAndValues(rows, false)
 	if err != nil {
 		return "", err
 	}
+	if options.Nested {
+		return getNestedJSONRow(rows, columns, vals, options)
+	}
+	keys := getJSONKeys(columns, options.KeyCase)
 
 	var b bytes.Buffer
 	if rows.Next() {
-		err := scanJSON(rows, columns, vals, false, &b)
+		err := scanJSON(rows, keys, vals, false, &b, options)
 		if err != nil {
 			return "", err
 		}
 	}
+	if err := rows.Err(); err != nil {
+		return "", err
+	}
 
 	return b.String(), nil
 }
 
-func scanJSON(s Scanner, columns []string, vals []interface{}, writeComma bool, b *bytes.Buffer) error {
+func scanJSON(s Scanner, keys []string, vals []interface{}, writeComma bool, b *bytes.Buffer, options JSONOptions) error {
 	if writeComma {
 		b.WriteByte(',')
 	}
@@ -57,12 +134,16 @@ func scanJSON(s Scanner, columns []string, vals []interface{}, writeComma bool,
 	}
 	firstColumn := true
 	for i := 0; i < len(vals); i++ {
-		jsonValue := getJSONValue(vals[i].(*interface{}))
-		if jsonValue != "null" {
+		val := vals[i].(*interface{})
+		if err := resolveValuer(val); err != nil {
+			return err
+		}
+		jsonValue := getJSONValueWithOptions(val, options)
+		if jsonValue != "null" || options.IncludeNulls {
 			if !firstColumn {
 				b.WriteByte(',')
 			}
-			b.WriteString(columns[i])
+			b.WriteString(keys[i])
 			b.WriteString(jsonValue)
 			firstColumn = false
 		}
@@ -93,15 +174,33 @@ func getColumnNamesAndValues(s RowsScanner, isJSON bool) ([]string, []interface{
 }
 
 func jsonize(columnName string) string {
-	var buffer bytes.Buffer
-	buffer.WriteByte('"')
-	buffer.WriteString(columnName)
-	buffer.WriteByte('"')
-	buffer.WriteByte(':')
-	return buffer.String()
+	return encodeString(columnName) + ":"
+}
+
+// getJSONKeys returns the quoted key, including the colon, written for each column
+func getJSONKeys(columns []string, keyCase KeyCase) []string {
+	keys := make([]string, len(columns))
+	for i, column := range columns {
+		keys[i] = getJSONKey(column, keyCase)
+	}
+	return keys
+}
+
+func getJSONKey(name string, keyCase KeyCase) string {
+	switch keyCase {
+	case KeyCaseCamel:
+		name = toCamelCase(name)
+	case KeyCaseSnake:
+		name = toSnakeCase(name)
+	}
+	return jsonize(name)
 }
 
 func getJSONValue(pval *interface{}) string {
+	return getJSONValueWithOptions(pval, JSONOptions{})
+}
+
+func getJSONValueWithOptions(pval *interface{}, options JSONOptions) string {
 	switch v := (*pval).(type) {
 	case nil:
 		return "null"
@@ -110,19 +209,108 @@ func getJSONValue(pval *interface{}) string {
 			return "true"
 		}
 		return "false"
+	case json.RawMessage:
+		if raw, ok := compactJSON(v); ok {
+			return raw
+		}
+		return encodeByteSlice(v)
 	case []byte:
+		if options.RawJSON {
+			if raw, ok := compactJSON(v); ok {
+				return raw
+			}
+		}
 		return encodeByteSlice(v)
 	case time.Time:
-		return v.Format(`"2006-01-02 15:04:05.999"`)
-	case uint8, uint16, uint32, uint64, int, int8, int16, int32, int64, float32, float64, complex64, complex128:
+		if options.RFC3339 {
+			return v.Format(`"` + time.RFC3339Nano + `"`)
+		}
+		return v.Format(`"` + defaultTimeLayout + `"`)
+	case int64, uint64, int, uint:
+		if options.Int64AsString {
+			return fmt.Sprintf(`"%v"`, v)
+		}
+		return fmt.Sprintf("%v", v)
+	case map[string]interface{}, []interface{}:
+		if options.RawJSON {
+			if raw, err := json.Marshal(v); err == nil {
+				return string(raw)
+			}
+		}
+		return encodeString(fmt.Sprintf("%v", v))
+	case uint8, uint16, uint32, int8, int16, int32, float32, float64, complex64, complex128:
 		return fmt.Sprintf("%v", v) // probably not optimized for speed since Sprintf is relatively slow
 	case string:
 		return encodeString(string(v))
+	case fmt.Stringer:
+		return encodeString(v.String())
 	default:
 		return encodeString(fmt.Sprintf("%v", v)) // probably not optimized for speed since Sprintf is relatively slow
 	}
 }
 
+// compactJSON returns b with insignificant white space removed so that it can be embedded in NDJSON output.
+// ok is false if b isn't valid JSON
+func compactJSON(b []byte) (string, bool) {
+	if len(b) == 0 {
+		return "", false
+	}
+	var buffer bytes.Buffer
+	if err := json.Compact(&buffer, b); err != nil {
+		return "", false
+	}
+	return buffer.String(), true
+}
+
+// toCamelCase converts a column name into camelCase: user_name, UserName and USER_NAME all become userName
+func toCamelCase(name string) string {
+	words := splitWords(name)
+	for i, word := range words {
+		word = strings.ToLower(word)
+		if i > 0 && word != "" {
+			r, size := utf8.DecodeRuneInString(word)
+			word = string(unicode.ToUpper(r)) + word[size:]
+		}
+		words[i] = word
+	}
+	return strings.Join(words, "")
+}
+
+// toSnakeCase converts a column name into snake_case: userName, UserName and UserID become user_name and user_id
+func toSnakeCase(name string) string {
+	words := splitWords(name)
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return strings.Join(words, "_")
+}
+
+// splitWords splits a name on underscores, dashes and spaces and at changes of case, keeping runs of capitals
+// such as the ID in UserID together
+func splitWords(name string) []string {
+	var words []string
+	runes := []rune(name)
+	start := 0
+	for i := 0; i <= len(runes); i++ {
+		if i == len(runes) || runes[i] == '_' || runes[i] == '-' || runes[i] == ' ' {
+			if i > start {
+				words = append(words, string(runes[start:i]))
+			}
+			start = i + 1
+			continue
+		}
+		if i > start && unicode.IsUpper(runes[i]) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				words = append(words, string(runes[start:i]))
+				start = i
+			}
+		}
+	}
+	return words
+}
+
 // these methods are taken directly from the "encoding/json" library and modified to return a string
 // and use a simple bytes.Buffer instead of its original encodeState struct which is a light wrapper
 // over the bytes.Buffer
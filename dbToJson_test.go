@@ -1,6 +1,10 @@
 package onedb
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
 	"errors"
 	"testing"
 	"time"
@@ -31,6 +35,51 @@ func TestGetJson(t *testing.T) {
 	}
 }
 
+func TestWriteJson(t *testing.T) {
+	// array
+	var b bytes.Buffer
+	rows := NewRowsScanner([]SimpleData{{1, "hello"}, {2, "world"}})
+	err := writeJSON(rows, &b, JSONOptions{})
+	if err != nil || b.String() != `[{"IntVal":1,"StringVal":"hello"},{"IntVal":2,"StringVal":"world"}]` {
+		t.Error("expected valid json", b.String(), err)
+	}
+
+	// newline delimited
+	b.Reset()
+	rows = NewRowsScanner([]SimpleData{{1, "hello"}, {2, "world"}})
+	err = writeJSON(rows, &b, JSONOptions{NewlineDelimited: true})
+	if err != nil || b.String() != "{\"IntVal\":1,\"StringVal\":\"hello\"}\n{\"IntVal\":2,\"StringVal\":\"world\"}\n" {
+		t.Error("expected newline delimited json", b.String(), err)
+	}
+
+	// rows are written as they are scanned
+	w := &countingWriter{}
+	rows = NewRowsScanner([]SimpleData{{1, "hello"}, {2, "world"}})
+	writeJSON(rows, w, JSONOptions{})
+	if w.writes != 3 {
+		t.Error("expected one write per row plus the closing bracket", w.writes)
+	}
+
+	// write error
+	rows = NewRowsScanner([]SimpleData{{1, "hello"}})
+	if err = writeJSON(rows, &countingWriter{err: errors.New("fail")}, JSONOptions{}); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestWriteJsonValuers(t *testing.T) {
+	type valuerData struct {
+		Name  sql.NullString
+		Count sql.NullInt64
+	}
+	var b bytes.Buffer
+	rows := NewRowsScanner([]valuerData{{sql.NullString{String: "hello", Valid: true}, sql.NullInt64{}}})
+	err := writeJSON(rows, &b, JSONOptions{})
+	if err != nil || b.String() != `[{"Name":"hello"}]` {
+		t.Error("expected driver.Valuer to be used", b.String(), err)
+	}
+}
+
 func TestGetJsonRow(t *testing.T) {
 	// success
 	rows := NewRowsScanner([]SimpleData{{1, "hello"}})
@@ -177,6 +226,16 @@ type TestData struct {
 	Byte  []byte
 }
 
+type countingWriter struct {
+	writes int
+	err    error
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.writes++
+	return len(p), w.err
+}
+
 type MockRows struct {
 	NumRows int
 }
@@ -217,3 +276,85 @@ func (m *MockRows) Scan(dest ...interface{}) error {
 func (m *MockRows) Err() error {
 	return nil
 }
+
+func TestGetJSONValueWithOptions(t *testing.T) {
+	date := time.Date(2000, 1, 2, 3, 4, 5, 123000000, time.FixedZone("EST", -5*3600))
+	tests := []struct {
+		value    interface{}
+		options  JSONOptions
+		expected string
+	}{
+		{date, JSONOptions{RFC3339: true}, `"2000-01-02T03:04:05.123-05:00"`},
+		{int64(9007199254740993), JSONOptions{Int64AsString: true}, `"9007199254740993"`},
+		{int64(12), JSONOptions{}, `12`},
+		{int32(12), JSONOptions{Int64AsString: true}, `12`},
+		{[]byte(`{"a": [1, 2]}`), JSONOptions{RawJSON: true}, `{"a":[1,2]}`},
+		{[]byte(`{"a": [1, 2]}`), JSONOptions{}, `"eyJhIjogWzEsIDJdfQ=="`},
+		{[]byte("not json"), JSONOptions{RawJSON: true}, `"bm90IGpzb24="`},
+		{json.RawMessage(`[true]`), JSONOptions{}, `[true]`},
+		{map[string]interface{}{"a": 1}, JSONOptions{RawJSON: true}, `{"a":1}`},
+	}
+	for _, test := range tests {
+		value := test.value
+		if actual := getJSONValueWithOptions(&value, test.options); actual != test.expected {
+			t.Errorf("expected %v to be %s. Actual: %s", test.value, test.expected, actual)
+		}
+	}
+}
+
+func TestGetJSONWithOptions(t *testing.T) {
+	type jsonData struct {
+		UserID   int64
+		UserName sql.NullString `db:"user_name"`
+		Profile  []byte
+	}
+	rows := NewRowsScanner([]jsonData{{1, sql.NullString{}, []byte(`{"x":1}`)}})
+	json, err := getJSONWithOptions(rows, JSONOptions{IncludeNulls: true, KeyCase: KeyCaseSnake, RawJSON: true})
+	if err != nil || json != `[{"user_id":1,"user_name":null,"profile":{"x":1}}]` {
+		t.Error("expected snake case keys with nulls and raw json", json, err)
+	}
+
+	rows = NewRowsScanner([]jsonData{{1, sql.NullString{String: "bob", Valid: true}, nil}})
+	json, err = getJSONRowWithOptions(rows, JSONOptions{KeyCase: KeyCaseCamel, Int64AsString: true})
+	if err != nil || json != `{"userId":"1","userName":"bob"}` {
+		t.Error("expected camel case keys", json, err)
+	}
+}
+
+func TestKeyCase(t *testing.T) {
+	tests := []struct {
+		name, camel, snake string
+	}{
+		{"user_name", "userName", "user_name"},
+		{"UserName", "userName", "user_name"},
+		{"USER_NAME", "userName", "user_name"},
+		{"UserID", "userId", "user_id"},
+		{"HTTPServer", "httpServer", "http_server"},
+		{"address2-line", "address2Line", "address2_line"},
+		{"id", "id", "id"},
+	}
+	for _, test := range tests {
+		if actual := toCamelCase(test.name); actual != test.camel {
+			t.Errorf("expected %s in camelCase to be %s. Actual: %s", test.name, test.camel, actual)
+		}
+		if actual := toSnakeCase(test.name); actual != test.snake {
+			t.Errorf("expected %s in snake_case to be %s. Actual: %s", test.name, test.snake, actual)
+		}
+	}
+	if keys := getJSONKeys([]string{`a"b`}, KeyCaseColumn); keys[0] != `"a\"b":` {
+		t.Error("expected escaped key", keys)
+	}
+}
+
+func TestQueryJSONWithOptions(t *testing.T) {
+	db := &mockBackend{Rows: NewRowsScanner([]SimpleData{{1, "hello"}})}
+	json, err := QueryJSONWithOptions(context.Background(), JSONOptions{KeyCase: KeyCaseSnake}, db, "query")
+	if err != nil || json != `[{"int_val":1,"string_val":"hello"}]` {
+		t.Error("expected json", json, err)
+	}
+	db = &mockBackend{Rows: NewRowsScanner([]SimpleData{{1, "hello"}})}
+	json, err = QueryJSONRowWithOptions(context.Background(), JSONOptions{KeyCase: KeyCaseCamel}, db, "query")
+	if err != nil || json != `{"intVal":1,"stringVal":"hello"}` {
+		t.Error("expected json row", json, err)
+	}
+}
@@ -0,0 +1,124 @@
+package onedb
+
+import (
+	"context"
+	"reflect"
+)
+
+// MapResult holds rows scanned into maps along with the columns of the query in the order they were returned.
+// When a query returns the same column name more than once the last value is kept in the map
+type MapResult[V any] struct {
+	Columns []string
+	Rows    []map[string]V
+}
+
+// QueryMaps runs a query against the provided Backender and returns each row as a map keyed by column name
+func QueryMaps(backend Backender, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	return QueryMapsContext(context.Background(), backend, query, args...)
+}
+
+// QueryMapsContext runs a query against the provided Backender and returns each row as a map keyed by column name.
+// The query is aborted if ctx is done
+func QueryMapsContext(ctx context.Context, backend Backender, query string, args ...interface{}) ([]map[string]interface{}, error) {
+	result, err := QueryMapsOfContext[interface{}](ctx, backend, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return result.Rows, nil
+}
+
+// QueryMapRow runs a query against the provided Backender and returns the first row as a map keyed by column name
+func QueryMapRow(backend Backender, query string, args ...interface{}) (map[string]interface{}, error) {
+	return QueryMapRowContext(context.Background(), backend, query, args...)
+}
+
+// QueryMapRowContext runs a query against the provided Backender and returns the first row as a map keyed by column
+// name. The query is aborted if ctx is done
+func QueryMapRowContext(ctx context.Context, backend Backender, query string, args ...interface{}) (map[string]interface{}, error) {
+	rows, err := queryContext(ctx, backend, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result, err := getMaps[interface{}](rows, 1)
+	if err != nil {
+		return nil, err
+	}
+	if len(result.Rows) == 0 {
+		return nil, ErrEmptyResultSet
+	}
+	return result.Rows[0], nil
+}
+
+// QueryMapsOf runs a query against the provided Backender and returns each row as a map of V keyed by column name
+// along with the column order. Values are converted into V the same way struct fields are populated, except that
+// map[string]string renders every value as text the way QueryWriteCSV does
+func QueryMapsOf[V any](backend Backender, query string, args ...interface{}) (*MapResult[V], error) {
+	return QueryMapsOfContext[V](context.Background(), backend, query, args...)
+}
+
+// QueryMapsOfContext runs a query against the provided Backender and returns each row as a map of V keyed by column
+// name along with the column order. The query is aborted if ctx is done
+func QueryMapsOfContext[V any](ctx context.Context, backend Backender, query string, args ...interface{}) (*MapResult[V], error) {
+	rows, err := queryContext(ctx, backend, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return getMaps[V](rows, -1)
+}
+
+// getMaps scans up to limit rows, or every row if limit is negative
+func getMaps[V any](rows RowsScanner, limit int) (*MapResult[V], error) {
+	columns, vals, err := getColumnNamesAndValues(rows, false)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &MapResult[V]{Columns: columns, Rows: []map[string]V{}}
+	for (limit < 0 || len(result.Rows) < limit) && rows.Next() {
+		row, err := scanMap[V](rows, columns, vals)
+		if err != nil {
+			return nil, err
+		}
+		result.Rows = append(result.Rows, row)
+	}
+	return result, rows.Err()
+}
+
+func scanMap[V any](s Scanner, columns []string, vals []interface{}) (map[string]V, error) {
+	if err := s.Scan(vals...); err != nil {
+		return nil, err
+	}
+	row := make(map[string]V, len(columns))
+	for i, column := range columns {
+		val := vals[i].(*interface{})
+		var item V
+		if err := setMapValue(&item, val); err != nil {
+			return nil, &ConversionError{Column: column, Field: column, SrcType: reflect.TypeOf(*val), DestType: reflect.TypeOf(&item).Elem(), Err: err}
+		}
+		row[column] = item
+	}
+	return row, nil
+}
+
+func setMapValue(item interface{}, val *interface{}) error {
+	switch dest := item.(type) {
+	case *interface{}:
+		*dest = *val
+		return nil
+	case *string:
+		if err := resolveValuer(val); err != nil {
+			return err
+		}
+		if b, ok := (*val).([]byte); ok {
+			*dest = string(b)
+			return nil
+		}
+		*dest = getCSVValue(val, CSVOptions{})
+		return nil
+	}
+	return SetValue(reflect.ValueOf(item).Elem(), val)
+}
@@ -0,0 +1,94 @@
+package onedb
+
+import (
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestQueryMaps(t *testing.T) {
+	db := &mockBackend{Rows: NewRowsScanner([]SimpleData{{1, "hello"}, {2, "world"}})}
+	rows, err := QueryMaps(db, "query")
+	if err != nil || len(rows) != 2 || rows[0]["IntVal"] != 1 || rows[1]["StringVal"] != "world" {
+		t.Error("expected maps", rows, err)
+	}
+
+	db = &mockBackend{Rows: NewRowsScanner([]SimpleData{})}
+	if rows, err = QueryMaps(db, "query"); err != nil || rows == nil || len(rows) != 0 {
+		t.Error("expected empty slice", rows, err)
+	}
+
+	db = &mockBackend{QueryErr: errors.New("fail")}
+	if _, err = QueryMaps(db, "query"); err == nil {
+		t.Error("expected error")
+	}
+
+	scanErr := NewRowsScanner([]SimpleData{{1, "hello"}})
+	scanErr.(*mockRowsScanner).ScanErr = errors.New("fail")
+	if _, err = QueryMaps(&mockBackend{Rows: scanErr}, "query"); err == nil {
+		t.Error("expected scan error")
+	}
+}
+
+func TestQueryMapRow(t *testing.T) {
+	db := &mockBackend{Rows: NewRowsScanner([]SimpleData{{1, "hello"}, {2, "world"}})}
+	row, err := QueryMapRow(db, "query")
+	if err != nil || len(row) != 2 || row["IntVal"] != 1 {
+		t.Error("expected first row", row, err)
+	}
+
+	db = &mockBackend{Rows: NewRowsScanner([]SimpleData{})}
+	if _, err = QueryMapRow(db, "query"); err != ErrEmptyResultSet {
+		t.Error("expected empty result set", err)
+	}
+}
+
+func TestQueryMapsOf(t *testing.T) {
+	type mapData struct {
+		ID      int64
+		Name    sql.NullString
+		Active  bool
+		Created time.Time
+		Bytes   []byte
+	}
+	created := time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC)
+	data := []mapData{{1, sql.NullString{String: "bob", Valid: true}, true, created, []byte("raw")}, {2, sql.NullString{}, false, created, nil}}
+
+	strs, err := QueryMapsOf[string](&mockBackend{Rows: NewRowsScanner(data)}, "query")
+	if err != nil || len(strs.Rows) != 2 {
+		t.Fatal("expected string maps", strs, err)
+	}
+	if len(strs.Columns) != 5 || strs.Columns[0] != "ID" || strs.Columns[4] != "Bytes" {
+		t.Error("expected column order", strs.Columns)
+	}
+	if row := strs.Rows[0]; row["ID"] != "1" || row["Name"] != "bob" || row["Active"] != "true" || row["Created"] != "2000-01-02 03:04:05" || row["Bytes"] != "raw" {
+		t.Error("expected text values", row)
+	}
+	if row := strs.Rows[1]; row["Name"] != "" {
+		t.Error("expected NULL as empty string", row)
+	}
+
+	ints, err := QueryMapsOf[int](&mockBackend{Rows: NewRowsScanner([]SimpleData{{1, "2"}})}, "query")
+	if err != nil || ints.Rows[0]["IntVal"] != 1 || ints.Rows[0]["StringVal"] != 2 {
+		t.Error("expected converted ints", ints, err)
+	}
+
+	_, err = QueryMapsOf[int](&mockBackend{Rows: NewRowsScanner([]SimpleData{{1, "abc"}})}, "query")
+	var convErr *ConversionError
+	if !errors.As(err, &convErr) || convErr.Column != "StringVal" {
+		t.Error("expected conversion error naming the column", err)
+	}
+}
+
+func TestMockDBQueryMaps(t *testing.T) {
+	db := NewMock(nil, nil, []SimpleData{{1, "hello"}}, []SimpleData{{2, "world"}})
+	if rows, err := QueryMaps(db, "query", "arg"); err != nil || len(rows) != 1 {
+		t.Error("expected rows", rows, err)
+	}
+	db.VerifyNextCommand(t, "Query", "query", "arg")
+	if row, err := QueryMapRow(db, "query"); err != nil || row["IntVal"] != 2 {
+		t.Error("expected row", row, err)
+	}
+	db.VerifyNextCommand(t, "Query", "query")
+}
@@ -0,0 +1,269 @@
+package onedb
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+)
+
+// jsonNode is one level of a nested JSON document: the columns written as its own fields and the arrays or
+// objects built from dotted column names below it
+type jsonNode struct {
+	key      string // quoted key, including the colon, of the node in its parent
+	path     string // dotted prefix of the node's columns. Empty for the top level
+	object   bool   // a single object instead of an array
+	columns  []int  // indexes of the node's own columns
+	keys     []string
+	group    []int // indexes of the columns whose values identify an element
+	children []*jsonNode
+}
+
+// getJSONTree arranges the columns into nodes by splitting their names on dots
+func getJSONTree(columns []string, options JSONOptions) *jsonNode {
+	root := &jsonNode{}
+	for i, column := range columns {
+		parts := strings.Split(column, ".")
+		node := root
+		for _, part := range parts[:len(parts)-1] {
+			node = node.child(part, options)
+		}
+		node.columns = append(node.columns, i)
+		node.keys = append(node.keys, getJSONKey(parts[len(parts)-1], options.KeyCase))
+	}
+	root.setGroup(columns, options.GroupBy)
+	return root
+}
+
+func (n *jsonNode) child(name string, options JSONOptions) *jsonNode {
+	path := name
+	if n.path != "" {
+		path = n.path + "." + name
+	}
+	for _, child := range n.children {
+		if child.path == path {
+			return child
+		}
+	}
+	child := &jsonNode{key: getJSONKey(name, options.KeyCase), path: path, object: indexOfColumn(options.NestedObjects, path) != -1}
+	n.children = append(n.children, child)
+	return child
+}
+
+// setGroup uses the GroupBy columns that belong to each node or all of the node's own columns if there are none
+func (n *jsonNode) setGroup(columns []string, groupBy []string) {
+	for _, index := range n.columns {
+		if indexOfColumn(groupBy, columns[index]) != -1 {
+			n.group = append(n.group, index)
+		}
+	}
+	if n.group == nil {
+		n.group = n.columns
+	}
+	for _, child := range n.children {
+		child.setGroup(columns, groupBy)
+	}
+}
+
+func (n *jsonNode) sameGroup(a, b []interface{}) bool {
+	for _, index := range n.group {
+		if !reflect.DeepEqual(a[index], b[index]) {
+			return false
+		}
+	}
+	return true
+}
+
+// isEmpty reports whether the row holds nothing but NULLs for the node
+func (n *jsonNode) isEmpty(row []interface{}) bool {
+	for _, index := range n.columns {
+		if row[index] != nil {
+			return false
+		}
+	}
+	for _, child := range n.children {
+		if !child.isEmpty(row) {
+			return false
+		}
+	}
+	return true
+}
+
+// write writes one object built from rows, which all share the node's grouping key
+func (n *jsonNode) write(b *bytes.Buffer, rows [][]interface{}, options JSONOptions) {
+	b.WriteByte('{')
+	first := true
+	writeKey := func(key string) {
+		if !first {
+			b.WriteByte(',')
+		}
+		b.WriteString(key)
+		first = false
+	}
+	for i, index := range n.columns {
+		value := rows[0][index]
+		jsonValue := getJSONValueWithOptions(&value, options)
+		if jsonValue != "null" || options.IncludeNulls {
+			writeKey(n.keys[i])
+			b.WriteString(jsonValue)
+		}
+	}
+	for _, child := range n.children {
+		if child.object {
+			if child.isEmpty(rows[0]) {
+				if options.IncludeNulls {
+					writeKey(child.key)
+					b.WriteString("null")
+				}
+				continue
+			}
+			writeKey(child.key)
+			child.write(b, child.firstGroup(rows), options)
+			continue
+		}
+		writeKey(child.key)
+		b.WriteByte('[')
+		firstElement := true
+		for _, group := range child.groups(rows) {
+			if !child.isEmpty(group[0]) {
+				if !firstElement {
+					b.WriteByte(',')
+				}
+				child.write(b, group, options)
+				firstElement = false
+			}
+		}
+		b.WriteByte(']')
+	}
+	b.WriteByte('}')
+}
+
+// groups splits rows into the node's elements in the order they first appear. The rows of an element needn't be
+// consecutive: joining two sibling arrays repeats each element of one for every element of the other
+func (n *jsonNode) groups(rows [][]interface{}) [][][]interface{} {
+	var groups [][][]interface{}
+	index := make(map[string]int)
+	for _, row := range rows {
+		key := n.groupKey(row)
+		if i, ok := index[key]; ok {
+			groups[i] = append(groups[i], row)
+			continue
+		}
+		index[key] = len(groups)
+		groups = append(groups, [][]interface{}{row})
+	}
+	return groups
+}
+
+func (n *jsonNode) groupKey(row []interface{}) string {
+	var b strings.Builder
+	for _, index := range n.group {
+		fmt.Fprintf(&b, "%T:%v\x00", row[index], row[index])
+	}
+	return b.String()
+}
+
+// firstGroup returns the leading rows that share the node's grouping key
+func (n *jsonNode) firstGroup(rows [][]interface{}) [][]interface{} {
+	end := 1
+	for end < len(rows) && n.sameGroup(rows[0], rows[end]) {
+		end++
+	}
+	return rows[:end]
+}
+
+// nestedJSONReader groups consecutive rows that share the top level grouping key
+type nestedJSONReader struct {
+	rows    RowsScanner
+	vals    []interface{}
+	tree    *jsonNode
+	pending []interface{}
+}
+
+func newNestedJSONReader(rows RowsScanner, columns []string, vals []interface{}, options JSONOptions) *nestedJSONReader {
+	return &nestedJSONReader{rows: rows, vals: vals, tree: getJSONTree(columns, options)}
+}
+
+// next returns the rows of the next top level object or nil when there are no more rows
+func (r *nestedJSONReader) next() ([][]interface{}, error) {
+	var group [][]interface{}
+	if r.pending != nil {
+		group = append(group, r.pending)
+		r.pending = nil
+	}
+	for r.rows.Next() {
+		row, err := r.scan()
+		if err != nil {
+			return nil, err
+		}
+		if group != nil && !r.tree.sameGroup(group[0], row) {
+			r.pending = row
+			return group, nil
+		}
+		group = append(group, row)
+	}
+	return group, r.rows.Err()
+}
+
+func (r *nestedJSONReader) scan() ([]interface{}, error) {
+	if err := r.rows.Scan(r.vals...); err != nil {
+		return nil, err
+	}
+	row := make([]interface{}, len(r.vals))
+	for i, value := range r.vals {
+		val := value.(*interface{})
+		if err := resolveValuer(val); err != nil {
+			return nil, err
+		}
+		row[i] = *val
+	}
+	return row, nil
+}
+
+// writeNestedJSON writes each top level object as soon as a row with a different grouping key is scanned
+func writeNestedJSON(rows RowsScanner, w io.Writer, columns []string, vals []interface{}, options JSONOptions) error {
+	reader := newNestedJSONReader(rows, columns, vals, options)
+	var b bytes.Buffer
+	if !options.NewlineDelimited {
+		b.WriteByte('[')
+	}
+	writeComma := false
+	for {
+		group, err := reader.next()
+		if err != nil {
+			return err
+		}
+		if group == nil {
+			break
+		}
+		if writeComma {
+			b.WriteByte(',')
+		}
+		reader.tree.write(&b, group, options)
+		if options.NewlineDelimited {
+			b.WriteByte('\n')
+		} else {
+			writeComma = true
+		}
+		if _, err := b.WriteTo(w); err != nil {
+			return err
+		}
+	}
+	if !options.NewlineDelimited {
+		b.WriteByte(']')
+	}
+	_, err := b.WriteTo(w)
+	return err
+}
+
+func getNestedJSONRow(rows RowsScanner, columns []string, vals []interface{}, options JSONOptions) (string, error) {
+	reader := newNestedJSONReader(rows, columns, vals, options)
+	group, err := reader.next()
+	if err != nil || group == nil {
+		return "", err
+	}
+	var b bytes.Buffer
+	reader.tree.write(&b, group, options)
+	return b.String(), nil
+}
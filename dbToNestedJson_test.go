@@ -0,0 +1,97 @@
+package onedb
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"testing"
+)
+
+type orderLineRow struct {
+	ID           int            `db:"id"`
+	Customer     string         `db:"customer.name"`
+	LineSKU      sql.NullString `db:"lines.sku"`
+	LineQuantity sql.NullInt64  `db:"lines.quantity"`
+	TagName      sql.NullString `db:"lines.tags.name"`
+}
+
+func getOrderRows() RowsScanner {
+	return NewRowsScanner([]orderLineRow{
+		{1, "bob", sql.NullString{String: "A", Valid: true}, sql.NullInt64{Int64: 2, Valid: true}, sql.NullString{String: "red", Valid: true}},
+		{1, "bob", sql.NullString{String: "A", Valid: true}, sql.NullInt64{Int64: 2, Valid: true}, sql.NullString{String: "big", Valid: true}},
+		{1, "bob", sql.NullString{String: "B", Valid: true}, sql.NullInt64{Int64: 1, Valid: true}, sql.NullString{}},
+		{2, "sue", sql.NullString{}, sql.NullInt64{}, sql.NullString{}},
+	})
+}
+
+func TestWriteNestedJSON(t *testing.T) {
+	var b bytes.Buffer
+	err := writeJSON(getOrderRows(), &b, JSONOptions{Nested: true, NestedObjects: []string{"customer"}})
+	expected := `[{"id":1,"customer":{"name":"bob"},"lines":[{"sku":"A","quantity":2,"tags":[{"name":"red"},{"name":"big"}]},{"sku":"B","quantity":1,"tags":[]}]},` +
+		`{"id":2,"customer":{"name":"sue"},"lines":[]}]`
+	if err != nil || b.String() != expected {
+		t.Errorf("expected %s. Actual: %s %v", expected, b.String(), err)
+	}
+
+	b.Reset()
+	err = writeJSON(getOrderRows(), &b, JSONOptions{Nested: true, NewlineDelimited: true, GroupBy: []string{"id", "lines.sku"}, KeyCase: KeyCaseCamel})
+	expected = `{"id":1,"customer":[{"name":"bob"}],"lines":[{"sku":"A","quantity":2,"tags":[{"name":"red"},{"name":"big"}]},{"sku":"B","quantity":1,"tags":[]}]}` + "\n" +
+		`{"id":2,"customer":[{"name":"sue"}],"lines":[]}` + "\n"
+	if err != nil || b.String() != expected {
+		t.Errorf("expected %s. Actual: %s %v", expected, b.String(), err)
+	}
+}
+
+func TestWriteNestedJSONSiblings(t *testing.T) {
+	// joining lines and tags repeats every line for every tag
+	rows := NewRows("id", "lines.sku", "lines.notes.text", "tags.name").
+		AddRow(1, "A", "gift", "red").
+		AddRow(1, "A", "gift", "big").
+		AddRow(1, "B", nil, "red").
+		AddRow(1, "B", nil, "big").
+		AddRow(1, "A", "wrap", "red").
+		AddRow(2, nil, nil, "blue")
+	var b bytes.Buffer
+	err := writeJSON(rows, &b, JSONOptions{Nested: true})
+	expected := `[{"id":1,"lines":[{"sku":"A","notes":[{"text":"gift"},{"text":"wrap"}]},{"sku":"B","notes":[]}],"tags":[{"name":"red"},{"name":"big"}]},` +
+		`{"id":2,"lines":[],"tags":[{"name":"blue"}]}]`
+	if err != nil || b.String() != expected {
+		t.Errorf("expected %s. Actual: %s %v", expected, b.String(), err)
+	}
+}
+
+func TestGetNestedJSONRow(t *testing.T) {
+	json, err := getJSONRowWithOptions(getOrderRows(), JSONOptions{Nested: true, NestedObjects: []string{"customer"}})
+	expected := `{"id":1,"customer":{"name":"bob"},"lines":[{"sku":"A","quantity":2,"tags":[{"name":"red"},{"name":"big"}]},{"sku":"B","quantity":1,"tags":[]}]}`
+	if err != nil || json != expected {
+		t.Errorf("expected %s. Actual: %s %v", expected, json, err)
+	}
+
+	json, err = getJSONRowWithOptions(NewRowsScanner([]orderLineRow{}), JSONOptions{Nested: true})
+	if err != nil || json != "" {
+		t.Error("expected empty result", json, err)
+	}
+}
+
+func TestWriteNestedJSONNulls(t *testing.T) {
+	type row struct {
+		ID   int            `db:"id"`
+		Name sql.NullString `db:"customer.name"`
+	}
+	var b bytes.Buffer
+	err := writeJSON(NewRowsScanner([]row{{1, sql.NullString{}}}), &b, JSONOptions{Nested: true, IncludeNulls: true, NestedObjects: []string{"customer"}})
+	if err != nil || b.String() != `[{"id":1,"customer":null}]` {
+		t.Error("expected null object", b.String(), err)
+	}
+}
+
+func TestWriteNestedJSONErrors(t *testing.T) {
+	rows := getOrderRows()
+	rows.(*mockRowsScanner).ScanErr = errors.New("fail")
+	if err := writeJSON(rows, &bytes.Buffer{}, JSONOptions{Nested: true}); err == nil {
+		t.Error("expected scan error")
+	}
+	if err := writeJSON(getOrderRows(), &countingWriter{err: errors.New("fail")}, JSONOptions{Nested: true}); err == nil {
+		t.Error("expected write error")
+	}
+}
@@ -0,0 +1,342 @@
+package onedb
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+// ParquetOptions contains specifications for how a Parquet file is written
+type ParquetOptions struct {
+	RowGroupSize int // rows per row group. Defaults to 10000
+}
+
+// parquet physical types
+const (
+	parquetBoolean   = 0
+	parquetInt64     = 2
+	parquetDouble    = 5
+	parquetByteArray = 6
+)
+
+// parquet converted types
+const (
+	parquetUTF8            = 0
+	parquetDecimal         = 5
+	parquetTimestampMicros = 10
+)
+
+const (
+	parquetOptional     = 1
+	parquetPlain        = 0
+	parquetRLE          = 3
+	parquetDataPage     = 0
+	parquetUncompressed = 0
+)
+
+var parquetMagic = []byte("PAR1")
+
+type parquetRowGroup struct {
+	numRows int64
+	size    int64
+	chunks  []parquetColumnChunk
+}
+
+type parquetColumnChunk struct {
+	offset int64
+	size   int64
+	values int64
+}
+
+// writeParquet writes an uncompressed Parquet file with one row group per RowGroupSize rows. Every column is
+// optional, PLAIN encoded and written as a single data page per row group
+func writeParquet(rows RowsScanner, w io.Writer, options ParquetOptions) error {
+	columns, vals, err := getColumnNamesAndValues(rows, false)
+	if err != nil {
+		return err
+	}
+	size := options.RowGroupSize
+	if size <= 0 {
+		size = defaultBatchSize
+	}
+
+	out := &offsetWriter{w: w}
+	if _, err := out.Write(parquetMagic); err != nil {
+		return err
+	}
+	var schema []columnSchema
+	var groups []parquetRowGroup
+	var numRows int64
+	for {
+		batch, err := readColumnarBatch(rows, vals, size)
+		if err != nil {
+			return err
+		}
+		if schema == nil {
+			schema = getColumnarSchema(rows, columns, batch)
+		}
+		if len(batch) == 0 {
+			break
+		}
+		buffers, err := newColumnBuffers(schema, batch)
+		if err != nil {
+			return err
+		}
+		group := parquetRowGroup{numRows: int64(len(batch))}
+		for _, buffer := range buffers {
+			chunk := parquetColumnChunk{offset: out.offset, values: int64(len(batch))}
+			if err := writeParquetPage(out, buffer); err != nil {
+				return err
+			}
+			chunk.size = out.offset - chunk.offset
+			group.size += chunk.size
+			group.chunks = append(group.chunks, chunk)
+		}
+		groups = append(groups, group)
+		numRows += group.numRows
+		if len(batch) < size {
+			break
+		}
+	}
+
+	footer := getParquetFooter(schema, groups, numRows)
+	if _, err := out.Write(footer); err != nil {
+		return err
+	}
+	var length [4]byte
+	binary.LittleEndian.PutUint32(length[:], uint32(len(footer)))
+	if _, err := out.Write(length[:]); err != nil {
+		return err
+	}
+	_, err = out.Write(parquetMagic)
+	return err
+}
+
+func writeParquetPage(w io.Writer, c *columnBuffer) error {
+	data := getParquetDefinitionLevels(c.valid)
+	for i, valid := range c.valid {
+		if !valid {
+			continue
+		}
+		switch c.schema.Kind {
+		case kindInt64, kindTimestamp:
+			data = appendUint64(data, uint64(c.ints[i]))
+		case kindFloat64:
+			data = appendUint64(data, math.Float64bits(c.floats[i]))
+		case kindDecimal:
+			data = appendParquetByteArray(data, twosComplement(c.decimals[i], c.decimals[i].BitLen()/8+1))
+		case kindString, kindBytes:
+			data = appendParquetByteArray(data, c.bytes[i])
+		}
+	}
+	if c.schema.Kind == kindBool {
+		data = append(data, packBits(c.bools, c.valid)...)
+	}
+
+	header := newThriftWriter()
+	header.i32(1, parquetDataPage)
+	header.i32(2, int32(len(data)))
+	header.i32(3, int32(len(data)))
+	header.structBegin(5)
+	header.i32(1, int32(len(c.valid)))
+	header.i32(2, parquetPlain)
+	header.i32(3, parquetRLE)
+	header.i32(4, parquetRLE)
+	header.structEnd()
+	if _, err := w.Write(header.end()); err != nil {
+		return err
+	}
+	_, err := w.Write(data)
+	return err
+}
+
+// getParquetDefinitionLevels encodes the NULL flags as a length prefixed run of bit packed definition levels
+func getParquetDefinitionLevels(valid []bool) []byte {
+	groups := (len(valid) + 7) / 8
+	levels := appendUvarint(nil, uint64(groups)<<1|1)
+	levels = append(levels, packBits(valid, nil)...)
+	data := appendUint32(nil, uint32(len(levels)))
+	return append(data, levels...)
+}
+
+// packBits packs the bits LSB first. When include is set only the bits whose include flag is set are packed
+func packBits(bits []bool, include []bool) []byte {
+	packed := make([]byte, 0, (len(bits)+7)/8)
+	n := 0
+	for i, bit := range bits {
+		if include != nil && !include[i] {
+			continue
+		}
+		if n%8 == 0 {
+			packed = append(packed, 0)
+		}
+		if bit {
+			packed[n/8] |= 1 << uint(n%8)
+		}
+		n++
+	}
+	return packed
+}
+
+func appendParquetByteArray(data, value []byte) []byte {
+	data = appendUint32(data, uint32(len(value)))
+	return append(data, value...)
+}
+
+func getParquetFooter(schema []columnSchema, groups []parquetRowGroup, numRows int64) []byte {
+	t := newThriftWriter()
+	t.i32(1, 1)
+	t.listBegin(2, thriftStruct, len(schema)+1)
+	t.elementBegin()
+	t.binary(4, "schema")
+	t.i32(5, int32(len(schema)))
+	t.structEnd()
+	for _, column := range schema {
+		t.elementBegin()
+		t.i32(1, getParquetType(column.Kind))
+		t.i32(3, parquetOptional)
+		t.binary(4, column.Name)
+		switch column.Kind {
+		case kindString:
+			t.i32(6, parquetUTF8)
+		case kindTimestamp:
+			t.i32(6, parquetTimestampMicros)
+		case kindDecimal:
+			t.i32(6, parquetDecimal)
+			t.i32(7, int32(column.Scale))
+			t.i32(8, int32(column.Precision))
+		}
+		t.structEnd()
+	}
+	t.i64(3, numRows)
+	t.listBegin(4, thriftStruct, len(groups))
+	for _, group := range groups {
+		t.elementBegin()
+		t.listBegin(1, thriftStruct, len(group.chunks))
+		for i, chunk := range group.chunks {
+			t.elementBegin()
+			t.i64(2, chunk.offset)
+			t.structBegin(3)
+			t.i32(1, getParquetType(schema[i].Kind))
+			t.listBegin(2, thriftI32, 2)
+			t.i32Element(parquetPlain)
+			t.i32Element(parquetRLE)
+			t.listBegin(3, thriftBinary, 1)
+			t.binaryElement(schema[i].Name)
+			t.i32(4, parquetUncompressed)
+			t.i64(5, chunk.values)
+			t.i64(6, chunk.size)
+			t.i64(7, chunk.size)
+			t.i64(9, chunk.offset)
+			t.structEnd()
+			t.structEnd()
+		}
+		t.i64(2, group.size)
+		t.i64(3, group.numRows)
+		t.structEnd()
+	}
+	t.binary(6, "onedb")
+	return t.end()
+}
+
+func getParquetType(kind columnKind) int32 {
+	switch kind {
+	case kindBool:
+		return parquetBoolean
+	case kindInt64, kindTimestamp:
+		return parquetInt64
+	case kindFloat64:
+		return parquetDouble
+	}
+	return parquetByteArray
+}
+
+// thrift compact protocol types
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes a struct with the Thrift compact protocol, which Parquet uses for its metadata
+type thriftWriter struct {
+	buf   []byte
+	last  int16
+	stack []int16
+}
+
+func newThriftWriter() *thriftWriter {
+	return &thriftWriter{}
+}
+
+func (t *thriftWriter) fieldHeader(id int16, fieldType byte) {
+	if delta := id - t.last; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|fieldType)
+	} else {
+		t.buf = append(t.buf, fieldType)
+		t.zigzag(int64(id))
+	}
+	t.last = id
+}
+
+func (t *thriftWriter) zigzag(v int64) {
+	t.buf = appendUvarint(t.buf, uint64(v<<1^v>>63))
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.fieldHeader(id, thriftI32)
+	t.zigzag(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.fieldHeader(id, thriftI64)
+	t.zigzag(v)
+}
+
+func (t *thriftWriter) binary(id int16, v string) {
+	t.fieldHeader(id, thriftBinary)
+	t.binaryElement(v)
+}
+
+func (t *thriftWriter) structBegin(id int16) {
+	t.fieldHeader(id, thriftStruct)
+	t.elementBegin()
+}
+
+// elementBegin starts a struct that is an element of a list and so has no field header
+func (t *thriftWriter) elementBegin() {
+	t.stack = append(t.stack, t.last)
+	t.last = 0
+}
+
+func (t *thriftWriter) structEnd() {
+	t.buf = append(t.buf, 0)
+	t.last = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+func (t *thriftWriter) listBegin(id int16, elementType byte, size int) {
+	t.fieldHeader(id, thriftList)
+	if size < 15 {
+		t.buf = append(t.buf, byte(size)<<4|elementType)
+		return
+	}
+	t.buf = append(t.buf, 0xf0|elementType)
+	t.buf = appendUvarint(t.buf, uint64(size))
+}
+
+func (t *thriftWriter) i32Element(v int32) {
+	t.zigzag(int64(v))
+}
+
+func (t *thriftWriter) binaryElement(v string) {
+	t.buf = appendUvarint(t.buf, uint64(len(v)))
+	t.buf = append(t.buf, v...)
+}
+
+// end writes the stop field of the outer struct and returns the encoded bytes
+func (t *thriftWriter) end() []byte {
+	return append(t.buf, 0)
+}
@@ -0,0 +1,217 @@
+package onedb
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+	"time"
+)
+
+type columnarData struct {
+	ID      int
+	Name    sql.NullString
+	Price   float64
+	Active  bool
+	Created time.Time
+	Data    []byte
+}
+
+func getColumnarData() []columnarData {
+	created := time.Date(2020, 1, 2, 3, 4, 5, 6000, time.UTC)
+	return []columnarData{
+		{1, sql.NullString{String: "bob", Valid: true}, 1.5, true, created, []byte{1, 2}},
+		{2, sql.NullString{}, 2.5, false, created, nil},
+		{3, sql.NullString{String: "sue", Valid: true}, 3.5, true, created, []byte{3}},
+	}
+}
+
+func TestWriteParquet(t *testing.T) {
+	var b bytes.Buffer
+	if err := writeParquet(NewRowsScanner(getColumnarData()), &b, ParquetOptions{RowGroupSize: 2}); err != nil {
+		t.Fatal("expected success", err)
+	}
+	file := b.Bytes()
+	if !bytes.HasPrefix(file, []byte("PAR1")) || !bytes.HasSuffix(file, []byte("PAR1")) {
+		t.Fatal("expected magic bytes")
+	}
+	footerLength := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	footer := newThriftReader(file[len(file)-8-footerLength : len(file)-8]).readStruct()
+
+	schema := footer[2].([]interface{})
+	if len(schema) != 7 || schema[0].(map[int16]interface{})[5] != int64(6) {
+		t.Fatal("expected root and 6 column schema elements", schema)
+	}
+	name := schema[2].(map[int16]interface{})
+	if string(name[4].([]byte)) != "Name" || name[1] != int64(parquetByteArray) || name[3] != int64(parquetOptional) || name[6] != int64(parquetUTF8) {
+		t.Error("expected optional UTF8 column", name)
+	}
+	if created := schema[5].(map[int16]interface{}); created[1] != int64(parquetInt64) || created[6] != int64(parquetTimestampMicros) {
+		t.Error("expected timestamp column", created)
+	}
+	if footer[3] != int64(3) {
+		t.Error("expected 3 rows", footer[3])
+	}
+	groups := footer[4].([]interface{})
+	if len(groups) != 2 || groups[0].(map[int16]interface{})[3] != int64(2) || groups[1].(map[int16]interface{})[3] != int64(1) {
+		t.Fatal("expected row groups of 2 and 1 rows", groups)
+	}
+
+	// decode the Name column of the first row group
+	chunk := groups[0].(map[int16]interface{})[1].([]interface{})[1].(map[int16]interface{})[3].(map[int16]interface{})
+	reader := newThriftReader(file[chunk[9].(int64):])
+	header := reader.readStruct()
+	page := reader.data[reader.pos : reader.pos+int(header[3].(int64))]
+	if header[5].(map[int16]interface{})[1] != int64(2) {
+		t.Error("expected 2 values in page", header)
+	}
+	levelsLength := int(binary.LittleEndian.Uint32(page))
+	if levels := page[4 : 4+levelsLength]; !bytes.Equal(levels, []byte{3, 1}) { // one bit packed group: 1, 0
+		t.Error("expected definition levels", levels)
+	}
+	if values := page[4+levelsLength:]; !bytes.Equal(values, []byte{3, 0, 0, 0, 'b', 'o', 'b'}) {
+		t.Error("expected only the non NULL value", values)
+	}
+
+	// decode the Created column of the first row group
+	chunk = groups[0].(map[int16]interface{})[1].([]interface{})[4].(map[int16]interface{})[3].(map[int16]interface{})
+	reader = newThriftReader(file[chunk[9].(int64):])
+	reader.readStruct()
+	micros := int64(binary.LittleEndian.Uint64(reader.data[reader.pos+4+2:]))
+	if micros != time.Date(2020, 1, 2, 3, 4, 5, 6000, time.UTC).UnixNano()/1000 {
+		t.Error("expected microseconds", micros)
+	}
+}
+
+func TestWriteParquetGolden(t *testing.T) {
+	var b bytes.Buffer
+	if err := writeParquet(getColumnarGoldenRows(), &b, ParquetOptions{RowGroupSize: 2}); err != nil {
+		t.Fatal("expected success", err)
+	}
+	checkGolden(t, "columnar.parquet", b.Bytes())
+}
+
+func TestWriteParquetEmpty(t *testing.T) {
+	var b bytes.Buffer
+	if err := writeParquet(NewRowsScanner([]columnarData{}), &b, ParquetOptions{}); err != nil {
+		t.Fatal("expected success", err)
+	}
+	file := b.Bytes()
+	footerLength := int(binary.LittleEndian.Uint32(file[len(file)-8:]))
+	footer := newThriftReader(file[len(file)-8-footerLength : len(file)-8]).readStruct()
+	if footer[3] != int64(0) || len(footer[2].([]interface{})) != 7 {
+		t.Error("expected schema without rows", footer)
+	}
+}
+
+func TestWriteParquetErrors(t *testing.T) {
+	rows := NewRowsScanner(getColumnarData())
+	rows.(*mockRowsScanner).ScanErr = errors.New("fail")
+	if err := writeParquet(rows, &bytes.Buffer{}, ParquetOptions{}); err == nil {
+		t.Error("expected scan error")
+	}
+	if err := writeParquet(&mockRowsScanner{ColumnsErr: errors.New("fail")}, &bytes.Buffer{}, ParquetOptions{}); err == nil {
+		t.Error("expected columns error")
+	}
+	if err := writeParquet(NewRowsScanner(getColumnarData()), &countingWriter{err: errors.New("fail")}, ParquetOptions{}); err == nil {
+		t.Error("expected write error")
+	}
+}
+
+func TestQueryWriteParquet(t *testing.T) {
+	db := NewMock(nil, nil, getColumnarData())
+	var b bytes.Buffer
+	if err := QueryWriteParquet(&b, ParquetOptions{}, db, "query"); err != nil || !bytes.HasPrefix(b.Bytes(), []byte("PAR1")) {
+		t.Error("expected parquet file", err)
+	}
+}
+
+func TestThriftWriter(t *testing.T) {
+	w := newThriftWriter()
+	w.i32(1, -3)
+	w.i64(20, math.MaxInt64)
+	w.listBegin(21, thriftI32, 20)
+	for i := 0; i < 20; i++ {
+		w.i32Element(int32(i))
+	}
+	w.structBegin(22)
+	w.binary(1, "x")
+	w.structEnd()
+	w.i32(23, 7)
+	result := newThriftReader(w.end()).readStruct()
+	if result[1] != int64(-3) || result[20] != int64(math.MaxInt64) || len(result[21].([]interface{})) != 20 ||
+		string(result[22].(map[int16]interface{})[1].([]byte)) != "x" || result[23] != int64(7) {
+		t.Error("expected round trip", result)
+	}
+}
+
+// thriftReader decodes the Thrift compact protocol into maps keyed by field id
+type thriftReader struct {
+	data []byte
+	pos  int
+}
+
+func newThriftReader(data []byte) *thriftReader {
+	return &thriftReader{data: data}
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.data[r.pos:])
+	r.pos += n
+	return v
+}
+
+func (r *thriftReader) zigzag() int64 {
+	v := r.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (r *thriftReader) readStruct() map[int16]interface{} {
+	result := make(map[int16]interface{})
+	var last int16
+	for {
+		b := r.data[r.pos]
+		r.pos++
+		if b == 0 {
+			return result
+		}
+		id := last + int16(b>>4)
+		if b>>4 == 0 {
+			id = int16(r.zigzag())
+		}
+		last = id
+		result[id] = r.readValue(b & 0x0f)
+	}
+}
+
+func (r *thriftReader) readValue(valueType byte) interface{} {
+	switch valueType {
+	case 1:
+		return true
+	case 2:
+		return false
+	case thriftI32, thriftI64:
+		return r.zigzag()
+	case thriftBinary:
+		n := int(r.uvarint())
+		r.pos += n
+		return r.data[r.pos-n : r.pos]
+	case thriftList:
+		b := r.data[r.pos]
+		r.pos++
+		size := int(b >> 4)
+		if size == 15 {
+			size = int(r.uvarint())
+		}
+		list := make([]interface{}, size)
+		for i := range list {
+			list[i] = r.readValue(b & 0x0f)
+		}
+		return list
+	case thriftStruct:
+		return r.readStruct()
+	}
+	panic("unsupported thrift type")
+}
@@ -1,21 +1,42 @@
 package onedb
 
 import (
+	"database/sql"
+	"encoding"
+	"encoding/json"
 	"fmt"
 	"reflect"
 	"strings"
 	"time"
-
-	"github.com/pkg/errors"
 )
 
+// StructOptions contains specifications for how query results are mapped into structs
+type StructOptions struct {
+	Strict bool // fail when a column has no matching field or a field has no matching column
+}
+
 func getStruct(rows RowsScanner, result interface{}) error {
+	return getStructWithOptions(rows, result, StructOptions{})
+}
+
+func getStructWithOptions(rows RowsScanner, result interface{}, options StructOptions) error {
 	columns, vals, err := getColumnNamesAndValues(rows, false)
 	if err != nil {
 		return err
 	}
 
-	itemType, dbToStruct := getItemTypeAndMap(columns, reflect.TypeOf(result).Elem())
+	if relations := getRelationPlan(columns, reflect.TypeOf(result).Elem().Elem()); relations != nil {
+		if options.Strict && relations.unmapped != nil {
+			return relations.unmapped
+		}
+		return relations.hydrate(rows, vals, reflect.ValueOf(result).Elem(), false, false)
+	}
+
+	plan := getScanPlan(columns, reflect.TypeOf(result).Elem().Elem())
+	if options.Strict && plan.unmapped != nil {
+		return plan.unmapped
+	}
+	itemType, dbToStruct := plan.itemType, plan.fields
 	sliceValue := reflect.ValueOf(result).Elem()
 	for rows.Next() {
 		itemValue := reflect.New(itemType)
@@ -25,23 +46,45 @@ func getStruct(rows RowsScanner, result interface{}) error {
 		}
 		sliceValue.Set(reflect.Append(sliceValue, itemValue.Elem()))
 	}
-	return nil
+	return rows.Err()
 }
 
 func getStructRow(rows RowsScanner, result interface{}) error {
+	return getStructRowWithOptions(rows, result, StructOptions{})
+}
+
+func getStructRowWithOptions(rows RowsScanner, result interface{}, options StructOptions) error {
 	if rows.Err() != nil {
 		return rows.Err()
 	}
 	if !rows.Next() {
-		return errors.New("Empty result set")
+		if rows.Err() != nil {
+			return rows.Err()
+		}
+		return ErrEmptyResultSet
 	}
 	columns, vals, err := getColumnNamesAndValues(rows, false)
 	if err != nil {
 		return err
 	}
 
-	_, dbToStruct := getItemTypeAndMap(columns, reflect.TypeOf(result))
-	err = scanStruct(rows, vals, dbToStruct, result)
+	if relations := getRelationPlan(columns, reflect.TypeOf(result).Elem()); relations != nil {
+		if options.Strict && relations.unmapped != nil {
+			return relations.unmapped
+		}
+		items := reflect.New(reflect.SliceOf(relations.itemType)).Elem()
+		if err := relations.hydrate(rows, vals, items, true, true); err != nil {
+			return err
+		}
+		reflect.ValueOf(result).Elem().Set(items.Index(0))
+		return nil
+	}
+
+	plan := getScanPlan(columns, reflect.TypeOf(result).Elem())
+	if options.Strict && plan.unmapped != nil {
+		return plan.unmapped
+	}
+	err = scanStruct(rows, vals, plan.fields, result)
 	if err != nil {
 		return err
 	}
@@ -55,7 +98,36 @@ func scanStruct(s Scanner, vals []interface{}, dbToStruct []structFieldInfo, res
 	}
 	item := reflect.ValueOf(result).Elem()
 	for _, fieldInfo := range dbToStruct {
-		SetValue(item.Field(fieldInfo.FieldIndex), vals[fieldInfo.DBIndex].(*interface{}))
+		src := vals[fieldInfo.DBIndex].(*interface{})
+		if err := setField(fieldByIndex(item, fieldInfo.FieldIndex), src, fieldInfo.Convert); err != nil {
+			return &ConversionError{Column: fieldInfo.Column, Field: fieldInfo.Field, SrcType: reflect.TypeOf(*src), DestType: fieldInfo.Type, Err: err}
+		}
+	}
+	return nil
+}
+
+// checkUnmapped returns an UnmappedError listing the columns and fields that weren't matched
+func checkUnmapped(columns []string, itemType reflect.Type, dbToStruct []structFieldInfo) *UnmappedError {
+	mappedColumns := make([]bool, len(columns))
+	mappedFields := make(map[string]bool, len(dbToStruct))
+	for _, fieldInfo := range dbToStruct {
+		mappedColumns[fieldInfo.DBIndex] = true
+		mappedFields[fieldInfo.Field] = true
+	}
+
+	unmapped := &UnmappedError{}
+	for i, column := range columns {
+		if !mappedColumns[i] {
+			unmapped.Columns = append(unmapped.Columns, column)
+		}
+	}
+	for _, column := range getStructColumns(itemType) {
+		if !mappedFields[column.Field] {
+			unmapped.Fields = append(unmapped.Fields, column.Field)
+		}
+	}
+	if len(unmapped.Columns) > 0 || len(unmapped.Fields) > 0 {
+		return unmapped
 	}
 	return nil
 }
@@ -64,139 +136,102 @@ var timeType = reflect.TypeOf(time.Time{})
 var nilType = reflect.TypeOf(nil)
 var nilValue = reflect.ValueOf(nil)
 
-// SetValue is used to update struct values from the database
+// SetValue is used to update struct values from the database. Numeric values are widened or narrowed when they
+// fit in the destination, []byte and string are interchangeable and strings are parsed into numbers, bools and
+// times. An error is returned when the value can't be stored without losing information
 func SetValue(dest reflect.Value, src *interface{}) error {
 	if !dest.CanSet() {
 		return fmt.Errorf("not settable")
 	}
-	destType := dest.Type()
-	destKind := destType.Kind()
-	destRootType := destType
-	destRootKind := destKind
-	if destKind == reflect.Ptr {
-		destRootType = destType.Elem()
-		destRootKind = destRootType.Kind()
-	}
-
-	switch v := (*src).(type) {
-	case nil:
-	case bool:
-		if destKind == reflect.Bool {
-			dest.SetBool(v)
-		} else if destKind == reflect.Ptr && destRootKind == reflect.Bool {
-			dest.Set(reflect.ValueOf(&v))
-		}
-	case []byte:
-		if destKind == reflect.Slice && destType.Elem().Kind() == reflect.Uint8 {
-			dest.SetBytes(v)
-		} else if destKind == reflect.Ptr && (destRootKind == reflect.Slice && destType.Elem().Elem().Kind() == reflect.Uint8) {
-			dest.Set(reflect.ValueOf(&v))
-		}
-	case *float32:
-		if v != nil {
-			setFloat(destKind, destRootKind, dest, float64(*v))
-		}
-	case float32:
-		setFloat(destKind, destRootKind, dest, float64(v))
-	case *float64:
-		if v != nil {
-			setFloat(destKind, destRootKind, dest, *v)
-		}
-	case float64:
-		setFloat(destKind, destRootKind, dest, v)
-	case int8:
-		if destKind == reflect.Int8 || destKind == reflect.Int16 || destKind == reflect.Int32 || destKind == reflect.Int64 || destKind == reflect.Int {
-			dest.SetInt(int64(v))
-		} else if destKind == reflect.Ptr && (destRootKind == reflect.Int8 || destRootKind == reflect.Int16 || destRootKind == reflect.Int32 || destRootKind == reflect.Int64 || destRootKind == reflect.Int) {
-			dest.Set(reflect.ValueOf(&v))
-		}
-	case int16:
-		if destKind == reflect.Int16 || destKind == reflect.Int32 || destKind == reflect.Int64 || destKind == reflect.Int {
-			dest.SetInt(int64(v))
-		} else if destKind == reflect.Ptr && (destRootKind == reflect.Int16 || destRootKind == reflect.Int32 || destRootKind == reflect.Int64 || destRootKind == reflect.Int) {
-			dest.Set(reflect.ValueOf(&v))
-		}
-	case int32:
-		if destKind == reflect.Int32 || destKind == reflect.Int64 || destKind == reflect.Int {
-			dest.SetInt(int64(v))
-		} else if destKind == reflect.Ptr && (destRootKind == reflect.Int32 || destRootKind == reflect.Int64 || destRootKind == reflect.Int) {
-			dest.Set(reflect.ValueOf(&v))
-		}
-	case int64:
-		if destKind == reflect.Int64 || destKind == reflect.Int {
-			dest.SetInt(v)
-		} else if destKind == reflect.Ptr && (destRootKind == reflect.Int64 || destRootKind == reflect.Int) {
-			dest.Set(reflect.ValueOf(&v))
-		}
-	case int:
-		if destKind == reflect.Int || destKind == reflect.Int64 {
-			dest.SetInt(int64(v))
-		} else if destKind == reflect.Ptr && (destRootKind == reflect.Int64 || destRootKind == reflect.Int) {
-			dest.Set(reflect.ValueOf(&v))
-		}
-	case uint8:
-		if destKind == reflect.Uint8 || destKind == reflect.Uint16 || destKind == reflect.Uint32 || destKind == reflect.Uint64 || destKind == reflect.Uint {
-			dest.SetUint(uint64(v))
-		} else if destKind == reflect.Ptr && (destRootKind == reflect.Uint8 || destRootKind == reflect.Uint16 || destRootKind == reflect.Uint32 || destRootKind == reflect.Uint64 || destRootKind == reflect.Uint) {
-			dest.Set(reflect.ValueOf(&v))
-		}
-	case uint16:
-		if destKind == reflect.Uint16 || destKind == reflect.Uint32 || destKind == reflect.Uint64 || destKind == reflect.Uint {
-			dest.SetUint(uint64(v))
-		} else if destKind == reflect.Ptr && (destRootKind == reflect.Uint16 || destRootKind == reflect.Uint32 || destRootKind == reflect.Uint64 || destRootKind == reflect.Uint) {
-			dest.Set(reflect.ValueOf(&v))
-		}
-	case uint32:
-		if destKind == reflect.Uint32 || destKind == reflect.Uint64 || destKind == reflect.Uint {
-			dest.SetUint(uint64(v))
-		} else if destKind == reflect.Ptr && (destRootKind == reflect.Uint32 || destRootKind == reflect.Uint64 || destRootKind == reflect.Uint) {
-			dest.Set(reflect.ValueOf(&v))
-		}
-	case uint64:
-		if destKind == reflect.Uint64 || destKind == reflect.Uint {
-			dest.SetUint(v)
-		} else if destKind == reflect.Ptr && (destRootKind == reflect.Uint64 || destRootKind == reflect.Uint) {
-			dest.Set(reflect.ValueOf(&v))
-		}
-	case uint:
-		if destKind == reflect.Uint64 || destKind == reflect.Uint {
-			dest.SetUint(uint64(v))
-		} else if destKind == reflect.Ptr && (destRootKind == reflect.Uint64 || destRootKind == reflect.Uint) {
-			dest.Set(reflect.ValueOf(&v))
-		}
-	case string:
-		if destKind == reflect.String {
-			dest.SetString(v)
-		} else if destKind == reflect.Ptr && destRootKind == reflect.String {
-			dest.Set(reflect.ValueOf(&v))
-		}
-	case time.Time:
-		if destType == timeType {
-			dest.Set(reflect.ValueOf(v))
-		} else if destKind == reflect.Ptr && destRootType == timeType {
-			dest.Set(reflect.ValueOf(&v))
+	if ok, err := setFromUnmarshaler(dest, *src); ok {
+		return err
+	}
+	return convertAssign(dest, *src)
+}
+
+// converter stores a database value into a struct field
+type converter func(dest reflect.Value, src interface{}) error
+
+// newConverter returns the converter for a field type, deciding once whether the type needs to be populated
+// through one of the unmarshaler interfaces
+func newConverter(destType reflect.Type) converter {
+	if destType == timeType || destType == reflect.PtrTo(timeType) {
+		return convertAssign
+	}
+	ptrType := destType
+	if destType.Kind() != reflect.Ptr {
+		ptrType = reflect.PtrTo(destType)
+	}
+	if ptrType.Implements(scannerType) || ptrType.Implements(textUnmarshalerType) || ptrType.Implements(jsonUnmarshalerType) {
+		return func(dest reflect.Value, src interface{}) error {
+			if ok, err := setFromUnmarshaler(dest, src); ok {
+				return err
+			}
+			return convertAssign(dest, src)
 		}
-	default:
-		if destType != reflect.TypeOf(*src) {
-			return fmt.Errorf("Incompatible types")
+	}
+	return convertAssign
+}
+
+func setField(dest reflect.Value, src *interface{}, convert converter) error {
+	if convert == nil {
+		return SetValue(dest, src)
+	}
+	if !dest.CanSet() {
+		return fmt.Errorf("not settable")
+	}
+	return convert(dest, *src)
+}
+
+var scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+var jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// setFromUnmarshaler populates dest through sql.Scanner, encoding.TextUnmarshaler or json.Unmarshaler when the
+// destination implements one of them and src isn't already the destination type. ok is false when none apply
+func setFromUnmarshaler(dest reflect.Value, src interface{}) (ok bool, err error) {
+	if src != nil && reflect.TypeOf(src) == dest.Type() || dest.Type() == timeType || dest.Type() == reflect.PtrTo(timeType) {
+		return false, nil
+	}
+	var target reflect.Value
+	if dest.Kind() == reflect.Ptr {
+		if src == nil {
+			return false, nil
 		}
-		dest.Set(reflect.ValueOf(v))
+		target = reflect.New(dest.Type().Elem())
+	} else if dest.CanAddr() {
+		target = dest.Addr()
+	} else {
+		return false, nil
 	}
-	return nil
+
+	ok, err = unmarshalValue(target.Interface(), src)
+	if ok && err == nil && dest.Kind() == reflect.Ptr {
+		dest.Set(target)
+	}
+	return ok, err
 }
 
-func setFloat(destKind, destRootKind reflect.Kind, dest reflect.Value, v float64) {
-	if destKind == reflect.Float32 || destKind == reflect.Float64 {
-		dest.SetFloat(v)
-	} else if destKind == reflect.Ptr {
-		if destRootKind == reflect.Float32 {
-			f32 := float32(v)
-			dest.Set(reflect.ValueOf(&f32))
-		} else if destRootKind == reflect.Float64 {
-			f64 := float64(v)
-			dest.Set(reflect.ValueOf(&f64))
+func unmarshalValue(target interface{}, src interface{}) (bool, error) {
+	switch t := target.(type) {
+	case sql.Scanner:
+		return true, t.Scan(src)
+	case encoding.TextUnmarshaler:
+		switch v := src.(type) {
+		case string:
+			return true, t.UnmarshalText([]byte(v))
+		case []byte:
+			return true, t.UnmarshalText(v)
+		}
+	case json.Unmarshaler:
+		switch v := src.(type) {
+		case string:
+			return true, t.UnmarshalJSON([]byte(v))
+		case []byte:
+			return true, t.UnmarshalJSON(v)
 		}
 	}
+	return false, nil
 }
 
 func getRootValue(value reflect.Value) reflect.Value {
@@ -211,34 +246,30 @@ func getRootValue(value reflect.Value) reflect.Value {
 
 type structFieldInfo struct {
 	Name       string
+	Column     string
+	Field      string
 	Type       reflect.Type
-	FieldIndex int
+	FieldIndex []int
 	DBIndex    int
+	Convert    converter
 }
 
+// getItemTypeAndMap returns the item type and the cached column to field mapping for the result type
 func getItemTypeAndMap(columns []string, resultType reflect.Type) (reflect.Type, []structFieldInfo) {
-	itemType := resultType.Elem()
-	dbColumnToStruct := []structFieldInfo{}
-
-	// make columns all lowercase
-	for i, column := range columns {
-		columns[i] = strings.ToLower(column)
-	}
-
-	for structIndex := 0; structIndex < itemType.NumField(); structIndex++ {
-		field := itemType.Field(structIndex)
-		if dbIndex := getDBIndex(field.Name, columns); dbIndex != -1 {
-			dbColumnToStruct = append(dbColumnToStruct, structFieldInfo{strings.ToLower(field.Name), field.Type, structIndex, dbIndex})
-		}
-	}
-	return itemType, dbColumnToStruct
+	plan := getScanPlan(columns, resultType.Elem())
+	return plan.itemType, plan.fields
 }
 
-func getDBIndex(name string, columns []string) int {
-	for i, column := range columns {
-		if column == strings.ToLower(name) {
-			return i
+func mapColumnsToFields(columns []string, itemType reflect.Type) []structFieldInfo {
+	dbColumnToStruct := []structFieldInfo{}
+
+	claimed := make([]bool, len(columns))
+	for _, column := range getStructColumns(itemType) {
+		if dbIndex := getColumnIndex(column, columns, claimed); dbIndex != -1 {
+			claimed[dbIndex] = true
+			dbColumnToStruct = append(dbColumnToStruct, structFieldInfo{Name: strings.ToLower(column.Name), Column: columns[dbIndex], Field: column.Field,
+				Type: column.Type, FieldIndex: column.Index, DBIndex: dbIndex, Convert: newConverter(column.Type)})
 		}
 	}
-	return -1
+	return dbColumnToStruct
 }
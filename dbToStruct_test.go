@@ -1,6 +1,8 @@
 package onedb
 
 import (
+	"database/sql"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"math"
@@ -271,3 +273,152 @@ func TestGetItemTypeAndMap(t *testing.T) {
 		t.Error("expected different type and field map", itemType, dbToStructMap)
 	}
 }
+
+type taggedBase struct {
+	ID      int
+	Created time.Time `db:"created_at"`
+}
+
+type taggedAddress struct {
+	Street string
+	City   string
+}
+
+type TaggedItem struct {
+	taggedBase
+	UserName string `db:"user_name"`
+	Password string `db:"-"`
+	FullName string
+	Home     taggedAddress  `db:"home,prefix=home_"`
+	Work     *taggedAddress `db:"work,prefix=work_"`
+}
+
+func TestGetItemTypeAndMapTags(t *testing.T) {
+	columns := []string{"ID", "created_at", "USER_NAME", "password", "full_name", "home_street", "home_city", "work_city"}
+	_, dbToStructMap := getItemTypeAndMap(columns, reflect.TypeOf(&TaggedItem{}))
+	expected := map[string]int{"user_name": 2, "fullname": 4, "home_street": 5, "home_city": 6, "work_city": 7, "id": 0, "created_at": 1}
+	if len(dbToStructMap) != len(expected) {
+		t.Fatal("expected different field map", dbToStructMap)
+	}
+	for _, field := range dbToStructMap {
+		if dbIndex, ok := expected[field.Name]; !ok || dbIndex != field.DBIndex {
+			t.Error("unexpected field mapping", field)
+		}
+	}
+}
+
+func TestGetStructTags(t *testing.T) {
+	created := time.Date(2000, 1, 2, 3, 4, 5, 0, time.UTC)
+	data := []TaggedItem{{taggedBase: taggedBase{ID: 1, Created: created}, UserName: "user", Password: "secret", FullName: "Full Name",
+		Home: taggedAddress{"1 Main", "Springfield"}, Work: &taggedAddress{"2 Main", "Shelbyville"}}}
+	rows := NewRowsScanner(data)
+	columns, _ := rows.Columns()
+	if len(columns) != 8 || columns[0] != "user_name" || columns[1] != "FullName" || columns[2] != "ID" || columns[3] != "created_at" || columns[4] != "home_Street" {
+		t.Fatal("expected tag driven column names", columns)
+	}
+
+	result := []TaggedItem{}
+	err := getStruct(rows, &result)
+	if err != nil || len(result) != 1 {
+		t.Fatal("expected success", err, result)
+	}
+	item := result[0]
+	if item.ID != 1 || item.Created != created || item.UserName != "user" || item.Password != "" || item.FullName != "Full Name" ||
+		item.Home.City != "Springfield" || item.Work == nil || item.Work.Street != "2 Main" {
+		t.Error("expected values to be mapped by tag", item)
+	}
+}
+
+func TestGetStructPrefixSnakeCase(t *testing.T) {
+	type address struct {
+		ZipCode string
+		City    string `db:"city"`
+	}
+	type person struct {
+		Name string
+		Addr address `db:"addr,prefix=addr_"`
+	}
+	rows := NewRows("name", "addr_zip_code", "ADDR_CITY").AddRow("bob", "12345", "Springfield")
+	var result []person
+	if err := getStructWithOptions(rows, &result, StructOptions{Strict: true}); err != nil || len(result) != 1 ||
+		result[0].Addr.ZipCode != "12345" || result[0].Addr.City != "Springfield" {
+		t.Error("expected prefixed fields to match snake_case columns", result, err)
+	}
+	rows = NewRows("addr_zip_code").AddRow("12345")
+	type tagged struct {
+		Addr struct {
+			ZipCode string `db:"zipcode"`
+		} `db:"addr,prefix=addr_"`
+	}
+	var strict []tagged
+	if err := getStructWithOptions(rows, &strict, StructOptions{Strict: true}); err == nil {
+		t.Error("expected an explicitly tagged field to need an exact match", strict)
+	}
+}
+
+type testEnum int
+
+func (e *testEnum) UnmarshalText(text []byte) error {
+	switch string(text) {
+	case "on":
+		*e = 1
+	case "off":
+		*e = 0
+	default:
+		return fmt.Errorf("invalid enum value %s", text)
+	}
+	return nil
+}
+
+type testDoc struct {
+	Name string
+}
+
+func (d *testDoc) UnmarshalJSON(data []byte) error {
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	d.Name = m["name"]
+	return nil
+}
+
+type UnmarshalerStruct struct {
+	NullStr    sql.NullString
+	NullIntPtr *sql.NullInt64
+	Enum       testEnum
+	EnumPtr    *testEnum
+	Doc        testDoc
+}
+
+func TestSetValueUnmarshalers(t *testing.T) {
+	item := &UnmarshalerStruct{}
+	v := reflect.ValueOf(item).Elem()
+	set := func(field string, value interface{}) error {
+		src := new(interface{})
+		*src = value
+		return SetValue(v.FieldByName(field), src)
+	}
+
+	if err := set("NullStr", "hello"); err != nil || !item.NullStr.Valid || item.NullStr.String != "hello" {
+		t.Error("expected sql.Scanner to be used", item.NullStr, err)
+	}
+	if err := set("NullStr", nil); err != nil || item.NullStr.Valid {
+		t.Error("expected sql.Scanner to be used for null", item.NullStr, err)
+	}
+	if err := set("NullIntPtr", int64(12)); err != nil || item.NullIntPtr == nil || item.NullIntPtr.Int64 != 12 {
+		t.Error("expected pointer to sql.Scanner to be allocated", item.NullIntPtr, err)
+	}
+	if err := set("Enum", []byte("on")); err != nil || item.Enum != 1 {
+		t.Error("expected encoding.TextUnmarshaler to be used", item.Enum, err)
+	}
+	if err := set("EnumPtr", "on"); err != nil || item.EnumPtr == nil || *item.EnumPtr != 1 {
+		t.Error("expected pointer to encoding.TextUnmarshaler to be allocated", item.EnumPtr, err)
+	}
+	if err := set("Enum", "bogus"); err == nil {
+		t.Error("expected unmarshal error")
+	}
+	if err := set("Doc", `{"name":"doc"}`); err != nil || item.Doc.Name != "doc" {
+		t.Error("expected json.Unmarshaler to be used", item.Doc, err)
+	}
+}
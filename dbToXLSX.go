@@ -0,0 +1,358 @@
+package onedb
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// defaultXLSXWidthRows is the number of rows read ahead to size columns when XLSXOptions.WidthRows isn't set
+const defaultXLSXWidthRows = 100
+
+// XLSXOptions contains specifications for how an Excel workbook is written
+type XLSXOptions struct {
+	SheetName string // name of the worksheet. Characters Excel doesn't allow become underscores and it is cut to 31 characters. Defaults to Sheet1
+	DateOnly  bool   // format times as dates without the time of day
+	WidthRows int    // rows held in memory to size columns before the rest are streamed. Defaults to 100
+}
+
+// cell styles defined by xlsxStyles
+const (
+	xlsxStyleNone = iota
+	xlsxStyleHeader
+	xlsxStyleDate
+	xlsxStyleDateTime
+)
+
+const (
+	xlsxMinWidth = 8
+	xlsxMaxWidth = 60
+)
+
+// xlsxMaxSheetName is the longest worksheet name Excel opens
+const xlsxMaxSheetName = 31
+
+// excelEpoch is day zero of the Excel 1900 date system
+var excelEpoch = time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)
+
+// xlsxCell is a formatted worksheet cell
+type xlsxCell struct {
+	Type  string // t attribute: n, b or inlineStr. Empty for NULL values, which are left out
+	Style int
+	Value string
+	Width int // width in characters of the displayed value
+}
+
+// writeXLSX writes a workbook with a single worksheet. Columns are sized from the header and the first WidthRows
+// rows. Those rows are held in memory while the rest are streamed straight into the zip
+func writeXLSX(rows RowsScanner, w io.Writer, options XLSXOptions) error {
+	headers, vals, err := getColumnNamesAndValues(rows, false)
+	if err != nil {
+		return err
+	}
+	widthRows := options.WidthRows
+	if widthRows <= 0 {
+		widthRows = defaultXLSXWidthRows
+	}
+
+	widths := make([]int, len(headers))
+	for i, header := range headers {
+		widths[i] = utf8.RuneCountInString(header) + 2 // room for the bold font
+	}
+	var buffered [][]xlsxCell
+	more := false
+	for rows.Next() {
+		row, err := scanXLSX(rows, vals, options)
+		if err != nil {
+			return err
+		}
+		buffered = append(buffered, row)
+		for i, cell := range row {
+			if cell.Width > widths[i] {
+				widths[i] = cell.Width
+			}
+		}
+		if len(buffered) == widthRows {
+			more = true
+			break
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	z := zip.NewWriter(w)
+	if err := writeXLSXParts(z, options); err != nil {
+		return err
+	}
+	sheet, err := z.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return err
+	}
+	sw := bufio.NewWriter(sheet)
+	sw.WriteString(xml.Header)
+	sw.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	sw.WriteString(`<sheetViews><sheetView workbookViewId="0"><pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/></sheetView></sheetViews>`)
+	sw.WriteString(`<cols>`)
+	for i, width := range widths {
+		fmt.Fprintf(sw, `<col min="%d" max="%d" width="%d" customWidth="1"/>`, i+1, i+1, clampXLSXWidth(width))
+	}
+	sw.WriteString(`</cols><sheetData>`)
+
+	header := make([]xlsxCell, len(headers))
+	for i, name := range headers {
+		header[i] = xlsxCell{Type: "inlineStr", Style: xlsxStyleHeader, Value: name}
+	}
+	if err := writeXLSXRow(sw, 1, header); err != nil {
+		return err
+	}
+	rowNum := 2
+	for _, row := range buffered {
+		if err := writeXLSXRow(sw, rowNum, row); err != nil {
+			return err
+		}
+		rowNum++
+	}
+	if more {
+		for rows.Next() {
+			row, err := scanXLSX(rows, vals, options)
+			if err != nil {
+				return err
+			}
+			if err := writeXLSXRow(sw, rowNum, row); err != nil {
+				return err
+			}
+			rowNum++
+		}
+		if err := rows.Err(); err != nil {
+			return err
+		}
+	}
+	sw.WriteString(`</sheetData></worksheet>`)
+	if err := sw.Flush(); err != nil {
+		return err
+	}
+	return z.Close()
+}
+
+func clampXLSXWidth(width int) int {
+	if width < xlsxMinWidth {
+		return xlsxMinWidth
+	}
+	if width > xlsxMaxWidth {
+		return xlsxMaxWidth
+	}
+	return width
+}
+
+func writeXLSXRow(w *bufio.Writer, rowNum int, row []xlsxCell) error {
+	fmt.Fprintf(w, `<row r="%d">`, rowNum)
+	for i, cell := range row {
+		if cell.Type == "" {
+			continue
+		}
+		fmt.Fprintf(w, `<c r="%s%d"`, xlsxColumnName(i), rowNum)
+		if cell.Style != xlsxStyleNone {
+			fmt.Fprintf(w, ` s="%d"`, cell.Style)
+		}
+		if cell.Type == "inlineStr" {
+			w.WriteString(` t="inlineStr"><is><t xml:space="preserve">`)
+			xml.EscapeText(w, []byte(cell.Value))
+			w.WriteString(`</t></is></c>`)
+			continue
+		}
+		if cell.Type != "n" {
+			fmt.Fprintf(w, ` t="%s"`, cell.Type)
+		}
+		fmt.Fprintf(w, `><v>%s</v></c>`, cell.Value)
+	}
+	_, err := w.WriteString(`</row>`)
+	return err
+}
+
+// xlsxColumnName converts a zero based column index into its spreadsheet name: A, B, ... Z, AA, AB, ...
+func xlsxColumnName(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+func scanXLSX(s Scanner, vals []interface{}, options XLSXOptions) ([]xlsxCell, error) {
+	if err := s.Scan(vals...); err != nil {
+		return nil, err
+	}
+
+	row := make([]xlsxCell, len(vals))
+	for i, value := range vals {
+		val := value.(*interface{})
+		if err := resolveValuer(val); err != nil {
+			return nil, err
+		}
+		row[i] = getXLSXCell(*val, options)
+	}
+	return row, nil
+}
+
+func getXLSXCell(value interface{}, options XLSXOptions) xlsxCell {
+	switch v := value.(type) {
+	case nil:
+		return xlsxCell{}
+	case bool:
+		if v {
+			return xlsxCell{Type: "b", Value: "1", Width: 5}
+		}
+		return xlsxCell{Type: "b", Value: "0", Width: 5}
+	case int, int8, int16, int32, int64:
+		i := reflect.ValueOf(v).Int()
+		return getXLSXNumber(strconv.FormatInt(i, 10), math.Abs(float64(i)))
+	case uint, uint8, uint16, uint32, uint64:
+		u := reflect.ValueOf(v).Uint()
+		return getXLSXNumber(strconv.FormatUint(u, 10), float64(u))
+	case float32:
+		return getXLSXFloat(float64(v), 32)
+	case float64:
+		return getXLSXFloat(v, 64)
+	case time.Time:
+		return getXLSXTime(v, options)
+	case []byte:
+		return getXLSXString(string(v))
+	case string:
+		return getXLSXString(v)
+	case fmt.Stringer:
+		return getXLSXString(v.String())
+	default:
+		return getXLSXString(fmt.Sprintf("%v", v))
+	}
+}
+
+// getXLSXNumber writes integers that Excel can't hold without losing precision as text
+func getXLSXNumber(text string, magnitude float64) xlsxCell {
+	if magnitude > 1<<53 {
+		return getXLSXString(text)
+	}
+	return xlsxCell{Type: "n", Value: text, Width: len(text)}
+}
+
+func getXLSXFloat(f float64, bitSize int) xlsxCell {
+	text := strconv.FormatFloat(f, 'g', -1, bitSize)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return getXLSXString(text)
+	}
+	return xlsxCell{Type: "n", Value: text, Width: len(text)}
+}
+
+// getXLSXTime converts the wall clock time into an Excel serial date
+func getXLSXTime(t time.Time, options XLSXOptions) xlsxCell {
+	wall := time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.UTC)
+	days := float64(wall.Sub(excelEpoch)) / float64(24*time.Hour)
+	if options.DateOnly {
+		return xlsxCell{Type: "n", Style: xlsxStyleDate, Value: strconv.FormatFloat(math.Floor(days), 'f', -1, 64), Width: 10}
+	}
+	return xlsxCell{Type: "n", Style: xlsxStyleDateTime, Value: strconv.FormatFloat(days, 'f', -1, 64), Width: 19}
+}
+
+func getXLSXString(s string) xlsxCell {
+	return xlsxCell{Type: "inlineStr", Value: s, Width: utf8.RuneCountInString(s)}
+}
+
+// getXLSXSheetName makes name into a worksheet name Excel accepts: []:*?/\ are replaced with underscores, leading
+// and trailing apostrophes are removed and the result is cut to 31 characters
+func getXLSXSheetName(name string) string {
+	name = strings.Trim(strings.Map(func(r rune) rune {
+		if strings.ContainsRune(`[]:*?/\`, r) {
+			return '_'
+		}
+		return r
+	}, name), "'")
+	if runes := []rune(name); len(runes) > xlsxMaxSheetName {
+		name = string(runes[:xlsxMaxSheetName])
+	}
+	if name == "" {
+		return "Sheet1"
+	}
+	return name
+}
+
+// writeXLSXParts writes the package parts that don't depend on the data
+func writeXLSXParts(z *zip.Writer, options XLSXOptions) error {
+	var escapedName xmlText
+	xml.EscapeText(&escapedName, []byte(getXLSXSheetName(options.SheetName)))
+
+	parts := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRels},
+		{"xl/workbook.xml", fmt.Sprintf(xlsxWorkbook, string(escapedName))},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/styles.xml", xlsxStyles},
+	}
+	for _, part := range parts {
+		f, err := z.Create(part.name)
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, xml.Header+part.content); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// xmlText collects escaped text
+type xmlText []byte
+
+func (t *xmlText) Write(p []byte) (int, error) {
+	*t = append(*t, p...)
+	return len(p), nil
+}
+
+const xlsxContentTypes = `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+	`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+	`<Default Extension="xml" ContentType="application/xml"/>` +
+	`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+	`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+	`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>` +
+	`</Types>`
+
+const xlsxRels = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+	`</Relationships>`
+
+const xlsxWorkbook = `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+	`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets>` +
+	`</workbook>`
+
+const xlsxWorkbookRels = `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+	`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+	`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>` +
+	`</Relationships>`
+
+// xlsxStyles defines the cell formats in the order of the xlsxStyle constants: default, bold header on a grey
+// fill, date (built in format 14) and date with time (built in format 22)
+const xlsxStyles = `<styleSheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">` +
+	`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+	`<fills count="3"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill>` +
+	`<fill><patternFill patternType="solid"><fgColor rgb="FFD9D9D9"/><bgColor indexed="64"/></patternFill></fill></fills>` +
+	`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+	`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+	`<cellXfs count="4">` +
+	`<xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+	`<xf numFmtId="0" fontId="1" fillId="2" borderId="0" xfId="0" applyFont="1" applyFill="1"/>` +
+	`<xf numFmtId="14" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`<xf numFmtId="22" fontId="0" fillId="0" borderId="0" xfId="0" applyNumberFormat="1"/>` +
+	`</cellXfs>` +
+	`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+	`</styleSheet>`
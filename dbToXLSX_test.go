@@ -0,0 +1,139 @@
+package onedb
+
+import (
+	"archive/zip"
+	"bytes"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteXLSX(t *testing.T) {
+	type xlsxData struct {
+		Code    string
+		Count   int
+		Price   float64
+		Active  bool
+		Created time.Time
+		Note    sql.NullString
+		Big     int64
+	}
+	created := time.Date(2020, 1, 2, 12, 0, 0, 0, time.UTC)
+	rows := NewRowsScanner([]xlsxData{
+		{"007", 3, 1.5, true, created, sql.NullString{String: "a < b", Valid: true}, 1 << 60},
+		{"008", 4, 2.25, false, created, sql.NullString{}, 5},
+		{"009", 5, 3, true, created, sql.NullString{}, 6},
+	})
+	var b bytes.Buffer
+	if err := writeXLSX(rows, &b, XLSXOptions{SheetName: "Q&A", WidthRows: 2}); err != nil {
+		t.Fatal("expected success", err)
+	}
+
+	parts := readXLSXParts(t, b.Bytes())
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml", "xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Fatal("expected part", name)
+		}
+		if err := xml.Unmarshal([]byte(parts[name]), new(interface{})); err != nil {
+			t.Error("expected well formed xml", name, err)
+		}
+	}
+	if !strings.Contains(parts["xl/workbook.xml"], `name="Q&amp;A"`) {
+		t.Error("expected escaped sheet name", parts["xl/workbook.xml"])
+	}
+
+	sheet := parts["xl/worksheets/sheet1.xml"]
+	expected := []string{
+		`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>`,
+		`<col min="7" max="7" width="19" customWidth="1"/>`,
+		`<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">Code</t></is></c>`,
+		`<c r="A2" t="inlineStr"><is><t xml:space="preserve">007</t></is></c>`,
+		`<c r="B2"><v>3</v></c>`,
+		`<c r="C2"><v>1.5</v></c>`,
+		`<c r="D2" t="b"><v>1</v></c>`,
+		`<c r="E2" s="3"><v>43832.5</v></c>`,
+		`<c r="F2" t="inlineStr"><is><t xml:space="preserve">a &lt; b</t></is></c>`,
+		`<c r="G2" t="inlineStr"><is><t xml:space="preserve">1152921504606846976</t></is></c>`,
+		`<c r="G3"><v>5</v></c>`,
+		`<row r="4"><c r="A4" t="inlineStr">`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(sheet, e) {
+			t.Errorf("expected sheet to contain %s", e)
+		}
+	}
+	if strings.Contains(sheet, `r="F3"`) {
+		t.Error("expected NULL cell to be left out")
+	}
+}
+
+func TestWriteXLSXErrors(t *testing.T) {
+	rows := NewRowsScanner([]SimpleData{{1, "hello"}})
+	rows.(*mockRowsScanner).ScanErr = errors.New("fail")
+	if err := writeXLSX(rows, io.Discard, XLSXOptions{}); err == nil {
+		t.Error("expected scan error")
+	}
+	if err := writeXLSX(&mockRowsScanner{ColumnsErr: errors.New("fail")}, io.Discard, XLSXOptions{}); err == nil {
+		t.Error("expected columns error")
+	}
+}
+
+func TestXLSXCells(t *testing.T) {
+	date := time.Date(2020, 1, 2, 12, 0, 0, 0, time.FixedZone("EST", -5*3600))
+	if cell := getXLSXCell(date, XLSXOptions{DateOnly: true}); cell.Value != "43832" || cell.Style != xlsxStyleDate {
+		t.Error("expected whole day serial", cell)
+	}
+	if names := xlsxColumnName(0) + xlsxColumnName(25) + xlsxColumnName(26) + xlsxColumnName(701) + xlsxColumnName(702); names != "AZAAZZAAA" {
+		t.Error("expected column names", names)
+	}
+	if cell := getXLSXCell(uint8(7), XLSXOptions{}); cell.Type != "n" || cell.Value != "7" {
+		t.Error("expected number", cell)
+	}
+}
+
+func TestGetXLSXSheetName(t *testing.T) {
+	tests := map[string]string{
+		"":                                   "Sheet1",
+		"Q&A":                                "Q&A",
+		"2020/01 [draft]: a*b?c\\d":          "2020_01 _draft__ a_b_c_d",
+		"'quoted'":                           "quoted",
+		"'":                                  "Sheet1",
+		"a very long worksheet name over 31": "a very long worksheet name over",
+		"ünïcödé ünïcödé ünïcödé ünïcödé ünïcö": "ünïcödé ünïcödé ünïcödé ünïcödé",
+	}
+	for name, expected := range tests {
+		if actual := getXLSXSheetName(name); actual != expected {
+			t.Errorf("%q: expected %q. Actual: %q", name, expected, actual)
+		}
+	}
+}
+
+func TestQueryWriteXLSX(t *testing.T) {
+	db := NewMock(nil, nil, []SimpleData{{1, "hello"}})
+	var b bytes.Buffer
+	if err := QueryWriteXLSX(&b, XLSXOptions{}, db, "query"); err != nil {
+		t.Fatal("expected success", err)
+	}
+	if sheet := readXLSXParts(t, b.Bytes())["xl/worksheets/sheet1.xml"]; !strings.Contains(sheet, "hello") {
+		t.Error("expected row data", sheet)
+	}
+}
+
+func readXLSXParts(t *testing.T, data []byte) map[string]string {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatal("expected zip file", err)
+	}
+	parts := make(map[string]string)
+	for _, f := range r.File {
+		rc, _ := f.Open()
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(content)
+	}
+	return parts
+}
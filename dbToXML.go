@@ -0,0 +1,132 @@
+package onedb
+
+import (
+	"bufio"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// XMLOptions contains specifications for how query results are written as XML
+type XMLOptions struct {
+	RootElement   string         // name of the document element. Defaults to rows
+	RowElement    string         // name of the element written for each row. Defaults to row
+	Attributes    []string       // columns written as attributes of the row element instead of child elements
+	AllAttributes bool           // write every column as an attribute
+	DateOnly      bool           // write times as dates
+	TimeLayout    string         // layout used to format times. Overrides DateOnly
+	Location      *time.Location // times are converted to this time zone before they are formatted
+	Null          string         // text written for NULL values. When empty NULL columns are left out of the row
+	Columns       []string       // columns to write, in this order. Defaults to every column in query order
+	Exclude       []string       // columns to leave out
+}
+
+// writeXML writes the rows as <rows><row><column>value</column></row></rows>. Column names are made into valid
+// XML names by replacing the characters XML doesn't allow with underscores. Names that end up the same as an earlier
+// column's are given a _2, _3, ... suffix
+func writeXML(rows RowsScanner, w io.Writer, options XMLOptions) error {
+	columns, vals, err := getColumnNamesAndValues(rows, false)
+	if err != nil {
+		return err
+	}
+	indexes, err := getColumnIndexes(columns, options.Columns, options.Exclude)
+	if err != nil {
+		return err
+	}
+	names := make([]string, len(columns))
+	attributes := make([]bool, len(columns))
+	used := make(map[string]bool)
+	for _, index := range indexes {
+		names[index] = getUniqueXMLName(columns[index], used)
+		attributes[index] = options.AllAttributes || indexOfColumn(options.Attributes, columns[index]) != -1
+	}
+	root := getXMLName(defaultString(options.RootElement, "rows"))
+	rowElement := getXMLName(defaultString(options.RowElement, "row"))
+	format := textFormat{DateOnly: options.DateOnly, TimeLayout: options.TimeLayout, Location: options.Location, Null: options.Null}
+
+	b := bufio.NewWriter(w)
+	b.WriteString(xml.Header)
+	b.WriteString("<" + root + ">\n")
+	for rows.Next() {
+		if err := rows.Scan(vals...); err != nil {
+			return err
+		}
+		for _, index := range indexes {
+			if err := resolveValuer(vals[index].(*interface{})); err != nil {
+				return err
+			}
+		}
+		b.WriteString("<" + rowElement)
+		for _, index := range indexes {
+			if value, ok := getXMLValue(vals[index].(*interface{}), format); ok && attributes[index] {
+				b.WriteString(" " + names[index] + `="`)
+				xml.EscapeText(b, []byte(value))
+				b.WriteByte('"')
+			}
+		}
+		b.WriteByte('>')
+		for _, index := range indexes {
+			if value, ok := getXMLValue(vals[index].(*interface{}), format); ok && !attributes[index] {
+				b.WriteString("<" + names[index] + ">")
+				xml.EscapeText(b, []byte(value))
+				b.WriteString("</" + names[index] + ">")
+			}
+		}
+		b.WriteString("</" + rowElement + ">\n")
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	b.WriteString("</" + root + ">\n")
+	return b.Flush()
+}
+
+// getXMLValue formats the value as text. ok is false for a NULL that is left out of the row
+func getXMLValue(pval *interface{}, format textFormat) (string, bool) {
+	if *pval == nil && format.Null == "" {
+		return "", false
+	}
+	return formatText(pval, format), true
+}
+
+// getXMLName replaces the characters that aren't allowed in an XML name with underscores
+func getXMLName(name string) string {
+	if name == "" {
+		return "_"
+	}
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case unicode.IsLetter(r) || r == '_':
+			b.WriteRune(r)
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'):
+			b.WriteRune(r)
+		case i == 0 && unicode.IsDigit(r):
+			b.WriteRune('_')
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// getUniqueXMLName returns the XML name of column, suffixed with a number when an earlier column already has it
+func getUniqueXMLName(column string, used map[string]bool) string {
+	name := getXMLName(column)
+	for i := 2; used[name]; i++ {
+		name = getXMLName(column) + "_" + strconv.Itoa(i)
+	}
+	used[name] = true
+	return name
+}
+
+func defaultString(value, defaultValue string) string {
+	if value == "" {
+		return defaultValue
+	}
+	return value
+}
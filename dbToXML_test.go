@@ -0,0 +1,98 @@
+package onedb
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/xml"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteXML(t *testing.T) {
+	type xmlData struct {
+		ID      int            `db:"id"`
+		Name    sql.NullString `db:"name"`
+		Created time.Time      `db:"created"`
+		Note    string         `db:"2 note"`
+	}
+	created := time.Date(2020, 1, 2, 23, 30, 0, 0, time.UTC)
+	data := []xmlData{{1, sql.NullString{String: "bob", Valid: true}, created, `a < b & "c"`}, {2, sql.NullString{}, created, ""}}
+
+	tests := []struct {
+		options  XMLOptions
+		expected string
+	}{
+		{XMLOptions{DateOnly: true}, xml.Header + "<rows>\n" +
+			"<row><id>1</id><name>bob</name><created>2020-01-02</created><_2_note>a &lt; b &amp; &#34;c&#34;</_2_note></row>\n" +
+			"<row><id>2</id><created>2020-01-02</created><_2_note></_2_note></row>\n</rows>\n"},
+		{XMLOptions{RootElement: "orders", RowElement: "order", Attributes: []string{"ID", "2 note"}, Exclude: []string{"created"}, Null: "NULL"}, xml.Header + "<orders>\n" +
+			`<order id="1" _2_note="a &lt; b &amp; &#34;c&#34;"><name>bob</name></order>` + "\n" +
+			`<order id="2" _2_note=""><name>NULL</name></order>` + "\n</orders>\n"},
+		{XMLOptions{AllAttributes: true, Columns: []string{"name", "id"}}, xml.Header + "<rows>\n" +
+			`<row name="bob" id="1"></row>` + "\n" + `<row id="2"></row>` + "\n</rows>\n"},
+	}
+	for i, test := range tests {
+		var b bytes.Buffer
+		if err := writeXML(NewRowsScanner(data), &b, test.options); err != nil || b.String() != test.expected {
+			t.Errorf("test %d: expected %q. Actual: %q %v", i, test.expected, b.String(), err)
+		}
+	}
+
+	// output is well formed
+	var b bytes.Buffer
+	writeXML(NewRowsScanner(data), &b, XMLOptions{Attributes: []string{"name"}})
+	var doc struct {
+		Rows []struct {
+			Name string `xml:"name,attr"`
+			ID   int    `xml:"id"`
+		} `xml:"row"`
+	}
+	if err := xml.Unmarshal(b.Bytes(), &doc); err != nil || len(doc.Rows) != 2 || doc.Rows[0].Name != "bob" || doc.Rows[1].ID != 2 {
+		t.Error("expected valid XML", err, doc)
+	}
+
+	if err := writeXML(NewRowsScanner(data), &b, XMLOptions{Columns: []string{"Missing"}}); err == nil {
+		t.Error("expected missing column error")
+	}
+	rows := NewRowsScanner(data)
+	rows.(*mockRowsScanner).ScanErr = errors.New("fail")
+	if err := writeXML(rows, &b, XMLOptions{}); err == nil {
+		t.Error("expected scan error")
+	}
+}
+
+func TestWriteXMLBytes(t *testing.T) {
+	var b bytes.Buffer
+	expected := xml.Header + "<rows>\n<row name=\"a&amp;b\"><note>hi</note></row>\n</rows>\n"
+	if err := writeXML(NewRows("name", "note").AddRow([]byte("a&b"), []byte("hi")), &b, XMLOptions{Attributes: []string{"name"}}); err != nil || b.String() != expected {
+		t.Errorf("expected []byte to be written as text. Actual: %q %v", b.String(), err)
+	}
+}
+
+func TestGetXMLName(t *testing.T) {
+	tests := map[string]string{"name": "name", "first name": "first_name", "count(*)": "count___", "1st": "_1st", "a-b.c": "a-b.c", "": "_"}
+	for name, expected := range tests {
+		if actual := getXMLName(name); actual != expected {
+			t.Errorf("%q: expected %q. Actual: %q", name, expected, actual)
+		}
+	}
+}
+
+func TestWriteXMLNameCollisions(t *testing.T) {
+	var b bytes.Buffer
+	rows := NewRows("a b", "a_b", "a_b_2", "c").AddRow(1, 2, 3, 4)
+	expected := xml.Header + "<rows>\n<row c=\"4\"><a_b>1</a_b><a_b_2>2</a_b_2><a_b_2_2>3</a_b_2_2></row>\n</rows>\n"
+	if err := writeXML(rows, &b, XMLOptions{Attributes: []string{"c"}}); err != nil || b.String() != expected {
+		t.Errorf("expected %q. Actual: %q %v", expected, b.String(), err)
+	}
+}
+
+func TestQueryWriteXML(t *testing.T) {
+	db := NewMock(nil, nil, []SimpleData{{1, "hello"}})
+	var b bytes.Buffer
+	if err := QueryWriteXML(&b, XMLOptions{}, db, "query"); err != nil || !strings.Contains(b.String(), "hello") {
+		t.Error("expected row data", b.String(), err)
+	}
+}
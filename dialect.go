@@ -0,0 +1,75 @@
+package onedb
+
+import "strconv"
+
+// Dialect identifies the SQL flavor spoken by a database server so that generated statements use the right
+// placeholder style
+type Dialect int
+
+const (
+	// MySQL uses ? placeholders. It is also the default for backends that don't report a Dialect
+	MySQL Dialect = iota
+	// PostgreSQL uses $1, $2, ... placeholders
+	PostgreSQL
+	// SQLServer uses @p1, @p2, ... placeholders
+	SQLServer
+)
+
+// Dialecter is implemented by backends that know which Dialect they speak
+type Dialecter interface {
+	Dialect() Dialect
+}
+
+// Placeholder returns the bind parameter for the nth (1 based) argument of a statement
+func (d Dialect) Placeholder(n int) string {
+	switch d {
+	case PostgreSQL:
+		return "$" + strconv.Itoa(n)
+	case SQLServer:
+		return "@p" + strconv.Itoa(n)
+	}
+	return "?"
+}
+
+func (d Dialect) String() string {
+	switch d {
+	case PostgreSQL:
+		return "PostgreSQL"
+	case SQLServer:
+		return "SQLServer"
+	}
+	return "MySQL"
+}
+
+// DialectFor returns the Dialect for a database/sql driver name. Unknown drivers use MySQL
+func DialectFor(driverName string) Dialect {
+	switch driverName {
+	case "postgres", "pgx", "pq":
+		return PostgreSQL
+	case "mssql", "sqlserver":
+		return SQLServer
+	}
+	return MySQL
+}
+
+// getDialect returns the Dialect reported by the backend or MySQL if it doesn't report one
+func getDialect(backend interface{}) Dialect {
+	if d, ok := backend.(Dialecter); ok {
+		return d.Dialect()
+	}
+	return MySQL
+}
+
+// isIdentifier allows plain identifiers only so that table and column names written into generated
+// SQL can't inject SQL
+func isIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -1,30 +1,39 @@
 module github.com/EndFirstCorp/onedb
 
-go 1.13
+go 1.18
 
 require (
-	github.com/cockroachdb/apd v1.1.0 // indirect
-	github.com/confluentinc/confluent-kafka-go v1.5.2 // indirect
 	github.com/denisenkom/go-mssqldb v0.0.0-20200131184339-0f454e2ecd6a
 	github.com/garyburd/redigo v1.6.0
 	github.com/go-sql-driver/mysql v1.5.0
+	github.com/pkg/errors v0.8.1
+	github.com/stretchr/testify v1.7.0
+	gopkg.in/confluentinc/confluent-kafka-go.v1 v1.5.2
+	gopkg.in/jackc/pgx.v2 v2.11.0
+	gopkg.in/ldap.v2 v2.5.1
+	gopkg.in/mgo.v2 v2.0.0-20190816093944-a6b53ec6cb22
+)
+
+require (
+	github.com/cockroachdb/apd v1.1.0 // indirect
+	github.com/confluentinc/confluent-kafka-go v1.5.2 // indirect
+	github.com/davecgh/go-spew v1.1.0 // indirect
 	github.com/go-stack/stack v1.8.0 // indirect
 	github.com/gofrs/uuid v3.3.0+incompatible // indirect
+	github.com/golang-sql/civil v0.0.0-20190719163853-cb61b32ac6fe // indirect
 	github.com/jackc/fake v0.0.0-20150926172116-812a484cc733 // indirect
 	github.com/jackc/pgx v3.6.2+incompatible // indirect
 	github.com/lib/pq v1.8.0 // indirect
 	github.com/mattn/go-colorable v0.1.7 // indirect
 	github.com/niemeyer/pretty v0.0.0-20200227124842-a10e7caefd8e // indirect
-	github.com/pkg/errors v0.8.1
+	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/shopspring/decimal v1.2.0 // indirect
-	github.com/stretchr/testify v1.7.0
+	github.com/stretchr/objx v0.1.0 // indirect
+	golang.org/x/crypto v0.0.0-20190325154230-a5d413f7728c // indirect
 	golang.org/x/text v0.3.3 // indirect
 	gopkg.in/asn1-ber.v1 v1.0.0-20181015200546-f715ec2f112d // indirect
 	gopkg.in/check.v1 v1.0.0-20200902074654-038fdea0a05b // indirect
-	gopkg.in/confluentinc/confluent-kafka-go.v1 v1.5.2
 	gopkg.in/inconshreveable/log15.v2 v2.0.0-20200109203555-b30bc20e4fd1 // indirect
-	gopkg.in/jackc/pgx.v2 v2.11.0
-	gopkg.in/ldap.v2 v2.5.1
-	gopkg.in/mgo.v2 v2.0.0-20190816093944-a6b53ec6cb22
 	gopkg.in/yaml.v2 v2.3.0 // indirect
+	gopkg.in/yaml.v3 v3.0.0-20200313102051-9f266ea9e77c // indirect
 )
@@ -1,6 +1,7 @@
 package onedb
 
 import (
+	"context"
 	"io"
 
 	"github.com/pkg/errors"
@@ -12,6 +13,32 @@ type Backender interface {
 	QueryRow(query string, args ...interface{}) Scanner
 }
 
+// ContextBackender is the optional extension to Backender for backends that can abort a running query
+// when the provided context is cancelled or its deadline expires
+type ContextBackender interface {
+	Backender
+	QueryContext(ctx context.Context, query string, args ...interface{}) (RowsScanner, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) Scanner
+}
+
+// Execer is the interface for backends that can run commands that don't return rows
+type Execer interface {
+	Exec(query string, args ...interface{}) (Result, error)
+}
+
+// ContextExecer is the optional extension to Execer for backends that can abort a running command when the
+// provided context is cancelled or its deadline expires
+type ContextExecer interface {
+	Execer
+	ExecContext(ctx context.Context, query string, args ...interface{}) (Result, error)
+}
+
+// Result summarizes an executed command. It is satisfied by database/sql's Result
+type Result interface {
+	LastInsertId() (int64, error)
+	RowsAffected() (int64, error)
+}
+
 // RowsScanner is the rows interface needed by onedb to enable QueryStruct and QueryJSON capability
 type RowsScanner interface {
 	Close() error
@@ -28,12 +55,22 @@ type Scanner interface {
 
 // DBer is the added interface that onedb can enable for database querying
 type DBer interface {
+	Exec(query string, args ...interface{}) (Result, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (Result, error)
+
 	QueryValues(query *Query, result ...interface{}) error
 	QueryJSON(query string, args ...interface{}) (string, error)
 	QueryJSONRow(query string, args ...interface{}) (string, error)
 	QueryStruct(result interface{}, query string, args ...interface{}) error
 	QueryStructRow(result interface{}, query string, args ...interface{}) error
 	QueryWriteCSV(w io.Writer, options CSVOptions, query string, args ...interface{}) error
+
+	QueryValuesContext(ctx context.Context, query *Query, result ...interface{}) error
+	QueryJSONContext(ctx context.Context, query string, args ...interface{}) (string, error)
+	QueryJSONRowContext(ctx context.Context, query string, args ...interface{}) (string, error)
+	QueryStructContext(ctx context.Context, result interface{}, query string, args ...interface{}) error
+	QueryStructRowContext(ctx context.Context, result interface{}, query string, args ...interface{}) error
+	QueryWriteCSVContext(ctx context.Context, w io.Writer, options CSVOptions, query string, args ...interface{}) error
 }
 
 // ErrRowsScannerInvalidData occurs when the provided data is not a slice of type struct.
@@ -42,5 +79,11 @@ var ErrRowsScannerInvalidData = errors.New("data must be a slice of structs")
 // ErrRowScannerInvalidData occurs when the provided data is not a pointer to a struct.
 var ErrRowScannerInvalidData = errors.New("data must be a ptr to a struct")
 
+// ErrLastInsertIDNotSupported occurs when the backend can't report the id generated by an insert.
+var ErrLastInsertIDNotSupported = errors.New("LastInsertId is not supported by this backend")
+
+// ErrEmptyResultSet occurs when a query for a single row returns no rows
+var ErrEmptyResultSet = errors.New("Empty result set")
+
 // ErrQueryIsNil occurs when the provided query is invalid.
 var ErrQueryIsNil = errors.New("invalid query")
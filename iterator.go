@@ -0,0 +1,148 @@
+package onedb
+
+import (
+	"context"
+	"reflect"
+)
+
+// Iterator decodes the rows of a RowsScanner into a T one row at a time so that large result sets don't need
+// to be held in memory. When T has child slices the adjacent rows sharing a parent key are merged into one T, so
+// the query must be ordered by the parent key
+type Iterator[T any] struct {
+	rows       RowsScanner
+	vals       []interface{}
+	dbToStruct []structFieldInfo
+	relations  *relationPlan // set when T has child slices
+	pending    bool          // vals holds a row already scanned that starts the next item
+	current    T
+	err        error
+}
+
+// NewIterator returns an Iterator over the provided rows. The rows are closed by Iterator.Close
+func NewIterator[T any](rows RowsScanner) (*Iterator[T], error) {
+	itemType := reflect.TypeOf((*T)(nil)).Elem()
+	if !IsStruct(itemType) {
+		return nil, ErrTypeNotStruct
+	}
+	columns, vals, err := getColumnNamesAndValues(rows, false)
+	if err != nil {
+		return nil, err
+	}
+	if relations := getRelationPlan(columns, itemType); relations != nil {
+		return &Iterator[T]{rows: rows, vals: vals, relations: relations}, nil
+	}
+	_, dbToStruct := getItemTypeAndMap(columns, reflect.PtrTo(itemType))
+	return &Iterator[T]{rows: rows, vals: vals, dbToStruct: dbToStruct}, nil
+}
+
+// Next decodes the next row so that it is available from Value. It returns false when there are no more rows
+// or an error occurs. Check Err to distinguish between the two
+func (it *Iterator[T]) Next() bool {
+	if it.err != nil {
+		return false
+	}
+	if it.relations != nil {
+		return it.nextRelated()
+	}
+	if !it.rows.Next() {
+		return false
+	}
+	var item T
+	if err := scanStruct(it.rows, it.vals, it.dbToStruct, &item); err != nil {
+		it.err = err
+		return false
+	}
+	it.current = item
+	return true
+}
+
+// nextRelated merges rows into one item until a row with a different parent key, which is kept in vals for the
+// following call
+func (it *Iterator[T]) nextRelated() bool {
+	items := reflect.New(reflect.SliceOf(it.relations.itemType)).Elem()
+	state := &relationState{index: make(map[string]int)}
+	for it.pending || it.rows.Next() {
+		if !it.pending {
+			if err := it.rows.Scan(it.vals...); err != nil {
+				it.err = err
+				return false
+			}
+		}
+		it.pending = false
+		if _, ok := state.index[it.relations.rowKey(it.vals)]; !ok && len(state.index) > 0 {
+			it.pending = true
+			break
+		}
+		if err := it.relations.merge(items, false, state, it.vals); err != nil {
+			it.err = err
+			return false
+		}
+	}
+	if items.Len() == 0 {
+		return false
+	}
+	it.current = items.Index(0).Interface().(T)
+	return true
+}
+
+// Value returns the row decoded by the last call to Next
+func (it *Iterator[T]) Value() T {
+	return it.current
+}
+
+// Err returns the first error that occurred during iteration
+func (it *Iterator[T]) Err() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.rows.Err()
+}
+
+// Close closes the underlying rows
+func (it *Iterator[T]) Close() error {
+	return it.rows.Close()
+}
+
+// QueryIterator runs a query against the provided Backender and returns an Iterator over the results. The caller
+// must Close the Iterator
+func QueryIterator[T any](backend Backender, query string, args ...interface{}) (*Iterator[T], error) {
+	return QueryIteratorContext[T](context.Background(), backend, query, args...)
+}
+
+// QueryIteratorContext runs a query against the provided Backender and returns an Iterator over the results. The
+// query is aborted if ctx is done. The caller must Close the Iterator
+func QueryIteratorContext[T any](ctx context.Context, backend Backender, query string, args ...interface{}) (*Iterator[T], error) {
+	rows, err := queryContext(ctx, backend, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	it, err := NewIterator[T](rows)
+	if err != nil {
+		rows.Close()
+		return nil, err
+	}
+	return it, nil
+}
+
+// QueryEach runs a query against the provided Backender and calls fn with each row. Iteration stops and the rows
+// are closed when fn returns an error, which is then returned from QueryEach
+func QueryEach[T any](backend Backender, fn func(row T) error, query string, args ...interface{}) error {
+	return QueryEachContext(context.Background(), backend, fn, query, args...)
+}
+
+// QueryEachContext runs a query against the provided Backender and calls fn with each row. The query is aborted if
+// ctx is done. Iteration stops and the rows are closed when fn returns an error, which is then returned
+func QueryEachContext[T any](ctx context.Context, backend Backender, fn func(row T) error, query string, args ...interface{}) error {
+	it, err := QueryIteratorContext[T](ctx, backend, query, args...)
+	if err != nil {
+		return err
+	}
+	defer it.Close()
+
+	for it.Next() {
+		if err := fn(it.Value()); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
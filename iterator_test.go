@@ -0,0 +1,114 @@
+package onedb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestQueryEach(t *testing.T) {
+	rows := &closeRows{RowsScanner: NewRowsScanner([]SimpleData{{1, "hello"}, {2, "world"}, {3, "test"}})}
+	db := &mockBackend{Rows: rows}
+
+	// success
+	var seen []SimpleData
+	err := QueryEach(db, func(row SimpleData) error {
+		seen = append(seen, row)
+		return nil
+	}, "query")
+	if err != nil || len(seen) != 3 || seen[2].IntVal != 3 || !rows.closed {
+		t.Error("expected every row", err, seen)
+	}
+
+	// callback error
+	rows = &closeRows{RowsScanner: NewRowsScanner([]SimpleData{{1, "hello"}, {2, "world"}, {3, "test"}})}
+	db = &mockBackend{Rows: rows}
+	fail := errors.New("fail")
+	count := 0
+	err = QueryEach(db, func(row SimpleData) error {
+		count++
+		if row.IntVal == 2 {
+			return fail
+		}
+		return nil
+	}, "query")
+	if err != fail || count != 2 || !rows.closed {
+		t.Error("expected iteration to stop at callback error", err, count)
+	}
+
+	// query error
+	db = &mockBackend{QueryErr: errors.New("fail")}
+	if err = QueryEach(db, func(row SimpleData) error { return nil }, "query"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestIterator(t *testing.T) {
+	rows := NewRowsScanner([]SimpleData{{1, "hello"}, {2, "world"}})
+	it, err := NewIterator[SimpleData](rows)
+	if err != nil {
+		t.Fatal("expected success", err)
+	}
+	count := 0
+	for it.Next() {
+		count++
+		if it.Value().IntVal != count {
+			t.Error("expected rows in order", it.Value())
+		}
+	}
+	if count != 2 || it.Err() != nil {
+		t.Error("expected 2 rows", count, it.Err())
+	}
+
+	// scan error
+	rows = NewRowsScanner([]SimpleData{{1, "hello"}})
+	rows.(*mockRowsScanner).ScanErr = errors.New("fail")
+	it, _ = NewIterator[SimpleData](rows)
+	if it.Next() || it.Err() == nil {
+		t.Error("expected error")
+	}
+
+	// not a struct
+	if _, err = NewIterator[string](rows); err != ErrTypeNotStruct {
+		t.Error("expected type error", err)
+	}
+}
+
+func TestIteratorRelations(t *testing.T) {
+	rows := &closeRows{RowsScanner: NewRowsScanner(relationRows()[:5])}
+	var orders []relationOrder
+	err := QueryEach(&mockBackend{Rows: rows}, func(order relationOrder) error {
+		orders = append(orders, order)
+		return nil
+	}, "query")
+	if err != nil || len(orders) != 3 || !rows.closed {
+		t.Fatal("expected one order per parent key", err, orders)
+	}
+	alice, bob, carol := orders[0], orders[1], orders[2]
+	if alice.ID != 1 || len(alice.Lines) != 2 || len(alice.Lines[0].Notes) != 2 || alice.Lines[1].SKU != "b" {
+		t.Error("expected merged lines and notes", alice)
+	}
+	if bob.ID != 2 || bob.Lines != nil {
+		t.Error("expected outer join without lines", bob)
+	}
+	if carol.ID != 3 || len(carol.Lines) != 1 || carol.Lines[0].SKU != "c" {
+		t.Error("expected one line", carol)
+	}
+
+	// scan error
+	scanRows := NewRowsScanner(relationRows())
+	scanRows.(*mockRowsScanner).ScanErr = errors.New("fail")
+	it, _ := NewIterator[relationOrder](scanRows)
+	if it.Next() || it.Err() == nil {
+		t.Error("expected error")
+	}
+}
+
+type closeRows struct {
+	RowsScanner
+	closed bool
+}
+
+func (r *closeRows) Close() error {
+	r.closed = true
+	return r.RowsScanner.Close()
+}
@@ -1,6 +1,7 @@
 package onedb
 
 import (
+	"context"
 	"crypto/tls"
 	"encoding/json"
 	"fmt"
@@ -25,6 +26,13 @@ type LDAPer interface {
 	QueryStruct(result interface{}, query *ldap.SearchRequest) error
 	QueryStructRow(result interface{}, query *ldap.SearchRequest) error
 	QueryValues(query *ldap.SearchRequest, result ...interface{}) error
+
+	QueryContext(ctx context.Context, query *ldap.SearchRequest) (*ldap.SearchResult, error)
+	QueryJSONContext(ctx context.Context, query *ldap.SearchRequest) (string, error)
+	QueryJSONRowContext(ctx context.Context, query *ldap.SearchRequest) (string, error)
+	QueryStructContext(ctx context.Context, result interface{}, query *ldap.SearchRequest) error
+	QueryStructRowContext(ctx context.Context, result interface{}, query *ldap.SearchRequest) error
+	QueryValuesContext(ctx context.Context, query *ldap.SearchRequest, result ...interface{}) error
 }
 
 var errInvalidLdapQueryType = errors.New("Invalid query. Must be of type *ldap.SearchRequest")
@@ -97,7 +105,11 @@ func (l *ldapBackend) Bind(username, password string) error {
 }
 
 func (l *ldapBackend) QueryJSON(query *ldap.SearchRequest) (string, error) {
-	res, err := l.Query(query)
+	return l.QueryJSONContext(context.Background(), query)
+}
+
+func (l *ldapBackend) QueryJSONContext(ctx context.Context, query *ldap.SearchRequest) (string, error) {
+	res, err := l.QueryContext(ctx, query)
 	if err != nil {
 		return "", err
 	}
@@ -110,7 +122,11 @@ func (l *ldapBackend) QueryJSON(query *ldap.SearchRequest) (string, error) {
 }
 
 func (l *ldapBackend) QueryJSONRow(query *ldap.SearchRequest) (string, error) {
-	res, err := l.Query(query)
+	return l.QueryJSONRowContext(context.Background(), query)
+}
+
+func (l *ldapBackend) QueryJSONRowContext(ctx context.Context, query *ldap.SearchRequest) (string, error) {
+	res, err := l.QueryContext(ctx, query)
 	if err != nil {
 		return "", err
 	}
@@ -129,12 +145,16 @@ type fieldInfo struct {
 }
 
 func (l *ldapBackend) QueryStruct(result interface{}, query *ldap.SearchRequest) error {
+	return l.QueryStructContext(context.Background(), result, query)
+}
+
+func (l *ldapBackend) QueryStructContext(ctx context.Context, result interface{}, query *ldap.SearchRequest) error {
 	resultType := reflect.TypeOf(result)
 	if result == nil || !onedb.IsPointer(resultType) || !onedb.IsSlice(resultType.Elem()) {
 		return errors.New("Invalid result argument.  Must be a pointer to a slice")
 	}
 
-	res, err := l.Query(query)
+	res, err := l.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
@@ -185,11 +205,15 @@ func setRowValue(row reflect.Value, field *fieldInfo, vals []string) error {
 }
 
 func (l *ldapBackend) QueryValues(query *ldap.SearchRequest, result ...interface{}) error {
+	return l.QueryValuesContext(context.Background(), query, result...)
+}
+
+func (l *ldapBackend) QueryValuesContext(ctx context.Context, query *ldap.SearchRequest, result ...interface{}) error {
 	if result == nil || !onedb.IsPointer(reflect.TypeOf(result)) || reflect.TypeOf(result).Elem().Kind() == reflect.Struct {
 		return errors.New("Invalid result argument.  Must be a pointer to a primitive type")
 	}
 
-	res, err := l.Query(query)
+	res, err := l.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
@@ -205,11 +229,15 @@ func (l *ldapBackend) QueryValues(query *ldap.SearchRequest, result ...interface
 }
 
 func (l *ldapBackend) QueryStructRow(result interface{}, query *ldap.SearchRequest) error {
+	return l.QueryStructRowContext(context.Background(), result, query)
+}
+
+func (l *ldapBackend) QueryStructRowContext(ctx context.Context, result interface{}, query *ldap.SearchRequest) error {
 	if result == nil || !onedb.IsPointer(reflect.TypeOf(result)) {
 		return errors.New("Invalid result argument.  Must be a pointer to a struct")
 	}
 
-	res, err := l.Query(query)
+	res, err := l.QueryContext(ctx, query)
 	if err != nil {
 		return err
 	}
@@ -256,16 +284,49 @@ func (l *ldapBackend) Execute(query interface{}) error {
 }
 
 func (l *ldapBackend) Query(query *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	return l.QueryContext(context.Background(), query)
+}
+
+// QueryContext runs the search and closes the connection if ctx is done before the search completes. The
+// ldap library has no way to abandon a single request, so the next query reconnects
+func (l *ldapBackend) QueryContext(ctx context.Context, query *ldap.SearchRequest) (*ldap.SearchResult, error) {
 	if query == nil {
 		return nil, onedb.ErrQueryIsNil
 	}
-	res, err := l.l.Search(query)
-	if err != nil && strings.HasSuffix(err.Error(), "ldap: connection closed") && l.reconnect() {
-		return l.Query(query)
+	res, err := l.search(ctx, query)
+	if err != nil && ctx.Err() == nil && strings.HasSuffix(err.Error(), "ldap: connection closed") && l.reconnect() {
+		return l.QueryContext(ctx, query)
 	}
 	return res, err
 }
 
+type searchResult struct {
+	res *ldap.SearchResult
+	err error
+}
+
+func (l *ldapBackend) search(ctx context.Context, query *ldap.SearchRequest) (*ldap.SearchResult, error) {
+	if ctx.Done() == nil {
+		return l.l.Search(query)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	conn := l.l
+	done := make(chan searchResult, 1)
+	go func() {
+		res, err := conn.Search(query)
+		done <- searchResult{res, err}
+	}()
+	select {
+	case <-ctx.Done():
+		conn.Close()
+		return nil, ctx.Err()
+	case r := <-done:
+		return r.res, r.err
+	}
+}
+
 func (l *ldapBackend) reconnect() bool {
 	ms := time.Millisecond * time.Duration(math.Pow10(l.retryCount)) // retry every 10^lastRetry milliseconds
 	if time.Since(l.lastRetry) > ms {
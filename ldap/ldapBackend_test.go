@@ -1,6 +1,7 @@
 package onedb
 
 import (
+	"context"
 	"crypto/tls"
 	"errors"
 	"net"
@@ -93,6 +94,26 @@ func TestLdapQuery(t *testing.T) {
 	}
 }
 
+func TestLdapQueryContext(t *testing.T) {
+	m := newMockLdap()
+	l := &ldapBackend{l: m}
+	entries := []*ldap.Entry{{DN: "item1"}}
+	m.SearchReturn = &ldap.SearchResult{Entries: entries}
+	r := ldap.NewSearchRequest("baseDn", ldap.ScopeSingleLevel, ldap.NeverDerefAliases, 0, 0, false, "filter", []string{"attributes"}, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s, err := l.QueryContext(ctx, r)
+	if err != nil || len(s.Entries) != 1 || len(m.MethodsCalled["Search"]) != 1 {
+		t.Error("expected Search method to be called on backend", err)
+	}
+
+	cancel()
+	_, err = l.QueryContext(ctx, r)
+	if err != context.Canceled || len(m.MethodsCalled["Search"]) != 1 {
+		t.Error("expected cancelled context to skip search", err)
+	}
+}
+
 type ldapQueryStruct struct {
 	Test  []string
 	Test2 []string
@@ -0,0 +1,761 @@
+package memdb
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+type column struct {
+	name          string
+	kind          valueKind
+	notNull       bool
+	primaryKey    bool
+	autoIncrement bool
+	defaultValue  expr
+}
+
+type table struct {
+	name       string
+	columns    []column
+	primaryKey []int
+	rows       [][]interface{}
+	lastID     int64
+}
+
+func (t *table) columnIndex(name string) int {
+	for i, col := range t.columns {
+		if strings.EqualFold(col.name, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// checkKeys returns an error if two rows share a primary key
+func (t *table) checkKeys(rows [][]interface{}) error {
+	if len(t.primaryKey) == 0 {
+		return nil
+	}
+	seen := make(map[string]bool, len(rows))
+	for _, row := range rows {
+		key := make([]interface{}, len(t.primaryKey))
+		for i, index := range t.primaryKey {
+			key[i] = row[index]
+		}
+		k := rowKey(key)
+		if seen[k] {
+			return fmt.Errorf("memdb: duplicate primary key %v in table %s", key, t.name)
+		}
+		seen[k] = true
+	}
+	return nil
+}
+
+// checkRow converts the values of row to their column types and enforces NOT NULL
+func (t *table) checkRow(row []interface{}) error {
+	for i, col := range t.columns {
+		value, err := coerce(row[i], col.kind)
+		if err != nil {
+			return fmt.Errorf("%v (column %s)", err, col.name)
+		}
+		if value == nil && (col.notNull || col.primaryKey) {
+			return fmt.Errorf("memdb: column %s of table %s can't be NULL", col.name, t.name)
+		}
+		row[i] = value
+	}
+	return nil
+}
+
+type expr interface {
+	eval(s *scope) (interface{}, error)
+}
+
+type literal struct {
+	value    interface{}
+	position bool // a number written in the query, which ORDER BY treats as a column position
+}
+
+type columnRef struct {
+	table string
+	name  string
+}
+
+type binary struct {
+	op    string
+	left  expr
+	right expr
+}
+
+type not struct {
+	value expr
+}
+
+type isNull struct {
+	value expr
+	not   bool
+}
+
+type inList struct {
+	value expr
+	list  []expr
+	not   bool
+}
+
+type like struct {
+	value   expr
+	pattern expr
+	not     bool
+}
+
+// source is a row of one of the tables of a query. row is nil for the missing side of a LEFT JOIN
+type source struct {
+	alias string
+	table *table
+	row   []interface{}
+}
+
+// scope holds the rows that column references are resolved against
+type scope struct {
+	sources []source
+}
+
+func (s *scope) lookup(tableName, name string) (interface{}, error) {
+	found := -1
+	var value interface{}
+	for i, src := range s.sources {
+		if tableName != "" && !strings.EqualFold(src.alias, tableName) {
+			continue
+		}
+		index := src.table.columnIndex(name)
+		if index == -1 {
+			continue
+		}
+		if found != -1 {
+			return nil, fmt.Errorf("memdb: column %s is ambiguous", name)
+		}
+		found = i
+		if src.row != nil {
+			value = src.row[index]
+		}
+	}
+	if found == -1 {
+		if tableName != "" {
+			return nil, fmt.Errorf("memdb: unknown column %s.%s", tableName, name)
+		}
+		return nil, fmt.Errorf("memdb: unknown column %s", name)
+	}
+	return value, nil
+}
+
+func (e *literal) eval(s *scope) (interface{}, error) {
+	return e.value, nil
+}
+
+func (e *columnRef) eval(s *scope) (interface{}, error) {
+	return s.lookup(e.table, e.name)
+}
+
+func (e *binary) eval(s *scope) (interface{}, error) {
+	left, err := e.left.eval(s)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "AND", "OR":
+		l, err := truth(left)
+		if err != nil {
+			return nil, err
+		}
+		// short circuit when the left side decides the result
+		if l != nil && *l == (e.op == "OR") {
+			return *l, nil
+		}
+		right, err := e.right.eval(s)
+		if err != nil {
+			return nil, err
+		}
+		r, err := truth(right)
+		if err != nil {
+			return nil, err
+		}
+		if r != nil && *r == (e.op == "OR") {
+			return *r, nil
+		}
+		if l == nil || r == nil {
+			return nil, nil
+		}
+		return *r, nil
+	}
+	right, err := e.right.eval(s)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "+", "-", "*", "/":
+		return arithmetic(e.op, left, right)
+	}
+	if left == nil || right == nil {
+		return nil, nil
+	}
+	c, err := compare(left, right)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "=":
+		return c == 0, nil
+	case "<>":
+		return c != 0, nil
+	case "<":
+		return c < 0, nil
+	case "<=":
+		return c <= 0, nil
+	case ">":
+		return c > 0, nil
+	}
+	return c >= 0, nil
+}
+
+// truth converts a value to a SQL boolean, where nil is unknown
+func truth(value interface{}) (*bool, error) {
+	var b bool
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case bool:
+		b = v
+	case int64:
+		b = v != 0
+	case float64:
+		b = v != 0
+	default:
+		return nil, fmt.Errorf("memdb: %T value %v is not a boolean", value, value)
+	}
+	return &b, nil
+}
+
+func (e *not) eval(s *scope) (interface{}, error) {
+	value, err := e.value.eval(s)
+	if err != nil {
+		return nil, err
+	}
+	b, err := truth(value)
+	if b == nil || err != nil {
+		return nil, err
+	}
+	return !*b, nil
+}
+
+func (e *isNull) eval(s *scope) (interface{}, error) {
+	value, err := e.value.eval(s)
+	if err != nil {
+		return nil, err
+	}
+	return (value == nil) != e.not, nil
+}
+
+func (e *inList) eval(s *scope) (interface{}, error) {
+	value, err := e.value.eval(s)
+	if err != nil || value == nil {
+		return nil, err
+	}
+	sawNull := false
+	for _, item := range e.list {
+		candidate, err := item.eval(s)
+		if err != nil {
+			return nil, err
+		}
+		if candidate == nil {
+			sawNull = true
+			continue
+		}
+		c, err := compare(value, candidate)
+		if err != nil {
+			return nil, err
+		}
+		if c == 0 {
+			return !e.not, nil
+		}
+	}
+	if sawNull {
+		return nil, nil
+	}
+	return e.not, nil
+}
+
+func (e *like) eval(s *scope) (interface{}, error) {
+	value, err := e.value.eval(s)
+	if err != nil {
+		return nil, err
+	}
+	pattern, err := e.pattern.eval(s)
+	if err != nil || value == nil || pattern == nil {
+		return nil, err
+	}
+	re, err := likePattern(fmt.Sprint(pattern))
+	if err != nil {
+		return nil, err
+	}
+	text := fmt.Sprint(value)
+	if b, ok := value.([]byte); ok {
+		text = string(b)
+	}
+	return re.MatchString(text) != e.not, nil
+}
+
+// matches reports whether a WHERE or ON condition is true for the scope. NULL conditions don't match
+func matches(condition expr, s *scope) (bool, error) {
+	if condition == nil {
+		return true, nil
+	}
+	value, err := condition.eval(s)
+	if err != nil {
+		return false, err
+	}
+	b, err := truth(value)
+	return b != nil && *b, err
+}
+
+func (db *memDB) createTable(stmt *createTable) error {
+	key := strings.ToLower(stmt.name)
+	if _, ok := db.tables[key]; ok {
+		if stmt.ifNotExists {
+			return nil
+		}
+		return fmt.Errorf("memdb: table %s already exists", stmt.name)
+	}
+	t := &table{name: stmt.name, columns: stmt.columns}
+	for i, col := range t.columns {
+		if t.columnIndex(col.name) != i {
+			return fmt.Errorf("memdb: duplicate column %s", col.name)
+		}
+		if col.primaryKey {
+			t.primaryKey = append(t.primaryKey, i)
+		}
+	}
+	for _, name := range stmt.primaryKey {
+		index := t.columnIndex(name)
+		if index == -1 {
+			return fmt.Errorf("memdb: primary key column %s not found", name)
+		}
+		t.columns[index].primaryKey = true
+		t.primaryKey = append(t.primaryKey, index)
+	}
+	// a single integer primary key is filled in automatically like SQLite's rowid
+	if len(t.primaryKey) == 1 && t.columns[t.primaryKey[0]].kind == kindInt {
+		t.columns[t.primaryKey[0]].autoIncrement = true
+	}
+	db.tables[key] = t
+	return nil
+}
+
+func (db *memDB) dropTable(stmt *dropTable) error {
+	key := strings.ToLower(stmt.name)
+	if _, ok := db.tables[key]; !ok && !stmt.ifExists {
+		return fmt.Errorf("memdb: table %s doesn't exist", stmt.name)
+	}
+	delete(db.tables, key)
+	return nil
+}
+
+func (db *memDB) getTable(name string) (*table, error) {
+	t, ok := db.tables[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("memdb: table %s doesn't exist", name)
+	}
+	return t, nil
+}
+
+func (db *memDB) insert(stmt *insert) (*result, error) {
+	t, err := db.getTable(stmt.table)
+	if err != nil {
+		return nil, err
+	}
+	indexes := make([]int, len(t.columns))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	if stmt.columns != nil {
+		indexes = indexes[:0]
+		for _, name := range stmt.columns {
+			index := t.columnIndex(name)
+			if index == -1 {
+				return nil, fmt.Errorf("memdb: unknown column %s in table %s", name, t.name)
+			}
+			indexes = append(indexes, index)
+		}
+	}
+
+	lastID := t.lastID
+	rows := make([][]interface{}, 0, len(stmt.rows))
+	empty := &scope{}
+	for _, values := range stmt.rows {
+		if len(values) != len(indexes) {
+			return nil, fmt.Errorf("memdb: %d values for %d columns", len(values), len(indexes))
+		}
+		row := make([]interface{}, len(t.columns))
+		set := make([]bool, len(t.columns))
+		for i, value := range values {
+			if row[indexes[i]], err = value.eval(empty); err != nil {
+				return nil, err
+			}
+			set[indexes[i]] = true
+		}
+		for i, col := range t.columns {
+			switch {
+			case !set[i] && col.defaultValue != nil:
+				row[i], _ = col.defaultValue.eval(empty)
+			case col.autoIncrement && row[i] == nil:
+				lastID++
+				row[i] = lastID
+			}
+		}
+		if err := t.checkRow(row); err != nil {
+			return nil, err
+		}
+		for i, col := range t.columns {
+			if id, ok := row[i].(int64); ok && col.autoIncrement && id > lastID {
+				lastID = id
+			}
+		}
+		rows = append(rows, row)
+	}
+	if err := t.checkKeys(append(append([][]interface{}{}, t.rows...), rows...)); err != nil {
+		return nil, err
+	}
+	t.rows = append(t.rows, rows...)
+	t.lastID = lastID
+	return &result{lastInsertID: lastID, rowsAffected: int64(len(rows))}, nil
+}
+
+func (db *memDB) update(stmt *update) (*result, error) {
+	t, err := db.getTable(stmt.table)
+	if err != nil {
+		return nil, err
+	}
+	indexes := make([]int, len(stmt.sets))
+	for i, set := range stmt.sets {
+		if indexes[i] = t.columnIndex(set.column); indexes[i] == -1 {
+			return nil, fmt.Errorf("memdb: unknown column %s in table %s", set.column, t.name)
+		}
+	}
+
+	// build the new rows first so that a failure leaves the table unchanged
+	updated := make([][]interface{}, len(t.rows))
+	count := int64(0)
+	for i, row := range t.rows {
+		updated[i] = row
+		s := &scope{[]source{{t.name, t, row}}}
+		ok, err := matches(stmt.where, s)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			continue
+		}
+		newRow := append([]interface{}{}, row...)
+		for j, set := range stmt.sets {
+			if newRow[indexes[j]], err = set.value.eval(s); err != nil {
+				return nil, err
+			}
+		}
+		if err := t.checkRow(newRow); err != nil {
+			return nil, err
+		}
+		updated[i] = newRow
+		count++
+	}
+	if err := t.checkKeys(updated); err != nil {
+		return nil, err
+	}
+	t.rows = updated
+	return &result{rowsAffected: count}, nil
+}
+
+func (db *memDB) deleteFrom(stmt *deleteFrom) (*result, error) {
+	t, err := db.getTable(stmt.table)
+	if err != nil {
+		return nil, err
+	}
+	kept := make([][]interface{}, 0, len(t.rows))
+	for _, row := range t.rows {
+		ok, err := matches(stmt.where, &scope{[]source{{t.name, t, row}}})
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			kept = append(kept, row)
+		}
+	}
+	count := int64(len(t.rows) - len(kept))
+	t.rows = kept
+	return &result{rowsAffected: count}, nil
+}
+
+// selected is an output row along with the scope it was produced from, which ORDER BY may refer to
+type selected struct {
+	values []interface{}
+	scope  *scope
+}
+
+func (db *memDB) selectRows(stmt *selectFrom) ([]string, [][]interface{}, error) {
+	scopes, tables, err := db.joinRows(stmt)
+	if err != nil {
+		return nil, nil, err
+	}
+	var filtered []*scope
+	for _, s := range scopes {
+		ok, err := matches(stmt.where, s)
+		if err != nil {
+			return nil, nil, err
+		}
+		if ok {
+			filtered = append(filtered, s)
+		}
+	}
+
+	columns, offsets, err := getColumns(stmt.items, tables)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows := make([]selected, 0, len(filtered))
+	seen := make(map[string]bool)
+	for _, s := range filtered {
+		values, err := project(stmt.items, s)
+		if err != nil {
+			return nil, nil, err
+		}
+		if stmt.distinct {
+			if key := rowKey(values); !seen[key] {
+				seen[key] = true
+			} else {
+				continue
+			}
+		}
+		rows = append(rows, selected{values, s})
+	}
+
+	if err := sortRows(rows, stmt.orderBy, stmt.items, offsets); err != nil {
+		return nil, nil, err
+	}
+	start, end, err := getLimits(stmt, len(rows))
+	if err != nil {
+		return nil, nil, err
+	}
+	result := make([][]interface{}, 0, end-start)
+	for _, row := range rows[start:end] {
+		result = append(result, row.values)
+	}
+	return columns, result, nil
+}
+
+// joinRows returns a scope for each combination of rows of the FROM and JOIN tables along with the tables. A SELECT
+// without FROM has a single empty scope
+func (db *memDB) joinRows(stmt *selectFrom) ([]*scope, []source, error) {
+	if stmt.from == nil {
+		return []*scope{{}}, nil, nil
+	}
+	t, err := db.getTable(stmt.from.name)
+	if err != nil {
+		return nil, nil, err
+	}
+	tables := []source{{alias: stmt.from.alias, table: t}}
+	scopes := make([]*scope, len(t.rows))
+	for i, row := range t.rows {
+		scopes[i] = &scope{[]source{{stmt.from.alias, t, row}}}
+	}
+	for _, j := range stmt.joins {
+		joined, err := db.getTable(j.table.name)
+		if err != nil {
+			return nil, nil, err
+		}
+		tables = append(tables, source{alias: j.table.alias, table: joined})
+		var next []*scope
+		for _, s := range scopes {
+			found := false
+			for _, row := range joined.rows {
+				candidate := &scope{append(append([]source{}, s.sources...), source{j.table.alias, joined, row})}
+				ok, err := matches(j.on, candidate)
+				if err != nil {
+					return nil, nil, err
+				}
+				if ok {
+					next = append(next, candidate)
+					found = true
+				}
+			}
+			if !found && j.left {
+				next = append(next, &scope{append(append([]source{}, s.sources...), source{j.table.alias, joined, nil})})
+			}
+		}
+		scopes = next
+	}
+	return scopes, tables, nil
+}
+
+// getColumns returns the output column names and the position of each select item among them
+func getColumns(items []selectItem, tables []source) ([]string, []int, error) {
+	var columns []string
+	offsets := make([]int, len(items))
+	for i, item := range items {
+		offsets[i] = len(columns)
+		if !item.star {
+			columns = append(columns, item.name)
+			continue
+		}
+		if len(tables) == 0 {
+			return nil, nil, fmt.Errorf("memdb: * requires a FROM clause")
+		}
+		found := false
+		for _, src := range tables {
+			if item.starTable == "" || strings.EqualFold(src.alias, item.starTable) {
+				found = true
+				for _, col := range src.table.columns {
+					columns = append(columns, col.name)
+				}
+			}
+		}
+		if !found {
+			return nil, nil, fmt.Errorf("memdb: unknown table %s", item.starTable)
+		}
+	}
+	return columns, offsets, nil
+}
+
+func project(items []selectItem, s *scope) ([]interface{}, error) {
+	var values []interface{}
+	for _, item := range items {
+		if !item.star {
+			value, err := item.expr.eval(s)
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+			continue
+		}
+		for _, src := range s.sources {
+			if item.starTable != "" && !strings.EqualFold(src.alias, item.starTable) {
+				continue
+			}
+			if src.row == nil {
+				values = append(values, make([]interface{}, len(src.table.columns))...)
+			} else {
+				values = append(values, src.row...)
+			}
+		}
+	}
+	return values, nil
+}
+
+// sortRows orders rows by ORDER BY items, which may be output column names, 1 based column positions or
+// expressions over the tables of the query
+func sortRows(rows []selected, orderBy []orderItem, items []selectItem, offsets []int) error {
+	if len(orderBy) == 0 {
+		return nil
+	}
+	keys := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		keys[i] = make([]interface{}, len(orderBy))
+		for j, item := range orderBy {
+			value, err := orderValue(item.expr, row, items, offsets)
+			if err != nil {
+				return err
+			}
+			keys[i][j] = value
+		}
+	}
+	indexes := make([]int, len(rows))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	var err error
+	sort.SliceStable(indexes, func(a, b int) bool {
+		for j, item := range orderBy {
+			c, cerr := compareNullsFirst(keys[indexes[a]][j], keys[indexes[b]][j])
+			if cerr != nil && err == nil {
+				err = cerr
+			}
+			if c != 0 {
+				return c < 0 != item.desc
+			}
+		}
+		return false
+	})
+	sorted := make([]selected, len(rows))
+	for i, index := range indexes {
+		sorted[i] = rows[index]
+	}
+	copy(rows, sorted)
+	return err
+}
+
+func orderValue(e expr, row selected, items []selectItem, offsets []int) (interface{}, error) {
+	if l, ok := e.(*literal); ok && l.position {
+		n, ok := l.value.(int64)
+		if !ok || n < 1 || int(n) > len(row.values) {
+			return nil, fmt.Errorf("memdb: ORDER BY position %v is out of range", l.value)
+		}
+		return row.values[n-1], nil
+	}
+	if ref, ok := e.(*columnRef); ok && ref.table == "" {
+		for i, item := range items {
+			// aliases of expressions, such as total in "a + b AS total", only exist in the output
+			if !item.star && strings.EqualFold(item.name, ref.name) {
+				if _, isColumn := item.expr.(*columnRef); !isColumn {
+					return row.values[offsets[i]], nil
+				}
+			}
+		}
+	}
+	return e.eval(row.scope)
+}
+
+func getLimits(stmt *selectFrom, count int) (int, int, error) {
+	start, end := 0, count
+	if stmt.offset != nil {
+		offset, err := getCount(stmt.offset, "OFFSET")
+		if err != nil {
+			return 0, 0, err
+		}
+		start = offset
+		if start > count {
+			start = count
+		}
+	}
+	if stmt.limit != nil {
+		limit, err := getCount(stmt.limit, "LIMIT")
+		if err != nil {
+			return 0, 0, err
+		}
+		if limit < end-start {
+			end = start + limit
+		}
+	}
+	return start, end, nil
+}
+
+func getCount(e expr, clause string) (int, error) {
+	value, err := e.eval(&scope{})
+	if err != nil {
+		return 0, err
+	}
+	n, ok := value.(int64)
+	if !ok || n < 0 {
+		return 0, fmt.Errorf("memdb: %s must be a non negative integer", clause)
+	}
+	return int(n), nil
+}
+
+type result struct {
+	lastInsertID int64
+	rowsAffected int64
+}
+
+func (r *result) LastInsertId() (int64, error) {
+	return r.lastInsertID, nil
+}
+
+func (r *result) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
+}
@@ -0,0 +1,155 @@
+package memdb
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenQuotedIdent
+	tokenNumber
+	tokenString
+	tokenPlaceholder
+	tokenSymbol
+)
+
+type token struct {
+	kind  tokenKind
+	text  string // upper case for identifiers so that keywords can be compared directly
+	raw   string
+	value interface{}
+	start int
+	end   int
+}
+
+// is reports whether the token is the keyword or symbol s
+func (t token) is(s string) bool {
+	return (t.kind == tokenIdent || t.kind == tokenSymbol) && t.text == s
+}
+
+// tokenize splits a statement into tokens. Placeholders may be written as ?, $1 or @p1 whatever the Dialect
+func tokenize(query string) ([]token, error) {
+	var tokens []token
+	runes := []rune(query)
+	offsets := make([]int, len(runes)+1)
+	for i, offset := 0, 0; i < len(runes); i++ {
+		offsets[i] = offset
+		offset += len(string(runes[i]))
+		offsets[i+1] = offset
+	}
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		start := i
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '-' && i+1 < len(runes) && runes[i+1] == '-':
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			continue
+		case r == '_' || unicode.IsLetter(r):
+			for i < len(runes) && (runes[i] == '_' || unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i])) {
+				i++
+			}
+			raw := string(runes[start:i])
+			tokens = append(tokens, token{kind: tokenIdent, text: strings.ToUpper(raw), raw: raw})
+		case r == '"' || r == '`':
+			end := indexRune(runes, i+1, r)
+			if end == -1 {
+				return nil, fmt.Errorf("memdb: unterminated identifier at %d", offsets[i])
+			}
+			raw := string(runes[i+1 : end])
+			tokens = append(tokens, token{kind: tokenQuotedIdent, text: strings.ToUpper(raw), raw: raw})
+			i = end + 1
+		case r == '\'':
+			var b strings.Builder
+			i++
+			for {
+				if i >= len(runes) {
+					return nil, fmt.Errorf("memdb: unterminated string at %d", offsets[start])
+				}
+				if runes[i] == '\'' {
+					if i+1 < len(runes) && runes[i+1] == '\'' {
+						b.WriteRune('\'')
+						i += 2
+						continue
+					}
+					i++
+					break
+				}
+				b.WriteRune(runes[i])
+				i++
+			}
+			tokens = append(tokens, token{kind: tokenString, value: b.String()})
+		case unicode.IsDigit(r) || r == '.' && i+1 < len(runes) && unicode.IsDigit(runes[i+1]):
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.' || runes[i] == 'e' || runes[i] == 'E' ||
+				(runes[i] == '-' || runes[i] == '+') && (runes[i-1] == 'e' || runes[i-1] == 'E')) {
+				i++
+			}
+			raw := string(runes[start:i])
+			value, err := parseNumber(raw)
+			if err != nil {
+				return nil, fmt.Errorf("memdb: invalid number %s", raw)
+			}
+			tokens = append(tokens, token{kind: tokenNumber, raw: raw, value: value})
+		case r == '?':
+			i++
+			tokens = append(tokens, token{kind: tokenPlaceholder, value: 0})
+		case (r == '$' || r == '@') && i+1 < len(runes):
+			i++
+			if r == '@' && (runes[i] == 'p' || runes[i] == 'P') {
+				i++
+			}
+			digits := i
+			for i < len(runes) && unicode.IsDigit(runes[i]) {
+				i++
+			}
+			n, err := strconv.Atoi(string(runes[digits:i]))
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("memdb: invalid placeholder %s", string(runes[start:i]))
+			}
+			tokens = append(tokens, token{kind: tokenPlaceholder, value: n})
+		default:
+			symbol := string(r)
+			if i+1 < len(runes) {
+				if two := string(runes[i : i+2]); two == "<=" || two == ">=" || two == "<>" || two == "!=" {
+					symbol = two
+				}
+			}
+			if len(symbol) == 1 && !strings.ContainsRune("(),.*=<>;+-/", r) {
+				return nil, fmt.Errorf("memdb: unexpected character %q at %d", r, offsets[i])
+			}
+			i += len([]rune(symbol))
+			tokens = append(tokens, token{kind: tokenSymbol, text: symbol})
+		}
+		tokens[len(tokens)-1].start = offsets[start]
+		tokens[len(tokens)-1].end = offsets[i]
+	}
+	return append(tokens, token{kind: tokenEOF, start: len(query), end: len(query)}), nil
+}
+
+func indexRune(runes []rune, from int, r rune) int {
+	for i := from; i < len(runes); i++ {
+		if runes[i] == r {
+			return i
+		}
+	}
+	return -1
+}
+
+func parseNumber(raw string) (interface{}, error) {
+	if !strings.ContainsAny(raw, ".eE") {
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return n, nil
+		}
+	}
+	return strconv.ParseFloat(raw, 64)
+}
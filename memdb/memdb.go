@@ -0,0 +1,187 @@
+// Package memdb is an in-memory relational database for tests that need real query semantics without a database
+// server. It understands a small subset of SQL:
+//
+//	CREATE TABLE [IF NOT EXISTS] t (col type [PRIMARY KEY] [NOT NULL] [DEFAULT v] [AUTO_INCREMENT], ..., [PRIMARY KEY (cols)])
+//	DROP TABLE [IF EXISTS] t
+//	INSERT INTO t [(cols)] VALUES (...), (...)
+//	UPDATE t SET col = expr, ... [WHERE cond]
+//	DELETE FROM t [WHERE cond]
+//	SELECT [DISTINCT] * | t.* | expr [AS name], ... [FROM t [alias] [[INNER | LEFT [OUTER]] JOIN t2 [alias] ON cond] ...]
+//		[WHERE cond] [ORDER BY expr [ASC | DESC], ...] [LIMIT n] [OFFSET n]
+//
+// Conditions support =, <>, !=, <, <=, >, >=, AND, OR, NOT, IS [NOT] NULL, [NOT] IN, [NOT] LIKE, [NOT] BETWEEN
+// and + - * / arithmetic with SQL NULL semantics. Placeholders may be written as ?, $1 or @p1. Aggregates, GROUP BY
+// and subqueries aren't supported.
+//
+// Values are stored as int64, float64, bool, string, []byte or time.Time depending on the column type. A single
+// integer primary key is assigned automatically when it is omitted
+package memdb
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/EndFirstCorp/onedb"
+)
+
+// MemDBer is the interface of an in-memory database
+type MemDBer interface {
+	onedb.DBer
+	onedb.ContextBackender
+	Dialect() onedb.Dialect
+}
+
+type memDB struct {
+	mu      sync.RWMutex
+	dialect onedb.Dialect
+	tables  map[string]*table
+}
+
+// New returns an empty in-memory database. dialect is reported to the onedb helpers that generate SQL, such as
+// onedb.Insert, so that they use its placeholders. Every placeholder style is accepted in queries
+func New(dialect onedb.Dialect) MemDBer {
+	return &memDB{dialect: dialect, tables: make(map[string]*table)}
+}
+
+// Dialect returns the dialect the database was created with
+func (db *memDB) Dialect() onedb.Dialect {
+	return db.dialect
+}
+
+func (db *memDB) Query(query string, args ...interface{}) (onedb.RowsScanner, error) {
+	return db.QueryContext(context.Background(), query, args...)
+}
+
+func (db *memDB) QueryRow(query string, args ...interface{}) onedb.Scanner {
+	return db.QueryRowContext(context.Background(), query, args...)
+}
+
+func (db *memDB) Exec(command string, args ...interface{}) (onedb.Result, error) {
+	return db.ExecContext(context.Background(), command, args...)
+}
+
+// QueryContext runs a statement and returns its rows. Statements other than SELECT return no rows
+func (db *memDB) QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error) {
+	columns, rows, _, err := db.run(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	result := onedb.NewRows(columns...)
+	for _, row := range rows {
+		result.AddRow(row...)
+	}
+	return result, nil
+}
+
+func (db *memDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner {
+	rows, err := db.QueryContext(ctx, query, args...)
+	return &row{rows, err}
+}
+
+// ExecContext runs a statement. A SELECT runs without returning its rows
+func (db *memDB) ExecContext(ctx context.Context, command string, args ...interface{}) (onedb.Result, error) {
+	_, _, result, err := db.run(ctx, command, args)
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (db *memDB) run(ctx context.Context, query string, args []interface{}) ([]string, [][]interface{}, *result, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, nil, err
+	}
+	stmt, err := parse(query, args)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if s, ok := stmt.(*selectFrom); ok {
+		db.mu.RLock()
+		defer db.mu.RUnlock()
+		columns, rows, err := db.selectRows(s)
+		return columns, rows, &result{}, err
+	}
+
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var r *result
+	switch s := stmt.(type) {
+	case *createTable:
+		r, err = &result{}, db.createTable(s)
+	case *dropTable:
+		r, err = &result{}, db.dropTable(s)
+	case *insert:
+		r, err = db.insert(s)
+	case *update:
+		r, err = db.update(s)
+	case *deleteFrom:
+		r, err = db.deleteFrom(s)
+	}
+	return nil, nil, r, err
+}
+
+// row scans the first of rows, the way database/sql's Row does
+type row struct {
+	rows onedb.RowsScanner
+	err  error
+}
+
+func (r *row) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	defer r.rows.Close()
+	if !r.rows.Next() {
+		return onedb.ErrEmptyResultSet
+	}
+	return r.rows.Scan(dest...)
+}
+
+func (db *memDB) QueryValues(query *onedb.Query, result ...interface{}) error {
+	return onedb.QueryValues(db, query, result...)
+}
+
+func (db *memDB) QueryJSON(query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSON(db, query, args...)
+}
+
+func (db *memDB) QueryJSONRow(query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSONRow(db, query, args...)
+}
+
+func (db *memDB) QueryStruct(result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStruct(db, result, query, args...)
+}
+
+func (db *memDB) QueryStructRow(result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStructRow(db, result, query, args...)
+}
+
+func (db *memDB) QueryWriteCSV(w io.Writer, options onedb.CSVOptions, query string, args ...interface{}) error {
+	return onedb.QueryWriteCSV(w, options, db, query, args...)
+}
+
+func (db *memDB) QueryValuesContext(ctx context.Context, query *onedb.Query, result ...interface{}) error {
+	return onedb.QueryValuesContext(ctx, db, query, result...)
+}
+
+func (db *memDB) QueryJSONContext(ctx context.Context, query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSONContext(ctx, db, query, args...)
+}
+
+func (db *memDB) QueryJSONRowContext(ctx context.Context, query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSONRowContext(ctx, db, query, args...)
+}
+
+func (db *memDB) QueryStructContext(ctx context.Context, result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStructContext(ctx, db, result, query, args...)
+}
+
+func (db *memDB) QueryStructRowContext(ctx context.Context, result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStructRowContext(ctx, db, result, query, args...)
+}
+
+func (db *memDB) QueryWriteCSVContext(ctx context.Context, w io.Writer, options onedb.CSVOptions, query string, args ...interface{}) error {
+	return onedb.QueryWriteCSVContext(ctx, w, options, db, query, args...)
+}
@@ -0,0 +1,266 @@
+package memdb
+
+import (
+	"context"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/EndFirstCorp/onedb"
+)
+
+type user struct {
+	ID      int       `db:"id,auto"`
+	Name    string    `db:"name"`
+	Age     *int      `db:"age"`
+	Created time.Time `db:"created"`
+}
+
+type order struct {
+	ID     int     `db:"id,auto"`
+	UserID int     `db:"user_id"`
+	Total  float64 `db:"total"`
+}
+
+func intPtr(i int) *int {
+	return &i
+}
+
+func newTestDB(t *testing.T) MemDBer {
+	db := New(onedb.PostgreSQL)
+	mustExec(t, db, `CREATE TABLE users (
+		id SERIAL PRIMARY KEY,
+		name VARCHAR(100) NOT NULL,
+		age INTEGER,
+		created TIMESTAMP WITH TIME ZONE
+	)`)
+	mustExec(t, db, "create table orders (id integer primary key, user_id int not null, total decimal(10, 2))")
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if _, err := onedb.Insert(db, "users",
+		user{Name: "alice", Age: intPtr(30), Created: created},
+		user{Name: "bob", Created: created.Add(time.Hour)},
+		user{Name: "carol", Age: intPtr(25), Created: created.Add(2 * time.Hour)}); err != nil {
+		t.Fatal("expected seeded users", err)
+	}
+	if _, err := onedb.Insert(db, "orders", order{UserID: 1, Total: 10.5}, order{UserID: 1, Total: 20}, order{UserID: 3, Total: 5}); err != nil {
+		t.Fatal("expected seeded orders", err)
+	}
+	return db
+}
+
+func mustExec(t *testing.T, db MemDBer, query string, args ...interface{}) onedb.Result {
+	t.Helper()
+	result, err := db.Exec(query, args...)
+	if err != nil {
+		t.Fatal(query, err)
+	}
+	return result
+}
+
+func TestSelect(t *testing.T) {
+	db := newTestDB(t)
+	var users []user
+	if err := db.QueryStruct(&users, "SELECT * FROM users WHERE age > $1 OR age IS NULL ORDER BY name DESC", 26); err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 || users[0].Name != "bob" || users[0].Age != nil || users[1].Name != "alice" || *users[1].Age != 30 || users[1].ID != 1 {
+		t.Errorf("expected bob and alice. Actual: %+v", users)
+	}
+
+	tests := []struct {
+		query    string
+		args     []interface{}
+		expected string
+	}{
+		{"select name from users order by age", nil, `[{"name":"bob"},{"name":"carol"},{"name":"alice"}]`},
+		{"select name from users order by 1 limit 2 offset 1", nil, `[{"name":"bob"},{"name":"carol"}]`},
+		{"select name from users limit ?, ?", []interface{}{2, 5}, `[{"name":"carol"}]`},
+		{"select id, age * 2 as double from users where name in ('alice', 'carol') and age between 20 and 40 order by double",
+			nil, `[{"id":3,"double":50},{"id":1,"double":60}]`},
+		{"select name from users where name like 'a%' or name not like '%o%'", nil, `[{"name":"alice"}]`},
+		{"select name from users where created >= @p1 and not (age = 25)", []interface{}{"2020-01-02 04:00:00"}, `[]`},
+		{"select name from users where created >= @p1", []interface{}{time.Date(2020, 1, 2, 4, 0, 0, 0, time.UTC)},
+			`[{"name":"bob"},{"name":"carol"}]`},
+		{"select distinct user_id from orders order by user_id", nil, `[{"user_id":1},{"user_id":3}]`},
+		{"select 1 + 2 as three, 'it''s' as quote, null as nothing", nil, `[{"three":3,"quote":"it's"}]`},
+		{`select "name" from "users" u where u.id = 2 -- comment`, nil, `[{"name":"bob"}]`},
+	}
+	for _, test := range tests {
+		json, err := db.QueryJSON(test.query, test.args...)
+		if err != nil || json != test.expected {
+			t.Errorf("%s: expected %s. Actual: %s %v", test.query, test.expected, json, err)
+		}
+	}
+}
+
+func TestUntaggedRoundTrip(t *testing.T) {
+	type address struct {
+		ZipCode string
+	}
+	type person struct {
+		ID       int `db:"id,auto"`
+		FullName string
+		Addr     address `db:"addr,prefix=addr_"`
+	}
+	db := New(onedb.MySQL)
+	mustExec(t, db, "create table people (id int auto_increment primary key, fullname text, addr_zipcode text)")
+	if _, err := onedb.Insert(db, "people", person{FullName: "alice", Addr: address{"12345"}}); err != nil {
+		t.Fatal("expected untagged fields to be inserted into the columns reads use", err)
+	}
+	if _, err := onedb.Update(db, "people", person{ID: 1, FullName: "bob", Addr: address{"54321"}}, "ID"); err != nil {
+		t.Fatal("expected untagged fields to be updated", err)
+	}
+	var people []person
+	if err := db.QueryStruct(&people, "select * from people"); err != nil || len(people) != 1 ||
+		people[0] != (person{ID: 1, FullName: "bob", Addr: address{"54321"}}) {
+		t.Errorf("expected the person to round trip. Actual: %+v %v", people, err)
+	}
+}
+
+func TestIntegerLimits(t *testing.T) {
+	db := newTestDB(t)
+	json, err := db.QueryJSON("select name from users order by id limit 9223372036854775807 offset 1")
+	if err != nil || json != `[{"name":"bob"},{"name":"carol"}]` {
+		t.Error("expected the largest LIMIT to return the remaining rows", json, err)
+	}
+
+	var min int64
+	if err := db.QueryRow("select -9223372036854775808").Scan(&min); err != nil || min != math.MinInt64 {
+		t.Error("expected the smallest int64 literal", min, err)
+	}
+	var value interface{}
+	if err := db.QueryRow("select -9223372036854775808").Scan(&value); err != nil || value != int64(math.MinInt64) {
+		t.Errorf("expected an int64 literal. Actual: %T %v", value, err)
+	}
+
+	for _, query := range []string{
+		"select 9223372036854775807 + 1",
+		"select -9223372036854775808 - 1",
+		"select 4611686018427387904 * 2",
+		"select -9223372036854775808 * -1",
+		"select -9223372036854775808 / -1",
+		"select -(-9223372036854775808)",
+	} {
+		if err := db.QueryRow(query).Scan(&value); err == nil || !strings.Contains(err.Error(), "out of range") {
+			t.Errorf("%s: expected out of range error. Actual: %v %v", query, value, err)
+		}
+	}
+	if err := db.QueryRow("select 9223372036854775807 - 1").Scan(&value); err != nil || value != int64(math.MaxInt64-1) {
+		t.Error("expected results in range to succeed", value, err)
+	}
+}
+
+func TestJoins(t *testing.T) {
+	db := newTestDB(t)
+	json, err := db.QueryJSON(`SELECT u.name, o.total FROM users u
+		INNER JOIN orders o ON o.user_id = u.id
+		WHERE o.total > 6 ORDER BY o.total DESC`)
+	if err != nil || json != `[{"name":"alice","total":20},{"name":"alice","total":10.5}]` {
+		t.Error("expected inner join", json, err)
+	}
+	json, err = db.QueryJSON(`select u.name, o.id as order_id from users as u left outer join orders o on o.user_id = u.id and o.total < 15
+		order by u.id, o.id`)
+	if err != nil || json != `[{"name":"alice","order_id":1},{"name":"bob"},{"name":"carol","order_id":3}]` {
+		t.Error("expected left join", json, err)
+	}
+	rows, err := db.Query("select o.*, u.name from orders o join users u on u.id = o.user_id where 1 = 0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if columns, _ := rows.Columns(); !reflect.DeepEqual(columns, []string{"id", "user_id", "total", "name"}) || rows.Next() {
+		t.Error("expected columns of an empty result", columns)
+	}
+	if _, err := db.Query("select id from users u join orders o on o.user_id = u.id"); err == nil || !strings.Contains(err.Error(), "ambiguous") {
+		t.Error("expected ambiguous column", err)
+	}
+}
+
+func TestModify(t *testing.T) {
+	db := newTestDB(t)
+	result := mustExec(t, db, "insert into users (name) values ('dave'), ('erin')")
+	if id, _ := result.LastInsertId(); id != 5 {
+		t.Error("expected generated ids", id)
+	}
+	result = mustExec(t, db, "update users set age = age + 1, name = ? where age is not null", "older")
+	if affected, _ := result.RowsAffected(); affected != 2 {
+		t.Error("expected 2 updated rows", affected)
+	}
+	if _, err := onedb.Update(db, "users", user{ID: 2, Name: "robert", Age: intPtr(40)}, "ID"); err != nil {
+		t.Error("expected struct update", err)
+	}
+	var bob user
+	if err := db.QueryStructRow(&bob, "select * from users where id = $1", 2); err != nil || bob.Name != "robert" || *bob.Age != 40 {
+		t.Error("expected updated row", bob, err)
+	}
+	result = mustExec(t, db, "DELETE FROM users WHERE name = 'older'")
+	if affected, _ := result.RowsAffected(); affected != 2 {
+		t.Error("expected 2 deleted rows", affected)
+	}
+	var count int
+	if err := db.QueryRow("select id from users order by id desc").Scan(&count); err != nil || count != 5 {
+		t.Error("expected last id", count, err)
+	}
+	if err := db.QueryRow("select id from users where id = 100").Scan(&count); err != onedb.ErrEmptyResultSet {
+		t.Error("expected empty result", err)
+	}
+	mustExec(t, db, "drop table orders")
+	mustExec(t, db, "drop table if exists orders")
+	mustExec(t, db, "create table if not exists users (id int)")
+}
+
+func TestConstraintsAndErrors(t *testing.T) {
+	db := newTestDB(t)
+	mustExec(t, db, "create table tags (user_id int, tag text, active boolean default true, primary key (user_id, tag))")
+	mustExec(t, db, "insert into tags (user_id, tag) values (1, 'admin')")
+	var active bool
+	if err := db.QueryRow("select active from tags").Scan(&active); err != nil || !active {
+		t.Error("expected default", active, err)
+	}
+	errors := []struct {
+		query string
+		args  []interface{}
+	}{
+		{"insert into tags values (1, 'admin', false)", nil},
+		{"update users set id = 1", nil},
+		{"insert into users (name) values (null)", nil},
+		{"insert into users (age, name) values ('old', 'x')", nil},
+		{"insert into users (missing) values (1)", nil},
+		{"insert into users (name) values (1, 2)", nil},
+		{"select * from missing", nil},
+		{"select missing from users", nil},
+		{"select x.* from users", nil},
+		{"select * from users where name = ?", nil},
+		{"select * from users where name = ?", []interface{}{"bob", "extra"}},
+		{"select * from users where id = $2", []interface{}{1, 2, 3}},
+		{"select * from users", []interface{}{1}},
+		{"select * from users where name > 1 and", nil},
+		{"select * from users where 'a", nil},
+		{"select * from users limit -1", nil},
+		{"select * from users order by 9", nil},
+		{"select * from users where created = true", nil},
+		{"create table users (id int)", nil},
+		{"create table t (id int, id int)", nil},
+		{"drop table missing", nil},
+		{"merge into users", nil},
+		{"select * from users; select 1", nil},
+	}
+	for _, test := range errors {
+		if _, err := db.Exec(test.query, test.args...); err == nil {
+			t.Errorf("%s: expected error", test.query)
+		}
+	}
+	var n int
+	if err := db.QueryRow("select count from users").Scan(&n); err == nil {
+		t.Error("expected QueryRow error")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := db.QueryContext(ctx, "select 1"); err != context.Canceled {
+		t.Error("expected canceled", err)
+	}
+	if db.Dialect() != onedb.PostgreSQL {
+		t.Error("expected dialect")
+	}
+}
@@ -0,0 +1,761 @@
+package memdb
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strings"
+)
+
+type statement interface{}
+
+type createTable struct {
+	name        string
+	ifNotExists bool
+	columns     []column
+	primaryKey  []string
+}
+
+type dropTable struct {
+	name     string
+	ifExists bool
+}
+
+type insert struct {
+	table   string
+	columns []string
+	rows    [][]expr
+}
+
+type assignment struct {
+	column string
+	value  expr
+}
+
+type update struct {
+	table string
+	sets  []assignment
+	where expr
+}
+
+type deleteFrom struct {
+	table string
+	where expr
+}
+
+type tableRef struct {
+	name  string
+	alias string
+}
+
+type join struct {
+	table tableRef
+	left  bool
+	on    expr
+}
+
+type selectItem struct {
+	expr      expr
+	name      string // output column name
+	star      bool
+	starTable string // qualifier of table.*
+}
+
+type orderItem struct {
+	expr expr
+	desc bool
+}
+
+type selectFrom struct {
+	distinct bool
+	items    []selectItem
+	from     *tableRef
+	joins    []join
+	where    expr
+	orderBy  []orderItem
+	limit    expr
+	offset   expr
+}
+
+// parser is a recursive descent parser for the supported subset of SQL. Arguments are bound to placeholders while
+// parsing
+type parser struct {
+	query  string
+	tokens []token
+	pos    int
+	args   []interface{}
+	next   int
+	used   int // highest placeholder bound
+}
+
+func parse(query string, args []interface{}) (statement, error) {
+	tokens, err := tokenize(query)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(args))
+	for i, arg := range args {
+		if values[i], err = driver.DefaultParameterConverter.ConvertValue(arg); err != nil {
+			return nil, fmt.Errorf("memdb: argument %d: %v", i+1, err)
+		}
+	}
+	p := &parser{query: query, tokens: tokens, args: values}
+	stmt, err := p.parseStatement()
+	if err != nil {
+		return nil, err
+	}
+	p.accept(";")
+	if p.peek().kind != tokenEOF {
+		return nil, p.errorf("unexpected %s", p.describe())
+	}
+	if len(p.args) > p.used {
+		return nil, p.errorf("%d arguments were provided for %d placeholders", len(p.args), p.used)
+	}
+	return stmt, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) advance() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+	return t
+}
+
+// accept consumes the next token if it is one of the keywords or symbols
+func (p *parser) accept(words ...string) bool {
+	for _, word := range words {
+		if p.peek().is(word) {
+			p.pos++
+			return true
+		}
+	}
+	return false
+}
+
+func (p *parser) expect(words ...string) error {
+	for _, word := range words {
+		if !p.accept(word) {
+			return p.errorf("expected %s but found %s", word, p.describe())
+		}
+	}
+	return nil
+}
+
+func (p *parser) describe() string {
+	t := p.peek()
+	if t.kind == tokenEOF {
+		return "end of statement"
+	}
+	return fmt.Sprintf("%q", p.query[t.start:t.end])
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("memdb: "+format, args...)
+}
+
+func (p *parser) identifier() (string, error) {
+	t := p.peek()
+	if t.kind != tokenIdent && t.kind != tokenQuotedIdent || t.kind == tokenIdent && reservedWords[t.text] {
+		return "", p.errorf("expected a name but found %s", p.describe())
+	}
+	p.pos++
+	return t.raw, nil
+}
+
+var reservedWords = map[string]bool{"SELECT": true, "FROM": true, "WHERE": true, "JOIN": true, "INNER": true, "LEFT": true,
+	"OUTER": true, "ON": true, "ORDER": true, "BY": true, "LIMIT": true, "OFFSET": true, "AND": true, "OR": true, "NOT": true,
+	"AS": true, "SET": true, "VALUES": true, "NULL": true, "IS": true, "IN": true, "LIKE": true, "BETWEEN": true}
+
+func (p *parser) parseStatement() (statement, error) {
+	switch {
+	case p.accept("SELECT"):
+		return p.parseSelect()
+	case p.accept("INSERT"):
+		return p.parseInsert()
+	case p.accept("UPDATE"):
+		return p.parseUpdate()
+	case p.accept("DELETE"):
+		return p.parseDelete()
+	case p.accept("CREATE"):
+		return p.parseCreate()
+	case p.accept("DROP"):
+		return p.parseDrop()
+	}
+	return nil, p.errorf("unsupported statement %s", p.describe())
+}
+
+func (p *parser) parseCreate() (statement, error) {
+	if err := p.expect("TABLE"); err != nil {
+		return nil, err
+	}
+	stmt := &createTable{}
+	if p.accept("IF") {
+		if err := p.expect("NOT", "EXISTS"); err != nil {
+			return nil, err
+		}
+		stmt.ifNotExists = true
+	}
+	var err error
+	if stmt.name, err = p.identifier(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	for {
+		if p.accept("PRIMARY") {
+			if err := p.expect("KEY"); err != nil {
+				return nil, err
+			}
+			if stmt.primaryKey, err = p.parseNameList(); err != nil {
+				return nil, err
+			}
+		} else {
+			col, err := p.parseColumn()
+			if err != nil {
+				return nil, err
+			}
+			stmt.columns = append(stmt.columns, col)
+		}
+		if !p.accept(",") {
+			break
+		}
+	}
+	return stmt, p.expect(")")
+}
+
+// parseColumn reads a column definition. Type sizes and constraints other than PRIMARY KEY, NOT NULL and
+// auto increment are skipped
+func (p *parser) parseColumn() (column, error) {
+	name, err := p.identifier()
+	if err != nil {
+		return column{}, err
+	}
+	col := column{name: name, kind: kindAny}
+	if t := p.peek(); t.kind == tokenIdent && !reservedWords[t.text] && !constraintWords[t.text] {
+		p.pos++
+		col.kind, col.autoIncrement = columnKind(t.text)
+		for typeWords[p.peek().text] && p.peek().kind == tokenIdent {
+			p.pos++
+		}
+		if p.accept("(") {
+			for !p.accept(")") {
+				if p.advance().kind == tokenEOF {
+					return column{}, p.errorf("unterminated type of column %s", name)
+				}
+			}
+		}
+	}
+	for {
+		switch {
+		case p.accept("PRIMARY"):
+			if err := p.expect("KEY"); err != nil {
+				return column{}, err
+			}
+			col.primaryKey = true
+		case p.accept("NOT"):
+			if err := p.expect("NULL"); err != nil {
+				return column{}, err
+			}
+			col.notNull = true
+		case p.accept("NULL", "UNIQUE"):
+		case p.accept("AUTO_INCREMENT", "AUTOINCREMENT", "IDENTITY"):
+			col.autoIncrement = true
+		case p.accept("DEFAULT"):
+			if col.defaultValue, err = p.parsePrimary(); err != nil {
+				return column{}, err
+			}
+		default:
+			return col, nil
+		}
+	}
+}
+
+// constraintWords start a column constraint rather than a type
+var constraintWords = map[string]bool{"PRIMARY": true, "DEFAULT": true, "UNIQUE": true, "AUTO_INCREMENT": true,
+	"AUTOINCREMENT": true, "IDENTITY": true}
+
+// typeWords continue multi-word types such as DOUBLE PRECISION or TIMESTAMP WITH TIME ZONE
+var typeWords = map[string]bool{"PRECISION": true, "VARYING": true, "WITH": true, "WITHOUT": true, "TIME": true,
+	"ZONE": true, "UNSIGNED": true}
+
+func (p *parser) parseNameList() ([]string, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	var names []string
+	for {
+		name, err := p.identifier()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+		if !p.accept(",") {
+			break
+		}
+	}
+	return names, p.expect(")")
+}
+
+func (p *parser) parseDrop() (statement, error) {
+	if err := p.expect("TABLE"); err != nil {
+		return nil, err
+	}
+	stmt := &dropTable{}
+	if p.accept("IF") {
+		if err := p.expect("EXISTS"); err != nil {
+			return nil, err
+		}
+		stmt.ifExists = true
+	}
+	var err error
+	stmt.name, err = p.identifier()
+	return stmt, err
+}
+
+func (p *parser) parseInsert() (statement, error) {
+	if err := p.expect("INTO"); err != nil {
+		return nil, err
+	}
+	stmt := &insert{}
+	var err error
+	if stmt.table, err = p.identifier(); err != nil {
+		return nil, err
+	}
+	if p.peek().is("(") {
+		if stmt.columns, err = p.parseNameList(); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect("VALUES"); err != nil {
+		return nil, err
+	}
+	for {
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		var row []expr
+		for {
+			value, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			row = append(row, value)
+			if !p.accept(",") {
+				break
+			}
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		stmt.rows = append(stmt.rows, row)
+		if !p.accept(",") {
+			return stmt, nil
+		}
+	}
+}
+
+func (p *parser) parseUpdate() (statement, error) {
+	stmt := &update{}
+	var err error
+	if stmt.table, err = p.identifier(); err != nil {
+		return nil, err
+	}
+	if err := p.expect("SET"); err != nil {
+		return nil, err
+	}
+	for {
+		name, err := p.identifier()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("="); err != nil {
+			return nil, err
+		}
+		value, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		stmt.sets = append(stmt.sets, assignment{name, value})
+		if !p.accept(",") {
+			break
+		}
+	}
+	stmt.where, err = p.parseWhere()
+	return stmt, err
+}
+
+func (p *parser) parseDelete() (statement, error) {
+	if err := p.expect("FROM"); err != nil {
+		return nil, err
+	}
+	stmt := &deleteFrom{}
+	var err error
+	if stmt.table, err = p.identifier(); err != nil {
+		return nil, err
+	}
+	stmt.where, err = p.parseWhere()
+	return stmt, err
+}
+
+func (p *parser) parseWhere() (expr, error) {
+	if !p.accept("WHERE") {
+		return nil, nil
+	}
+	return p.parseExpr()
+}
+
+func (p *parser) parseSelect() (statement, error) {
+	stmt := &selectFrom{distinct: p.accept("DISTINCT")}
+	for {
+		item, err := p.parseSelectItem()
+		if err != nil {
+			return nil, err
+		}
+		stmt.items = append(stmt.items, item)
+		if !p.accept(",") {
+			break
+		}
+	}
+
+	if p.accept("FROM") {
+		from, err := p.parseTableRef()
+		if err != nil {
+			return nil, err
+		}
+		stmt.from = &from
+		for {
+			j := join{}
+			if p.accept("LEFT") {
+				p.accept("OUTER")
+				j.left = true
+			} else {
+				p.accept("INNER")
+			}
+			if !p.accept("JOIN") {
+				if j.left {
+					return nil, p.errorf("expected JOIN but found %s", p.describe())
+				}
+				break
+			}
+			if j.table, err = p.parseTableRef(); err != nil {
+				return nil, err
+			}
+			if err := p.expect("ON"); err != nil {
+				return nil, err
+			}
+			if j.on, err = p.parseExpr(); err != nil {
+				return nil, err
+			}
+			stmt.joins = append(stmt.joins, j)
+		}
+	}
+
+	var err error
+	if stmt.where, err = p.parseWhere(); err != nil {
+		return nil, err
+	}
+	if p.accept("ORDER") {
+		if err := p.expect("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			value, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			item := orderItem{expr: value}
+			if p.accept("DESC") {
+				item.desc = true
+			} else {
+				p.accept("ASC")
+			}
+			stmt.orderBy = append(stmt.orderBy, item)
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+	if p.accept("LIMIT") {
+		if stmt.limit, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+		if p.accept(",") { // MySQL's LIMIT offset, count
+			stmt.offset = stmt.limit
+			if stmt.limit, err = p.parseExpr(); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if p.accept("OFFSET") {
+		if stmt.offset, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	return stmt, nil
+}
+
+func (p *parser) parseSelectItem() (selectItem, error) {
+	if p.accept("*") {
+		return selectItem{star: true}, nil
+	}
+	if t := p.peek(); (t.kind == tokenIdent || t.kind == tokenQuotedIdent) && p.tokens[p.pos+1].is(".") && p.tokens[p.pos+2].is("*") {
+		p.pos += 3
+		return selectItem{star: true, starTable: t.raw}, nil
+	}
+	start := p.peek().start
+	value, err := p.parseExpr()
+	if err != nil {
+		return selectItem{}, err
+	}
+	item := selectItem{expr: value, name: strings.TrimSpace(p.query[start:p.tokens[p.pos-1].end])}
+	if ref, ok := value.(*columnRef); ok {
+		item.name = ref.name
+	}
+	if p.accept("AS") || p.peek().kind == tokenQuotedIdent || p.peek().kind == tokenIdent && !reservedWords[p.peek().text] {
+		if item.name, err = p.identifier(); err != nil {
+			return selectItem{}, err
+		}
+	}
+	return item, nil
+}
+
+func (p *parser) parseTableRef() (tableRef, error) {
+	name, err := p.identifier()
+	if err != nil {
+		return tableRef{}, err
+	}
+	ref := tableRef{name: name, alias: name}
+	if p.accept("AS") || p.peek().kind == tokenQuotedIdent || p.peek().kind == tokenIdent && !reservedWords[p.peek().text] {
+		if ref.alias, err = p.identifier(); err != nil {
+			return tableRef{}, err
+		}
+	}
+	return ref, nil
+}
+
+// parseExpr parses an expression. Precedence from lowest to highest is OR, AND, NOT, comparisons, + -, * /
+func (p *parser) parseExpr() (expr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("OR") {
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: "OR", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd() (expr, error) {
+	left, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("AND") {
+		right, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: "AND", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (expr, error) {
+	if p.accept("NOT") {
+		value, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &not{value}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (expr, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range []string{"=", "<>", "!=", "<=", ">=", "<", ">"} {
+		if p.accept(op) {
+			right, err := p.parseAdditive()
+			if err != nil {
+				return nil, err
+			}
+			if op == "!=" {
+				op = "<>"
+			}
+			return &binary{op: op, left: left, right: right}, nil
+		}
+	}
+	if p.accept("IS") {
+		negate := p.accept("NOT")
+		if err := p.expect("NULL"); err != nil {
+			return nil, err
+		}
+		return &isNull{value: left, not: negate}, nil
+	}
+	negate := p.accept("NOT")
+	switch {
+	case p.accept("IN"):
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		in := &inList{value: left, not: negate}
+		for {
+			item, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			in.list = append(in.list, item)
+			if !p.accept(",") {
+				break
+			}
+		}
+		return in, p.expect(")")
+	case p.accept("LIKE"):
+		pattern, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &like{value: left, pattern: pattern, not: negate}, nil
+	case p.accept("BETWEEN"):
+		low, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("AND"); err != nil {
+			return nil, err
+		}
+		high, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		var between expr = &binary{op: "AND", left: &binary{op: ">=", left: left, right: low}, right: &binary{op: "<=", left: left, right: high}}
+		if negate {
+			between = &not{between}
+		}
+		return between, nil
+	case negate:
+		return nil, p.errorf("expected IN, LIKE or BETWEEN after NOT but found %s", p.describe())
+	}
+	return left, nil
+}
+
+func (p *parser) parseAdditive() (expr, error) {
+	left, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek().text
+		if !p.accept("+", "-") {
+			return left, nil
+		}
+		right, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parseMultiplicative() (expr, error) {
+	left, err := p.parsePrimary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := p.peek().text
+		if !p.accept("*", "/") {
+			return left, nil
+		}
+		right, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) parsePrimary() (expr, error) {
+	t := p.peek()
+	switch {
+	case t.kind == tokenNumber || t.kind == tokenString:
+		p.pos++
+		return &literal{value: t.value, position: t.kind == tokenNumber}, nil
+	case t.kind == tokenPlaceholder:
+		p.pos++
+		n := t.value.(int)
+		if n == 0 {
+			p.next++
+			n = p.next
+		}
+		if n > len(p.args) {
+			return nil, p.errorf("placeholder %d has no argument. %d were provided", n, len(p.args))
+		}
+		if n > p.used {
+			p.used = n
+		}
+		return &literal{value: p.args[n-1]}, nil
+	case p.accept("NULL"):
+		return &literal{}, nil
+	case p.accept("TRUE"):
+		return &literal{value: true}, nil
+	case p.accept("FALSE"):
+		return &literal{value: false}, nil
+	case p.accept("-"):
+		if t := p.peek(); t.kind == tokenNumber {
+			p.pos++
+			return &literal{value: negateNumber(t)}, nil
+		}
+		value, err := p.parsePrimary()
+		if err != nil {
+			return nil, err
+		}
+		return &binary{op: "-", left: &literal{value: int64(0)}, right: value}, nil
+	case p.accept("("):
+		value, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		return value, p.expect(")")
+	}
+	name, err := p.identifier()
+	if err != nil {
+		return nil, err
+	}
+	if p.accept(".") {
+		column, err := p.identifier()
+		if err != nil {
+			return nil, err
+		}
+		return &columnRef{table: name, name: column}, nil
+	}
+	return &columnRef{name: name}, nil
+}
+
+// negateNumber negates a number literal directly so that -9223372036854775808 is an int64 even though its digits
+// alone don't fit in one
+func negateNumber(t token) interface{} {
+	switch v := t.value.(type) {
+	case int64:
+		return -v
+	case float64:
+		if t.raw == "9223372036854775808" {
+			return int64(math.MinInt64)
+		}
+		return -v
+	}
+	return t.value
+}
@@ -0,0 +1,321 @@
+package memdb
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// valueKind is the type a column stores. Values are kept as the driver.Value types returned by database/sql drivers:
+// int64, float64, bool, string, []byte and time.Time
+type valueKind int
+
+const (
+	kindAny valueKind = iota
+	kindInt
+	kindFloat
+	kindBool
+	kindString
+	kindTime
+	kindBytes
+)
+
+// columnKind maps a SQL type name to the kind stored and whether the type auto increments
+func columnKind(typeName string) (valueKind, bool) {
+	switch typeName {
+	case "SERIAL", "BIGSERIAL", "SMALLSERIAL":
+		return kindInt, true
+	case "INT", "INTEGER", "BIGINT", "SMALLINT", "TINYINT", "MEDIUMINT", "INT2", "INT4", "INT8":
+		return kindInt, false
+	case "REAL", "FLOAT", "FLOAT4", "FLOAT8", "DOUBLE", "DECIMAL", "NUMERIC", "MONEY":
+		return kindFloat, false
+	case "BOOL", "BOOLEAN", "BIT":
+		return kindBool, false
+	case "TEXT", "VARCHAR", "CHAR", "CHARACTER", "NVARCHAR", "NCHAR", "NTEXT", "CLOB", "UUID", "UNIQUEIDENTIFIER", "JSON", "JSONB":
+		return kindString, false
+	case "DATE", "DATETIME", "DATETIME2", "TIMESTAMP", "TIMESTAMPTZ", "TIME":
+		return kindTime, false
+	case "BLOB", "BYTEA", "BINARY", "VARBINARY":
+		return kindBytes, false
+	}
+	return kindAny, false
+}
+
+var timeLayouts = []string{time.RFC3339Nano, "2006-01-02 15:04:05.999999999Z07:00", "2006-01-02 15:04:05.999999999", "2006-01-02"}
+
+func parseTime(s string) (time.Time, bool) {
+	for _, layout := range timeLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// coerce converts a value to the kind of a column the way a database converts inserted values
+func coerce(value interface{}, kind valueKind) (interface{}, error) {
+	if value == nil || kind == kindAny {
+		return value, nil
+	}
+	switch kind {
+	case kindInt:
+		switch v := value.(type) {
+		case int64:
+			return v, nil
+		case float64:
+			if v == float64(int64(v)) {
+				return int64(v), nil
+			}
+		case bool:
+			if v {
+				return int64(1), nil
+			}
+			return int64(0), nil
+		case string:
+			if n, err := strconv.ParseInt(strings.TrimSpace(v), 10, 64); err == nil {
+				return n, nil
+			}
+		}
+	case kindFloat:
+		switch v := value.(type) {
+		case int64:
+			return float64(v), nil
+		case float64:
+			return v, nil
+		case string:
+			if f, err := strconv.ParseFloat(strings.TrimSpace(v), 64); err == nil {
+				return f, nil
+			}
+		}
+	case kindBool:
+		switch v := value.(type) {
+		case bool:
+			return v, nil
+		case int64:
+			return v != 0, nil
+		case string:
+			if b, err := strconv.ParseBool(v); err == nil {
+				return b, nil
+			}
+		}
+	case kindString:
+		switch v := value.(type) {
+		case string:
+			return v, nil
+		case []byte:
+			return string(v), nil
+		case time.Time:
+			return v.Format(time.RFC3339Nano), nil
+		default:
+			return fmt.Sprint(v), nil
+		}
+	case kindTime:
+		switch v := value.(type) {
+		case time.Time:
+			return v, nil
+		case string:
+			if t, ok := parseTime(v); ok {
+				return t, nil
+			}
+		}
+	case kindBytes:
+		switch v := value.(type) {
+		case []byte:
+			return append([]byte{}, v...), nil
+		case string:
+			return []byte(v), nil
+		}
+	}
+	return nil, fmt.Errorf("memdb: cannot store %T value %v in a %s column", value, value, kind)
+}
+
+func (k valueKind) String() string {
+	return [...]string{"untyped", "integer", "float", "boolean", "text", "timestamp", "binary"}[k]
+}
+
+// compare orders two non NULL values. Numbers compare across int64 and float64, and text is converted when it is
+// compared with a number or time
+func compare(a, b interface{}) (int, error) {
+	switch x := a.(type) {
+	case int64:
+		switch y := b.(type) {
+		case int64:
+			return compareOrdered(x, y), nil
+		case float64:
+			return compareOrdered(float64(x), y), nil
+		case string:
+			if f, err := strconv.ParseFloat(y, 64); err == nil {
+				return compareOrdered(float64(x), f), nil
+			}
+		}
+	case float64:
+		switch y := b.(type) {
+		case int64:
+			return compareOrdered(x, float64(y)), nil
+		case float64:
+			return compareOrdered(x, y), nil
+		case string:
+			if f, err := strconv.ParseFloat(y, 64); err == nil {
+				return compareOrdered(x, f), nil
+			}
+		}
+	case string:
+		switch y := b.(type) {
+		case string:
+			return strings.Compare(x, y), nil
+		case []byte:
+			return strings.Compare(x, string(y)), nil
+		case int64, float64, time.Time:
+			c, err := compare(b, a)
+			return -c, err
+		}
+	case []byte:
+		switch y := b.(type) {
+		case []byte:
+			return bytes.Compare(x, y), nil
+		case string:
+			return strings.Compare(string(x), y), nil
+		}
+	case bool:
+		if y, ok := b.(bool); ok {
+			if x == y {
+				return 0, nil
+			} else if !x {
+				return -1, nil
+			}
+			return 1, nil
+		}
+	case time.Time:
+		y, ok := b.(time.Time)
+		if s, isString := b.(string); isString {
+			y, ok = parseTime(s)
+		}
+		if ok {
+			if x.Before(y) {
+				return -1, nil
+			} else if x.After(y) {
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+	return 0, fmt.Errorf("memdb: cannot compare %T with %T", a, b)
+}
+
+func compareOrdered[T int64 | float64](x, y T) int {
+	if x < y {
+		return -1
+	} else if x > y {
+		return 1
+	}
+	return 0
+}
+
+// compareNullsFirst orders NULL before every other value, as ORDER BY does in MySQL and SQLite
+func compareNullsFirst(a, b interface{}) (int, error) {
+	switch {
+	case a == nil && b == nil:
+		return 0, nil
+	case a == nil:
+		return -1, nil
+	case b == nil:
+		return 1, nil
+	}
+	return compare(a, b)
+}
+
+func arithmetic(op string, a, b interface{}) (interface{}, error) {
+	if a == nil || b == nil {
+		return nil, nil
+	}
+	x, xInt := a.(int64)
+	y, yInt := b.(int64)
+	if xInt && yInt && (op != "/" || y != 0) {
+		return intArithmetic(op, x, y)
+	}
+	fx, ok1 := toFloat(a)
+	fy, ok2 := toFloat(b)
+	if !ok1 || !ok2 {
+		return nil, fmt.Errorf("memdb: cannot apply %s to %T and %T", op, a, b)
+	}
+	switch op {
+	case "+":
+		return fx + fy, nil
+	case "-":
+		return fx - fy, nil
+	case "*":
+		return fx * fy, nil
+	}
+	if fy == 0 {
+		return nil, nil // division by zero is NULL, as in MySQL
+	}
+	return fx / fy, nil
+}
+
+// intArithmetic fails instead of wrapping around when the result doesn't fit in an int64, as databases do
+func intArithmetic(op string, x, y int64) (interface{}, error) {
+	var result int64
+	overflow := false
+	switch op {
+	case "+":
+		result = x + y
+		overflow = (y > 0 && result < x) || (y < 0 && result > x)
+	case "-":
+		result = x - y
+		overflow = (y > 0 && result > x) || (y < 0 && result < x)
+	case "*":
+		result = x * y
+		overflow = x != 0 && (result/x != y || x == -1 && y == math.MinInt64)
+	case "/":
+		result = x / y
+		overflow = x == math.MinInt64 && y == -1
+	}
+	if overflow {
+		return nil, fmt.Errorf("memdb: integer out of range for %d %s %d", x, op, y)
+	}
+	return result, nil
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// likePattern converts a LIKE pattern using % and _ into a regular expression
+func likePattern(pattern string) (*regexp.Regexp, error) {
+	var b strings.Builder
+	b.WriteString("(?s)^")
+	for _, r := range pattern {
+		switch r {
+		case '%':
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return regexp.Compile(b.String())
+}
+
+// rowKey identifies a set of values for primary key and DISTINCT comparisons
+func rowKey(values []interface{}) string {
+	var b strings.Builder
+	for _, value := range values {
+		if t, ok := value.(time.Time); ok {
+			value = t.UTC().Format(time.RFC3339Nano)
+		}
+		fmt.Fprintf(&b, "%T:%v\x00", value, value)
+	}
+	return b.String()
+}
@@ -1,18 +1,23 @@
 package onedb
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
 )
 
 type mockDb struct {
+	mu         sync.Mutex
 	data       []interface{}
 	methodsRun []MethodsRun
 	closeErr   error
 	execErr    error
+	*Expectations
 }
 
 // MethodsRun contains the name of the method run and a slice of arguments
@@ -26,18 +31,26 @@ type Mocker interface {
 	DBer
 	Query(query string, args ...interface{}) (RowsScanner, error)
 	QueryRow(query string, args ...interface{}) Scanner
+	QueryContext(ctx context.Context, query string, args ...interface{}) (RowsScanner, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) Scanner
 	QueriesRun() []MethodsRun
 	SaveMethodCall(name string, arguments []interface{})
-	VerifyNextCommand(t *testing.T, name string, expected ...interface{})
+	VerifyNextCommand(t testing.TB, name string, expected ...interface{})
+	AssertCalled(t testing.TB, name string, expected ...interface{})
+	Expecter
 }
 
-// NewMock will create an instance that implements the Mocker interface
+// NewMock will create an instance that implements the Mocker interface. Queries return the data items, slices of
+// structs or Rows (see NewRows), in call order unless expectations are declared with ExpectQuery. The mock is safe
+// for concurrent use
 func NewMock(closeErr, execErr error, data ...interface{}) Mocker {
 	queries := []MethodsRun{}
-	return &mockDb{data, queries, closeErr, execErr}
+	return &mockDb{data: data, methodsRun: queries, closeErr: closeErr, execErr: execErr, Expectations: &Expectations{}}
 }
 
 func (r *mockDb) SaveMethodCall(name string, arguments []interface{}) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	r.methodsRun = append(r.methodsRun, MethodsRun{name, arguments})
 }
 
@@ -47,12 +60,36 @@ func (r *mockDb) Backend() interface{} {
 
 func (r *mockDb) Query(query string, args ...interface{}) (RowsScanner, error) {
 	r.SaveMethodCall("Query", append([]interface{}{query}, args...))
-	return r.nextScanner()
+	return r.nextScanner(query, args)
 }
 
 func (r *mockDb) QueryRow(query string, args ...interface{}) Scanner {
 	r.SaveMethodCall("QueryRow", append([]interface{}{query}, args...))
-	s, _ := r.nextScanner()
+	s, err := r.nextScanner(query, args)
+	if err != nil {
+		return &errorScanner{err}
+	}
+	s.Next()
+	return s
+}
+
+func (r *mockDb) QueryContext(ctx context.Context, query string, args ...interface{}) (RowsScanner, error) {
+	r.SaveMethodCall("QueryContext", append([]interface{}{query}, args...))
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.nextScanner(query, args)
+}
+
+func (r *mockDb) QueryRowContext(ctx context.Context, query string, args ...interface{}) Scanner {
+	r.SaveMethodCall("QueryRowContext", append([]interface{}{query}, args...))
+	if err := ctx.Err(); err != nil {
+		return &errorScanner{err}
+	}
+	s, err := r.nextScanner(query, args)
+	if err != nil {
+		return &errorScanner{err}
+	}
 	s.Next()
 	return s
 }
@@ -87,40 +124,150 @@ func (r *mockDb) QueryWriteCSV(w io.Writer, options CSVOptions, query string, ar
 	return QueryWriteCSV(w, options, r, query, args...)
 }
 
+func (r *mockDb) QueryValuesContext(ctx context.Context, query *Query, result ...interface{}) error {
+	r.SaveMethodCall("QueryValuesContext", append([]interface{}{query}, result...))
+	return QueryValuesContext(ctx, r, query, result...)
+}
+
+func (r *mockDb) QueryJSONContext(ctx context.Context, query string, args ...interface{}) (string, error) {
+	r.SaveMethodCall("QueryJSONContext", append([]interface{}{query}, args...))
+	return QueryJSONContext(ctx, r, query, args...)
+}
+
+func (r *mockDb) QueryJSONRowContext(ctx context.Context, query string, args ...interface{}) (string, error) {
+	r.SaveMethodCall("QueryJSONRowContext", append([]interface{}{query}, args...))
+	return QueryJSONRowContext(ctx, r, query, args...)
+}
+
+func (r *mockDb) QueryStructContext(ctx context.Context, result interface{}, query string, args ...interface{}) error {
+	r.SaveMethodCall("QueryStructContext", append([]interface{}{result, query}, args...))
+	return QueryStructContext(ctx, r, result, query, args...)
+}
+
+func (r *mockDb) QueryStructRowContext(ctx context.Context, result interface{}, query string, args ...interface{}) error {
+	r.SaveMethodCall("QueryStructRowContext", append([]interface{}{result, query}, args...))
+	return QueryStructRowContext(ctx, r, result, query, args...)
+}
+
+func (r *mockDb) QueryWriteCSVContext(ctx context.Context, w io.Writer, options CSVOptions, query string, args ...interface{}) error {
+	r.SaveMethodCall("QueryWriteCSVContext", append([]interface{}{w, options, query}, args...))
+	return QueryWriteCSVContext(ctx, w, options, r, query, args...)
+}
+
 func (r *mockDb) Close() error {
 	r.SaveMethodCall("Close", nil)
 	return r.closeErr
 }
 
+// Exec returns the Result of the matching expectation. Without expectations it returns the next mock data item
+// when it is a Result (see NewResult) and an empty Result otherwise
+func (r *mockDb) Exec(query string, args ...interface{}) (Result, error) {
+	r.SaveMethodCall("Exec", append([]interface{}{query}, args...))
+	return r.nextResult(query, args)
+}
+
+func (r *mockDb) ExecContext(ctx context.Context, query string, args ...interface{}) (Result, error) {
+	r.SaveMethodCall("ExecContext", append([]interface{}{query}, args...))
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.nextResult(query, args)
+}
+
 func (r *mockDb) Execute(query string, args ...interface{}) error {
 	r.SaveMethodCall("Execute", append([]interface{}{query}, args...))
 	return r.execErr
 }
 
 func (r *mockDb) QueriesRun() []MethodsRun {
-	return r.methodsRun
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]MethodsRun{}, r.methodsRun...)
 }
 
-func (r *mockDb) nextScanner() (RowsScanner, error) {
+func (r *mockDb) nextScanner(query string, args []interface{}) (RowsScanner, error) {
+	e, err := r.MatchQuery(query, args...)
+	if err == nil && e != nil {
+		err = e.Err()
+	}
+	if err != nil {
+		return &mockRowsScanner{ErrErr: err}, err
+	}
+	if e != nil {
+		return newMockRows(e.Rows()), nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
 	if len(r.data) == 0 {
 		err := errors.New("no mock data found to return")
 		return &mockRowsScanner{ErrErr: err}, err
 	}
 	data := r.data[0]
 	r.data = r.data[1:]
-	return NewRowsScanner(data), nil
+	return newMockRows(data), nil
+}
+
+// newMockRows reads mock data from the start. data is either Rows or a slice of structs
+func newMockRows(data interface{}) RowsScanner {
+	if rows, ok := data.(*Rows); ok {
+		return rows.clone()
+	}
+	return NewRowsScanner(data)
+}
+
+func (r *mockDb) nextResult(query string, args []interface{}) (Result, error) {
+	e, err := r.MatchExec(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	if e != nil {
+		return e.Result(), e.Err()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.data) > 0 {
+		if result, ok := r.data[0].(Result); ok {
+			r.data = r.data[1:]
+			return result, r.execErr
+		}
+	}
+	return &mockResult{}, r.execErr
+}
+
+type mockResult struct {
+	lastInsertID int64
+	rowsAffected int64
+}
+
+// NewResult returns a Result that can be passed as mock data to be returned from Exec
+func NewResult(lastInsertID, rowsAffected int64) Result {
+	return &mockResult{lastInsertID, rowsAffected}
+}
+
+func (r *mockResult) LastInsertId() (int64, error) {
+	return r.lastInsertID, nil
+}
+
+func (r *mockResult) RowsAffected() (int64, error) {
+	return r.rowsAffected, nil
 }
 
 // ErrNoMethods is an error for when no methods are left to verify.
 var ErrNoMethods = errors.New("No methods found to have been run")
 
-func (r *mockDb) VerifyNextCommand(t *testing.T, name string, expected ...interface{}) {
+// VerifyNextCommand removes the oldest recorded call and checks its name and arguments. Expected arguments may be
+// Matchers
+func (r *mockDb) VerifyNextCommand(t testing.TB, name string, expected ...interface{}) {
+	t.Helper()
+	r.mu.Lock()
 	if len(r.methodsRun) == 0 {
+		r.mu.Unlock()
 		t.Error(ErrNoMethods)
 		return
 	}
 	current := r.methodsRun[0]
 	r.methodsRun = r.methodsRun[1:]
+	r.mu.Unlock()
 	if current.MethodName != name {
 		t.Errorf("Method %s not found. Actual method was %s", name, current.MethodName)
 		return
@@ -128,12 +275,27 @@ func (r *mockDb) VerifyNextCommand(t *testing.T, name string, expected ...interf
 	verifyArgs(t, current.Arguments, expected...)
 }
 
-func verifyArgs(t *testing.T, actual []interface{}, expected ...interface{}) {
+// AssertCalled checks that a call with this name and arguments was recorded, in any order. Unlike VerifyNextCommand
+// it doesn't remove calls. Expected arguments may be Matchers
+func (r *mockDb) AssertCalled(t testing.TB, name string, expected ...interface{}) {
+	t.Helper()
+	var calls []string
+	for _, call := range r.QueriesRun() {
+		if call.MethodName == name && argsMatch(expected, call.Arguments) {
+			return
+		}
+		calls = append(calls, fmt.Sprintf("%s%v", call.MethodName, call.Arguments))
+	}
+	t.Errorf("Method %s%v was not called. Calls were: %s", name, expected, strings.Join(calls, ", "))
+}
+
+func verifyArgs(t testing.TB, actual []interface{}, expected ...interface{}) {
+	t.Helper()
 	if len(expected) != len(actual) {
 		t.Fatal("Number of arguments don't match. Expected:", len(expected), "actual:", len(actual))
 	}
 	for i := range actual {
-		if !reflect.DeepEqual(actual[i], expected[i]) {
+		if !argMatches(expected[i], actual[i]) {
 			t.Errorf("Argument mismatch at %d. Expected:%v, Actual:%v\n", i, expected[i], actual[i])
 		}
 	}
@@ -142,8 +304,7 @@ func verifyArgs(t *testing.T, actual []interface{}, expected ...interface{}) {
 type mockRowsScanner struct {
 	sliceValue reflect.Value
 	sliceLen   int
-	structType reflect.Type
-	structLen  int
+	columns    []structColumn
 	data       interface{}
 	currentRow int
 	ColumnsErr error
@@ -158,10 +319,9 @@ func NewRowsScanner(data interface{}) RowsScanner {
 	}
 	sliceValue := reflect.ValueOf(data)
 	sliceLen := sliceValue.Len()
-	structType := reflect.TypeOf(data).Elem()
-	structLen := structType.NumField()
+	columns := getStructColumns(reflect.TypeOf(data).Elem())
 
-	return &mockRowsScanner{data: data, currentRow: -1, sliceValue: sliceValue, sliceLen: sliceLen, structType: structType, structLen: structLen}
+	return &mockRowsScanner{data: data, currentRow: -1, sliceValue: sliceValue, sliceLen: sliceLen, columns: columns}
 }
 
 func (r *mockRowsScanner) Columns() ([]string, error) {
@@ -169,9 +329,9 @@ func (r *mockRowsScanner) Columns() ([]string, error) {
 		return nil, r.ColumnsErr
 	}
 
-	columns := make([]string, r.structLen)
-	for i := 0; i < r.structLen; i++ {
-		columns[i] = r.structType.Field(i).Name
+	columns := make([]string, len(r.columns))
+	for i, column := range r.columns {
+		columns[i] = column.Name
 	}
 	return columns, nil
 }
@@ -194,16 +354,20 @@ func (r *mockRowsScanner) Scan(dest ...interface{}) error {
 	if r.currentRow >= r.sliceLen || r.currentRow < 0 {
 		return errors.New("invalid current row")
 	}
-	return setDestValue(r.sliceValue.Index(r.currentRow), dest)
+	return setDestValue(r.sliceValue.Index(r.currentRow), r.columns, dest)
 }
 
-func setDestValue(structVal reflect.Value, dest []interface{}) error {
-	if len(dest) != structVal.NumField() {
-		return fmt.Errorf("expected equal number of dest values as source. Expected: %d, Actual: %d", structVal.NumField(), len(dest))
+func setDestValue(structVal reflect.Value, columns []structColumn, dest []interface{}) error {
+	if len(dest) != len(columns) {
+		return fmt.Errorf("expected equal number of dest values as source. Expected: %d, Actual: %d", len(columns), len(dest))
 	}
 	for i := range dest {
 		destination := reflect.ValueOf(dest[i]).Elem()
-		source := structVal.Field(i)
+		source, ok := readFieldByIndex(structVal, columns[i].Index)
+		if !ok { // nested struct pointer is nil so the column is NULL
+			destination.Set(reflect.Zero(destination.Type()))
+			continue
+		}
 		if destination.Type() != source.Type() && destination.Type().Kind() != reflect.Interface {
 			return fmt.Errorf("source and destination types do not match at index: %d", i)
 		}
@@ -218,6 +382,7 @@ func (r *mockRowsScanner) Err() error {
 
 type mockScanner struct {
 	structValue reflect.Value
+	columns     []structColumn
 	data        interface{}
 	ScanErr     error
 }
@@ -228,14 +393,14 @@ func NewScanner(data interface{}) Scanner {
 		return &mockScanner{ScanErr: ErrRowScannerInvalidData}
 	}
 	structValue := reflect.ValueOf(data).Elem()
-	return &mockScanner{data: data, structValue: structValue}
+	return &mockScanner{data: data, structValue: structValue, columns: getStructColumns(structValue.Type())}
 }
 
 func (s *mockScanner) Scan(dest ...interface{}) error {
 	if s.ScanErr != nil {
 		return s.ScanErr
 	}
-	return setDestValue(s.structValue, dest)
+	return setDestValue(s.structValue, s.columns, dest)
 }
 
 type errorScanner struct {
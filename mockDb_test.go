@@ -114,6 +114,37 @@ func TestExec(t *testing.T) {
 	}
 }
 
+func TestMockDBExec(t *testing.T) {
+	d := NewMock(nil, nil, NewResult(12, 1), []SimpleData{{1, "hello"}})
+	result, err := d.Exec("insert", "arg1")
+	if err != nil {
+		t.Fatal("expected success", err)
+	}
+	if id, _ := result.LastInsertId(); id != 12 {
+		t.Error("expected mock result", id)
+	}
+	if affected, _ := result.RowsAffected(); affected != 1 {
+		t.Error("expected mock result", affected)
+	}
+	d.VerifyNextCommand(t, "Exec", "insert", "arg1")
+
+	// query data is not consumed by Exec
+	result, _ = d.Exec("update")
+	if affected, _ := result.RowsAffected(); affected != 0 {
+		t.Error("expected empty result", affected)
+	}
+	data := []SimpleData{}
+	if err := d.QueryStruct(&data, "select"); err != nil || len(data) != 1 {
+		t.Error("expected query data to remain", err, data)
+	}
+
+	fail := errors.New("fail")
+	d = NewMock(nil, fail)
+	if _, err := d.Exec("insert"); err != fail {
+		t.Error("expected exec error", err)
+	}
+}
+
 func TestErrorScannerScan(t *testing.T) {
 	err := errors.New("fail")
 	e := errorScanner{err}
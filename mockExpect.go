@@ -0,0 +1,217 @@
+package onedb
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Expecter is implemented by the mocks so that tests can declare the queries they expect instead of relying on the
+// order of the mock data:
+//
+//	db := onedb.NewMock(nil, nil)
+//	db.ExpectQuery("select * from users where id = ?").WithArgs(1).WillReturnRows([]User{{ID: 1}})
+//	db.ExpectQueryRegex(`^select .* from orders\b`).WillReturnRows([]Order{{ID: 2}})
+//	db.ExpectExec("delete from sessions").WillReturnResult(onedb.NewResult(0, 3))
+//	...
+//	if err := db.ExpectationsWereMet(); err != nil {
+//		t.Error(err)
+//	}
+//
+// Once an expectation is declared every query must match one. Mocks without expectations hand out their data in
+// call order
+type Expecter interface {
+	ExpectQuery(query string) *Expectation
+	ExpectQueryRegex(pattern string) *Expectation
+	ExpectExec(query string) *Expectation
+	ExpectExecRegex(pattern string) *Expectation
+	MatchExpectationsInOrder(ordered bool)
+	ExpectationsWereMet() error
+	MatchQuery(query string, args ...interface{}) (*Expectation, error)
+	MatchExec(query string, args ...interface{}) (*Expectation, error)
+}
+
+// Expectation is a query or command a mock expects to run and what it returns when it does
+type Expectation struct {
+	exec    bool
+	query   string
+	pattern *regexp.Regexp
+	args    []interface{}
+	hasArgs bool
+	rows    interface{}
+	result  Result
+	err     error
+	met     bool
+}
+
+// WithArgs restricts the expectation to calls with these arguments, which may be Matchers. Without it any arguments
+// match
+func (e *Expectation) WithArgs(args ...interface{}) *Expectation {
+	e.args = args
+	e.hasArgs = true
+	return e
+}
+
+// WillReturnRows sets the Rows or slice of structs returned by a query. The redis mock returns it as the reply of Do
+func (e *Expectation) WillReturnRows(data interface{}) *Expectation {
+	e.rows = data
+	return e
+}
+
+// WillReturnResult sets the Result returned by an expected Exec
+func (e *Expectation) WillReturnResult(result Result) *Expectation {
+	e.result = result
+	return e
+}
+
+// WillReturnError makes the matching call fail with err
+func (e *Expectation) WillReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// Rows returns the data set by WillReturnRows
+func (e *Expectation) Rows() interface{} {
+	return e.rows
+}
+
+// Result returns the Result set by WillReturnResult or an empty Result
+func (e *Expectation) Result() Result {
+	if e.result == nil {
+		return &mockResult{}
+	}
+	return e.result
+}
+
+// Err returns the error set by WillReturnError
+func (e *Expectation) Err() error {
+	return e.err
+}
+
+func (e *Expectation) String() string {
+	kind := "query"
+	if e.exec {
+		kind = "exec"
+	}
+	if e.pattern != nil {
+		kind += " matching"
+	}
+	if e.hasArgs {
+		return fmt.Sprintf("%s %q with args %v", kind, e.query, e.args)
+	}
+	return fmt.Sprintf("%s %q", kind, e.query)
+}
+
+// matches compares the query text exactly, ignoring differences in white space, or against the regular expression
+// of ExpectQueryRegex and ExpectExecRegex
+func (e *Expectation) matches(exec bool, query string, args []interface{}) bool {
+	if e.exec != exec {
+		return false
+	}
+	if e.pattern != nil && !e.pattern.MatchString(query) || e.pattern == nil && normalizeQuery(e.query) != normalizeQuery(query) {
+		return false
+	}
+	return !e.hasArgs || argsMatch(e.args, args)
+}
+
+func normalizeQuery(query string) string {
+	return strings.Join(strings.Fields(query), " ")
+}
+
+// Expectations is the list of expected calls shared by the mocks. Expectations are matched in the order they were
+// declared unless MatchExpectationsInOrder(false) is called
+type Expectations struct {
+	mu        sync.Mutex
+	list      []*Expectation
+	unordered bool
+}
+
+// ExpectQuery adds an expected query. query must match the whole query text, ignoring differences in white space
+func (x *Expectations) ExpectQuery(query string) *Expectation {
+	return x.expect(&Expectation{query: query})
+}
+
+// ExpectQueryRegex adds an expected query that matches the regular expression pattern. Like regexp.MatchString the
+// pattern may match any part of the query unless it is anchored with ^ and $. It panics if pattern doesn't compile
+func (x *Expectations) ExpectQueryRegex(pattern string) *Expectation {
+	return x.expect(&Expectation{query: pattern, pattern: regexp.MustCompile(pattern)})
+}
+
+// ExpectExec adds an expected command that doesn't return rows. query is compared as it is by ExpectQuery
+func (x *Expectations) ExpectExec(query string) *Expectation {
+	return x.expect(&Expectation{exec: true, query: query})
+}
+
+// ExpectExecRegex adds an expected command that matches the regular expression pattern, as ExpectQueryRegex does
+func (x *Expectations) ExpectExecRegex(pattern string) *Expectation {
+	return x.expect(&Expectation{exec: true, query: pattern, pattern: regexp.MustCompile(pattern)})
+}
+
+func (x *Expectations) expect(e *Expectation) *Expectation {
+	x.mu.Lock()
+	x.list = append(x.list, e)
+	x.mu.Unlock()
+	return e
+}
+
+// MatchExpectationsInOrder sets whether calls must happen in the order the expectations were declared. The default
+// is true
+func (x *Expectations) MatchExpectationsInOrder(ordered bool) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	x.unordered = !ordered
+}
+
+// MatchQuery finds the expectation for a query and marks it as met. It returns nil and no error when no
+// expectations were declared
+func (x *Expectations) MatchQuery(query string, args ...interface{}) (*Expectation, error) {
+	return x.match(false, query, args)
+}
+
+// MatchExec finds the expectation for a command and marks it as met. It returns nil and no error when no
+// expectations were declared
+func (x *Expectations) MatchExec(query string, args ...interface{}) (*Expectation, error) {
+	return x.match(true, query, args)
+}
+
+func (x *Expectations) match(exec bool, query string, args []interface{}) (*Expectation, error) {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	if len(x.list) == 0 {
+		return nil, nil
+	}
+	kind := "query"
+	if exec {
+		kind = "exec"
+	}
+	for _, e := range x.list {
+		if e.met {
+			continue
+		}
+		if e.matches(exec, query, args) {
+			e.met = true
+			return e, nil
+		}
+		if !x.unordered {
+			return nil, fmt.Errorf("onedb: %s %q with args %v was not expected. Next expectation is %s", kind, query, args, e)
+		}
+	}
+	return nil, fmt.Errorf("onedb: %s %q with args %v was not expected", kind, query, args)
+}
+
+// ExpectationsWereMet returns an error listing the expectations that haven't been matched
+func (x *Expectations) ExpectationsWereMet() error {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+	var unmet []string
+	for _, e := range x.list {
+		if !e.met {
+			unmet = append(unmet, e.String())
+		}
+	}
+	if len(unmet) > 0 {
+		return fmt.Errorf("onedb: expectations were not met: %s", strings.Join(unmet, ", "))
+	}
+	return nil
+}
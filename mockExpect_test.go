@@ -0,0 +1,130 @@
+package onedb
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestExpectQueryRowError(t *testing.T) {
+	d := NewMock(nil, nil)
+	d.ExpectQuery("select name from users").WillReturnError(errors.New("fail"))
+	d.ExpectQuery("select count from users").WillReturnError(errors.New("timeout"))
+	d.ExpectQuery("select 1").WillReturnError(errors.New("canceled"))
+
+	var name string
+	if err := d.QueryRow("select name from users").Scan(&name); err == nil || err.Error() != "fail" {
+		t.Error("expected QueryRow to return the expected error", err)
+	}
+	var count int
+	if err := d.QueryValues(NewQuery("select count from users"), &count); err == nil || err.Error() != "timeout" {
+		t.Error("expected QueryValues to return the expected error", err)
+	}
+	if err := d.QueryRowContext(context.Background(), "select 1").Scan(&count); err == nil || err.Error() != "canceled" {
+		t.Error("expected QueryRowContext to return the expected error", err)
+	}
+	if err := NewMock(nil, nil).QueryRow("select 1").Scan(&count); err == nil || !strings.Contains(err.Error(), "no mock data") {
+		t.Error("expected missing data error", err)
+	}
+}
+
+func TestExpectQueryInOrder(t *testing.T) {
+	d := NewMock(nil, nil)
+	d.ExpectQuery("select * from users where id = ?").WithArgs(1).WillReturnRows([]SimpleData{{1, "alice"}})
+	d.ExpectQueryRegex(`select \* from users where name like`).WillReturnRows([]SimpleData{{2, "bob"}, {3, "bill"}})
+
+	var users []SimpleData
+	if err := d.QueryStruct(&users, "select *\n  from users where id = ?", 1); err != nil || len(users) != 1 || users[0].StringVal != "alice" {
+		t.Error("expected exact match ignoring white space", users, err)
+	}
+	if err := d.ExpectationsWereMet(); err == nil || !strings.Contains(err.Error(), "like") {
+		t.Error("expected unmet expectation", err)
+	}
+	users = nil
+	if err := d.QueryStruct(&users, "select * from users where name like ?", "b%"); err != nil || len(users) != 2 {
+		t.Error("expected regular expression match", users, err)
+	}
+	if err := d.ExpectationsWereMet(); err != nil {
+		t.Error("expected all expectations met", err)
+	}
+	if _, err := d.Query("select 1"); err == nil {
+		t.Error("expected unexpected query error")
+	}
+}
+
+func TestExpectQueryExact(t *testing.T) {
+	d := NewMock(nil, nil)
+	d.ExpectQuery("select id from users").WillReturnRows([]SimpleData{{1, "a"}})
+	if _, err := d.Query("select id from users_archive"); err == nil {
+		t.Error("expected a longer query not to match")
+	}
+	if _, err := d.Query("select id from users"); err != nil {
+		t.Error("expected exact match", err)
+	}
+
+	d = NewMock(nil, nil)
+	d.ExpectQuery("select count(*) from users").WillReturnRows([]SimpleData{{1, "a"}})
+	d.ExpectExecRegex(`^delete from (sessions|tokens)$`)
+	d.ExpectExecRegex(`^update users\b`).WithArgs(Any())
+	if _, err := d.Query("select  count(*)\nfrom users"); err != nil {
+		t.Error("expected query that isn't a valid expression to match exactly", err)
+	}
+	if _, err := d.Exec("delete from sessions_archive"); err == nil {
+		t.Error("expected anchored expression not to match")
+	}
+	if _, err := d.Exec("delete from tokens"); err != nil {
+		t.Error("expected regular expression match", err)
+	}
+	if _, err := d.Exec("update users set name = ?", "bob"); err != nil {
+		t.Error("expected regular expression match with args", err)
+	}
+	if err := d.ExpectationsWereMet(); err != nil {
+		t.Error("expected all expectations met", err)
+	}
+}
+
+func TestExpectQueryOrderAndArgs(t *testing.T) {
+	d := NewMock(nil, nil)
+	d.ExpectQuery("select a").WillReturnRows([]SimpleData{{1, "a"}})
+	d.ExpectQuery("select b").WithArgs(2, "x").WillReturnRows([]SimpleData{{2, "b"}})
+	if _, err := d.Query("select b", 2, "x"); err == nil || !strings.Contains(err.Error(), `query "select a"`) {
+		t.Error("expected out of order error", err)
+	}
+
+	d.MatchExpectationsInOrder(false)
+	if _, err := d.Query("select b", 2, "y"); err == nil {
+		t.Error("expected argument mismatch")
+	}
+	var row SimpleData
+	if err := d.QueryStructRow(&row, "select b", 2, "x"); err != nil || row.IntVal != 2 {
+		t.Error("expected unordered match", row, err)
+	}
+	if err := d.QueryStructRow(&row, "select a", "any", "args"); err != nil || row.IntVal != 1 {
+		t.Error("expected match without WithArgs", row, err)
+	}
+}
+
+func TestExpectErrorsAndExec(t *testing.T) {
+	d := NewMock(nil, errors.New("unused"))
+	d.ExpectQuery("select fail").WillReturnError(errors.New("fail"))
+	d.ExpectExec("delete from sessions").WillReturnResult(NewResult(0, 3))
+	d.ExpectExec("update users").WillReturnError(errors.New("locked"))
+
+	if _, err := d.QueryJSON("select fail"); err == nil || err.Error() != "fail" {
+		t.Error("expected query error", err)
+	}
+	result, err := d.Exec("delete from sessions")
+	if affected, _ := result.RowsAffected(); err != nil || affected != 3 {
+		t.Error("expected result", affected, err)
+	}
+	if _, err := d.Exec("update users"); err == nil || err.Error() != "locked" {
+		t.Error("expected exec error", err)
+	}
+	if err := d.ExpectationsWereMet(); err != nil {
+		t.Error("expected all expectations met", err)
+	}
+	d.VerifyNextCommand(t, "QueryJSON", "select fail")
+	d.VerifyNextCommand(t, "Query", "select fail")
+	d.VerifyNextCommand(t, "Exec", "delete from sessions")
+}
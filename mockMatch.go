@@ -0,0 +1,110 @@
+package onedb
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+)
+
+// Matcher is an expected argument that matches more than one value. Matchers can be passed to WithArgs,
+// VerifyNextCommand and AssertCalled in place of a value:
+//
+//	db.ExpectExecRegex("^insert into users").WithArgs(onedb.Any(), onedb.TypeOf(time.Time{}))
+//	db.AssertCalled(t, "Query", onedb.Regex("^select .* from users"))
+type Matcher interface {
+	Match(actual interface{}) bool
+	String() string
+}
+
+type anyMatcher struct{}
+
+// Any matches every argument, including nil
+func Any() Matcher {
+	return anyMatcher{}
+}
+
+func (anyMatcher) Match(actual interface{}) bool {
+	return true
+}
+
+func (anyMatcher) String() string {
+	return "Any()"
+}
+
+type regexMatcher struct {
+	pattern *regexp.Regexp
+}
+
+// Regex matches string and []byte arguments against a regular expression. It panics if pattern doesn't compile
+func Regex(pattern string) Matcher {
+	return regexMatcher{regexp.MustCompile(pattern)}
+}
+
+func (m regexMatcher) Match(actual interface{}) bool {
+	switch v := actual.(type) {
+	case string:
+		return m.pattern.MatchString(v)
+	case []byte:
+		return m.pattern.Match(v)
+	}
+	return false
+}
+
+func (m regexMatcher) String() string {
+	return fmt.Sprintf("Regex(%q)", m.pattern)
+}
+
+type typeMatcher struct {
+	valueType reflect.Type
+}
+
+// TypeOf matches arguments of the same type as example
+func TypeOf(example interface{}) Matcher {
+	return typeMatcher{reflect.TypeOf(example)}
+}
+
+func (m typeMatcher) Match(actual interface{}) bool {
+	return reflect.TypeOf(actual) == m.valueType
+}
+
+func (m typeMatcher) String() string {
+	return fmt.Sprintf("TypeOf(%v)", m.valueType)
+}
+
+type funcMatcher struct {
+	match       func(actual interface{}) bool
+	description string
+}
+
+// MatchFunc matches arguments for which match returns true. description is shown in failure messages
+func MatchFunc(description string, match func(actual interface{}) bool) Matcher {
+	return funcMatcher{match, description}
+}
+
+func (m funcMatcher) Match(actual interface{}) bool {
+	return m.match(actual)
+}
+
+func (m funcMatcher) String() string {
+	return m.description
+}
+
+// argMatches compares an argument with an expected value or Matcher
+func argMatches(expected, actual interface{}) bool {
+	if m, ok := expected.(Matcher); ok {
+		return m.Match(actual)
+	}
+	return reflect.DeepEqual(expected, actual)
+}
+
+func argsMatch(expected, actual []interface{}) bool {
+	if len(expected) != len(actual) {
+		return false
+	}
+	for i := range expected {
+		if !argMatches(expected[i], actual[i]) {
+			return false
+		}
+	}
+	return true
+}
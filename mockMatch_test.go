@@ -0,0 +1,121 @@
+package onedb
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type recordingTB struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Error(args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprint(args...))
+}
+
+func (r *recordingTB) Errorf(format string, args ...interface{}) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+func TestMatchers(t *testing.T) {
+	tests := []struct {
+		matcher  Matcher
+		actual   interface{}
+		expected bool
+	}{
+		{Any(), nil, true},
+		{Any(), 5, true},
+		{Regex("^sel"), "select 1", true},
+		{Regex("^sel"), []byte("select 1"), true},
+		{Regex("^sel"), "update", false},
+		{Regex("^sel"), 5, false},
+		{TypeOf(time.Time{}), time.Now(), true},
+		{TypeOf(""), 5, false},
+		{MatchFunc("positive", func(v interface{}) bool { i, ok := v.(int); return ok && i > 0 }), 5, true},
+		{MatchFunc("positive", func(v interface{}) bool { i, ok := v.(int); return ok && i > 0 }), -1, false},
+	}
+	for i, test := range tests {
+		if test.matcher.Match(test.actual) != test.expected {
+			t.Errorf("test %d: expected %s to return %t for %v", i, test.matcher, test.expected, test.actual)
+		}
+	}
+	if !argsMatch([]interface{}{"a", Any(), TypeOf(1)}, []interface{}{"a", nil, 2}) || argsMatch([]interface{}{"a"}, []interface{}{"b"}) {
+		t.Error("expected values and matchers to be compared")
+	}
+}
+
+func TestMockMatcherArgs(t *testing.T) {
+	d := NewMock(nil, nil)
+	d.ExpectExec("insert into users").WithArgs(Any(), TypeOf(time.Time{}))
+	if _, err := d.Exec("insert into users", "alice", time.Now()); err != nil {
+		t.Error("expected matched exec", err)
+	}
+	if _, err := d.Exec("insert into users", "bob", "today"); err == nil {
+		t.Error("expected argument mismatch")
+	}
+	d.VerifyNextCommand(t, "Exec", Regex("^insert"), "alice", TypeOf(time.Time{}))
+}
+
+func TestMockAssertCalled(t *testing.T) {
+	d := NewMock(nil, nil)
+	d.Exec("update users set name = ?", "alice")
+	d.Exec("delete from users where id = ?", 1)
+	d.AssertCalled(t, "Exec", "delete from users where id = ?", 1)
+	d.AssertCalled(t, "Exec", Regex("^update"), Any())
+	if len(d.QueriesRun()) != 2 {
+		t.Error("expected calls to be kept", d.QueriesRun())
+	}
+
+	tb := &recordingTB{}
+	d.AssertCalled(tb, "Exec", "delete from users where id = ?", 2)
+	d.AssertCalled(tb, "Query", Any())
+	if len(tb.errors) != 2 || !strings.Contains(tb.errors[0], "delete from users where id = ? 1") {
+		t.Error("expected assertion failures", tb.errors)
+	}
+	tb = &recordingTB{}
+	NewMock(nil, nil).VerifyNextCommand(tb, "Exec")
+	if len(tb.errors) != 1 {
+		t.Error("expected no methods error", tb.errors)
+	}
+}
+
+func TestMockConcurrent(t *testing.T) {
+	data := make([]interface{}, 50)
+	for i := range data {
+		data[i] = []SimpleData{{i, "row"}}
+	}
+	d := NewMock(nil, nil, data...)
+	e := NewMock(nil, nil)
+	e.MatchExpectationsInOrder(false)
+	for i := 0; i < 50; i++ {
+		e.ExpectExec("update users").WithArgs(i)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var rows []SimpleData
+			if err := d.QueryStruct(&rows, "select"); err != nil || len(rows) != 1 {
+				t.Error("expected rows", err)
+			}
+			if _, err := e.Exec("update users", i); err != nil {
+				t.Error("expected exec", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if err := e.ExpectationsWereMet(); err != nil {
+		t.Error(err)
+	}
+	if calls := d.QueriesRun(); len(calls) != 100 {
+		t.Error("expected every call recorded", len(calls))
+	}
+	d.AssertCalled(t, "QueryStruct", Any(), "select")
+}
@@ -1,6 +1,7 @@
 package onedb
 
 import (
+	"context"
 	"io"
 	"net"
 	"reflect"
@@ -11,64 +12,127 @@ import (
 
 // QueryValues runs a query against the provided Backender and populates result values
 func QueryValues(backend Backender, query *Query, result ...interface{}) error {
+	return QueryValuesContext(context.Background(), backend, query, result...)
+}
+
+// QueryValuesContext runs a query against the provided Backender and populates result values. The query is aborted if ctx is done
+func QueryValuesContext(ctx context.Context, backend Backender, query *Query, result ...interface{}) error {
 	if query == nil {
 		return ErrQueryIsNil
 	}
-	row := backend.QueryRow(query.Query, query.Args...)
+	row := queryRowContext(ctx, backend, query.Query, query.Args...)
 	return row.Scan(result...)
 }
 
 // QueryJSON runs a query against the provided Backender and returns the JSON result
 func QueryJSON(backend Backender, query string, args ...interface{}) (string, error) {
-	rows, err := backend.Query(query, args...)
+	return QueryJSONContext(context.Background(), backend, query, args...)
+}
+
+// QueryJSONContext runs a query against the provided Backender and returns the JSON result. The query is aborted if ctx is done
+func QueryJSONContext(ctx context.Context, backend Backender, query string, args ...interface{}) (string, error) {
+	return QueryJSONWithOptions(ctx, JSONOptions{}, backend, query, args...)
+}
+
+// QueryJSONWithOptions runs a query against the provided Backender and returns the JSON result rendered using the
+// specified options. The query is aborted if ctx is done
+func QueryJSONWithOptions(ctx context.Context, options JSONOptions, backend Backender, query string, args ...interface{}) (string, error) {
+	rows, err := queryContext(ctx, backend, query, args...)
 	if err != nil {
 		return "", err
 	}
 	defer rows.Close()
 
-	return getJSON(rows)
+	return getJSONWithOptions(rows, options)
 }
 
 // QueryJSONRow runs a query against the provided Backender and returns the JSON result
 func QueryJSONRow(backend Backender, query string, args ...interface{}) (string, error) {
-	rows, err := backend.Query(query, args...)
+	return QueryJSONRowContext(context.Background(), backend, query, args...)
+}
+
+// QueryJSONRowContext runs a query against the provided Backender and returns the JSON result. The query is aborted if ctx is done
+func QueryJSONRowContext(ctx context.Context, backend Backender, query string, args ...interface{}) (string, error) {
+	return QueryJSONRowWithOptions(ctx, JSONOptions{}, backend, query, args...)
+}
+
+// QueryJSONRowWithOptions runs a query against the provided Backender and returns the JSON result rendered using
+// the specified options. The query is aborted if ctx is done
+func QueryJSONRowWithOptions(ctx context.Context, options JSONOptions, backend Backender, query string, args ...interface{}) (string, error) {
+	rows, err := queryContext(ctx, backend, query, args...)
 	if err != nil {
 		return "", err
 	}
 	defer rows.Close()
 
-	return getJSONRow(rows)
+	return getJSONRowWithOptions(rows, options)
 }
 
-// QueryStruct runs a query against the provided Backender and populates the provided result
+// QueryStruct runs a query against the provided Backender and populates the provided result. A slice of structs field
+// tagged with a prefix, e.g. `db:"lines,prefix=line_"`, is filled from the prefixed columns of a joined query: rows with
+// the same parent key (fields tagged `db:"id,key"`, or all of the parent's columns) are merged and the children appended
 func QueryStruct(backend Backender, result interface{}, query string, args ...interface{}) error {
+	return QueryStructContext(context.Background(), backend, result, query, args...)
+}
+
+// QueryStructContext runs a query against the provided Backender and populates the provided result. The query is aborted if ctx is done
+func QueryStructContext(ctx context.Context, backend Backender, result interface{}, query string, args ...interface{}) error {
+	return QueryStructWithOptionsContext(ctx, StructOptions{}, backend, result, query, args...)
+}
+
+// QueryStructWithOptions runs a query against the provided Backender and populates the provided result using the
+// specified mapping options
+func QueryStructWithOptions(options StructOptions, backend Backender, result interface{}, query string, args ...interface{}) error {
+	return QueryStructWithOptionsContext(context.Background(), options, backend, result, query, args...)
+}
+
+// QueryStructWithOptionsContext runs a query against the provided Backender and populates the provided result using
+// the specified mapping options. The query is aborted if ctx is done
+func QueryStructWithOptionsContext(ctx context.Context, options StructOptions, backend Backender, result interface{}, query string, args ...interface{}) error {
 	resultType := reflect.TypeOf(result)
 	if !IsPointer(resultType) || !IsSlice(resultType.Elem()) {
 		return errors.New("Invalid result argument.  Must be a pointer to a slice")
 	}
 
-	rows, err := backend.Query(query, args...)
+	rows, err := queryContext(ctx, backend, query, args...)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
-	return getStruct(rows, result)
+	return getStructWithOptions(rows, result, options)
 }
 
 // QueryStructRow runs a query against the provided Backender and populates the provided result
 func QueryStructRow(backend Backender, result interface{}, query string, args ...interface{}) error {
+	return QueryStructRowContext(context.Background(), backend, result, query, args...)
+}
+
+// QueryStructRowContext runs a query against the provided Backender and populates the provided result. The query is aborted if ctx is done
+func QueryStructRowContext(ctx context.Context, backend Backender, result interface{}, query string, args ...interface{}) error {
+	return QueryStructRowWithOptionsContext(ctx, StructOptions{}, backend, result, query, args...)
+}
+
+// QueryStructRowWithOptions runs a query against the provided Backender and populates the provided result using the
+// specified mapping options
+func QueryStructRowWithOptions(options StructOptions, backend Backender, result interface{}, query string, args ...interface{}) error {
+	return QueryStructRowWithOptionsContext(context.Background(), options, backend, result, query, args...)
+}
+
+// QueryStructRowWithOptionsContext runs a query against the provided Backender and populates the provided result
+// using the specified mapping options. The query is aborted if ctx is done
+func QueryStructRowWithOptionsContext(ctx context.Context, options StructOptions, backend Backender, result interface{}, query string, args ...interface{}) error {
 	if !IsPointer(reflect.TypeOf(result)) {
 		return errors.New("Invalid result argument.  Must be a pointer to a struct")
 	}
 
-	rows, err := backend.Query(query, args...)
+	rows, err := queryContext(ctx, backend, query, args...)
 	if err != nil {
 		return err
 	}
 	defer rows.Close()
 
-	return getStructRow(rows, result)
+	return getStructRowWithOptions(rows, result, options)
 }
 
 // IsPointer is used to determine if a reflect.Type is a pointer
@@ -88,7 +152,13 @@ func IsStruct(item reflect.Type) bool {
 
 // QueryWriteCSV runs a query against the provided Backender and saves the response to the specified file in CSV format
 func QueryWriteCSV(w io.Writer, options CSVOptions, backend Backender, query string, args ...interface{}) error {
-	rows, err := backend.Query(query, args...)
+	return QueryWriteCSVContext(context.Background(), w, options, backend, query, args...)
+}
+
+// QueryWriteCSVContext runs a query against the provided Backender and saves the response to the specified file in CSV format.
+// The query is aborted if ctx is done
+func QueryWriteCSVContext(ctx context.Context, w io.Writer, options CSVOptions, backend Backender, query string, args ...interface{}) error {
+	rows, err := queryContext(ctx, backend, query, args...)
 	if err != nil {
 		return err
 	}
@@ -97,6 +167,23 @@ func QueryWriteCSV(w io.Writer, options CSVOptions, backend Backender, query str
 	return writeCSV(rows, w, options)
 }
 
+// QueryWriteJSON runs a query against the provided Backender and writes the JSON result to w as rows are scanned
+func QueryWriteJSON(w io.Writer, options JSONOptions, backend Backender, query string, args ...interface{}) error {
+	return QueryWriteJSONContext(context.Background(), w, options, backend, query, args...)
+}
+
+// QueryWriteJSONContext runs a query against the provided Backender and writes the JSON result to w as rows are scanned.
+// The query is aborted if ctx is done
+func QueryWriteJSONContext(ctx context.Context, w io.Writer, options JSONOptions, backend Backender, query string, args ...interface{}) error {
+	rows, err := queryContext(ctx, backend, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	return writeJSON(rows, w, options)
+}
+
 // Query is a generic struct that houses a query string and arguments used to construct a query
 type Query struct {
 	Query string
@@ -136,3 +223,90 @@ func DialTCP(network, addr string) (net.Conn, error) {
 	}
 	return tc, nil
 }
+
+// QueryWriteXLSX runs a query against the provided Backender and writes the result to w as an Excel workbook
+func QueryWriteXLSX(w io.Writer, options XLSXOptions, backend Backender, query string, args ...interface{}) error {
+	return QueryWriteXLSXContext(context.Background(), w, options, backend, query, args...)
+}
+
+// QueryWriteXLSXContext runs a query against the provided Backender and writes the result to w as an Excel workbook.
+// The query is aborted if ctx is done
+func QueryWriteXLSXContext(ctx context.Context, w io.Writer, options XLSXOptions, backend Backender, query string, args ...interface{}) error {
+	rows, err := queryContext(ctx, backend, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	return writeXLSX(rows, w, options)
+}
+
+// QueryWriteXML runs a query against the provided Backender and writes the result to w as XML
+func QueryWriteXML(w io.Writer, options XMLOptions, backend Backender, query string, args ...interface{}) error {
+	return QueryWriteXMLContext(context.Background(), w, options, backend, query, args...)
+}
+
+// QueryWriteXMLContext runs a query against the provided Backender and writes the result to w as XML.
+// The query is aborted if ctx is done
+func QueryWriteXMLContext(ctx context.Context, w io.Writer, options XMLOptions, backend Backender, query string, args ...interface{}) error {
+	rows, err := queryContext(ctx, backend, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	return writeXML(rows, w, options)
+}
+
+// QueryWriteHTMLTable runs a query against the provided Backender and writes the result to w as an HTML table
+func QueryWriteHTMLTable(w io.Writer, options HTMLTableOptions, backend Backender, query string, args ...interface{}) error {
+	return QueryWriteHTMLTableContext(context.Background(), w, options, backend, query, args...)
+}
+
+// QueryWriteHTMLTableContext runs a query against the provided Backender and writes the result to w as an HTML table.
+// The query is aborted if ctx is done
+func QueryWriteHTMLTableContext(ctx context.Context, w io.Writer, options HTMLTableOptions, backend Backender, query string, args ...interface{}) error {
+	rows, err := queryContext(ctx, backend, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	return writeHTMLTable(rows, w, options)
+}
+
+// QueryWriteParquet runs a query against the provided Backender and writes the result to w as a Parquet file,
+// one row group at a time as rows are scanned
+func QueryWriteParquet(w io.Writer, options ParquetOptions, backend Backender, query string, args ...interface{}) error {
+	return QueryWriteParquetContext(context.Background(), w, options, backend, query, args...)
+}
+
+// QueryWriteParquetContext runs a query against the provided Backender and writes the result to w as a Parquet file.
+// The query is aborted if ctx is done
+func QueryWriteParquetContext(ctx context.Context, w io.Writer, options ParquetOptions, backend Backender, query string, args ...interface{}) error {
+	rows, err := queryContext(ctx, backend, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	return writeParquet(rows, w, options)
+}
+
+// QueryWriteArrow runs a query against the provided Backender and writes the result to w as an Arrow IPC stream,
+// one record batch at a time as rows are scanned
+func QueryWriteArrow(w io.Writer, options ArrowOptions, backend Backender, query string, args ...interface{}) error {
+	return QueryWriteArrowContext(context.Background(), w, options, backend, query, args...)
+}
+
+// QueryWriteArrowContext runs a query against the provided Backender and writes the result to w as an Arrow IPC stream.
+// The query is aborted if ctx is done
+func QueryWriteArrowContext(ctx context.Context, w io.Writer, options ArrowOptions, backend Backender, query string, args ...interface{}) error {
+	rows, err := queryContext(ctx, backend, query, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	return writeArrow(rows, w, options)
+}
@@ -1,6 +1,8 @@
 package onedb
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"testing"
 )
@@ -43,6 +45,22 @@ func TestQueryJsonRow(t *testing.T) {
 	}
 }
 
+func TestQueryWriteJson(t *testing.T) {
+	rows := NewRowsScanner([]SimpleData{{1, "hello"}})
+	db := &mockBackend{Rows: rows}
+
+	var b bytes.Buffer
+	err := QueryWriteJSON(&b, JSONOptions{NewlineDelimited: true}, db, "select * from TestTable")
+	if err != nil || b.String() != "{\"IntVal\":1,\"StringVal\":\"hello\"}\n" {
+		t.Error("expected different json back.  Actual:", b.String(), err)
+	}
+
+	db = &mockBackend{QueryErr: errors.New("fail")}
+	if err = QueryWriteJSON(&b, JSONOptions{}, db, "select * from TestTable"); err == nil {
+		t.Error("expected error")
+	}
+}
+
 func TestQueryStruct(t *testing.T) {
 	rows := NewRowsScanner([]SimpleData{{1, "hello"}})
 	db := &mockBackend{Rows: rows}
@@ -93,6 +111,76 @@ func TestQueryStructRow(t *testing.T) {
 	}
 }
 
+func TestQueryStructContext(t *testing.T) {
+	rows := NewRowsScanner([]SimpleData{{1, "hello"}, {2, "world"}})
+	db := &mockBackend{Rows: rows}
+	data := []SimpleData{}
+
+	// success
+	ctx, cancel := context.WithCancel(context.Background())
+	err := QueryStructContext(ctx, db, &data, "query")
+	if err != nil || len(data) != 2 {
+		t.Error("expected success", err, data)
+	}
+
+	// cancelled before query
+	cancel()
+	err = QueryStructContext(ctx, db, &data, "query")
+	if err != context.Canceled {
+		t.Error("expected cancelled error", err)
+	}
+
+	// cancelled between rows
+	ctx, cancel = context.WithCancel(context.Background())
+	db = &mockBackend{Rows: &cancelRows{RowsScanner: NewRowsScanner([]SimpleData{{1, "hello"}, {2, "world"}}), cancel: cancel}}
+	data = []SimpleData{}
+	err = QueryStructContext(ctx, db, &data, "query")
+	if err != context.Canceled || len(data) != 1 {
+		t.Error("expected iteration to stop after cancel", err, data)
+	}
+}
+
+func TestQueryStructWithOptions(t *testing.T) {
+	type strictData struct {
+		IntVal int
+	}
+	db := &mockBackend{Rows: NewRowsScanner([]SimpleData{{1, "hello"}})}
+	var data []strictData
+	if err := QueryStructWithOptions(StructOptions{}, db, &data, "query"); err != nil || len(data) != 1 || data[0].IntVal != 1 {
+		t.Error("expected unmapped column to be ignored", err, data)
+	}
+	db = &mockBackend{Rows: NewRowsScanner([]SimpleData{{1, "hello"}})}
+	if err := QueryStructWithOptions(StructOptions{Strict: true}, db, &data, "query"); err == nil {
+		t.Error("expected strict mapping error")
+	}
+	var row strictData
+	db = &mockBackend{Rows: NewRowsScanner([]SimpleData{{1, "hello"}})}
+	if err := QueryStructRowWithOptions(StructOptions{Strict: true}, db, &row, "query"); err == nil {
+		t.Error("expected strict mapping error")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := QueryStructWithOptionsContext(ctx, StructOptions{}, db, &data, "query"); err != context.Canceled {
+		t.Error("expected cancelled error", err)
+	}
+	if err := QueryStructRowWithOptionsContext(ctx, StructOptions{}, db, &row, "query"); err != context.Canceled {
+		t.Error("expected cancelled error", err)
+	}
+}
+
+func TestQueryValuesContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	db := &mockBackend{Row: NewScanner(&SimpleData{1, "hello"})}
+	var intVal int
+	var stringVal string
+	err := QueryValuesContext(ctx, db, NewQuery("query"), &intVal, &stringVal)
+	if err != context.Canceled || intVal != 0 {
+		t.Error("expected cancelled error", err)
+	}
+}
+
 func TestNewQuery(t *testing.T) {
 	q := NewQuery("query", "arg1", "arg2")
 	if q == nil || q.Query != "query" || len(q.Args) != 2 || q.Args[0] != "arg1" || q.Args[1] != "arg2" {
@@ -117,3 +205,14 @@ func (b *mockBackend) QueryRow(query string, args ...interface{}) Scanner {
 	}
 	return b.Row
 }
+
+// cancelRows cancels its context after the first row is scanned
+type cancelRows struct {
+	RowsScanner
+	cancel context.CancelFunc
+}
+
+func (r *cancelRows) Scan(dest ...interface{}) error {
+	defer r.cancel()
+	return r.RowsScanner.Scan(dest...)
+}
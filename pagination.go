@@ -0,0 +1,289 @@
+package onedb
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidPageToken occurs when a page token is malformed or was issued for a different query
+var ErrInvalidPageToken = errors.New("invalid page token")
+
+// PageOptions describes how a base query is split into pages. The base query is wrapped in a derived table, so it
+// must not have its own ORDER BY or LIMIT clause
+type PageOptions struct {
+	Size    int          // rows per page. Defaults to 50
+	Token   string       // next page token returned with the previous page. Empty for the first page
+	OrderBy []PageColumn // columns the pages are ordered by
+	Keyset  bool         // continue after the OrderBy values of the last row instead of skipping rows with OFFSET
+}
+
+// PageColumn is a result column of the base query that pages are ordered by. Keyset pagination needs columns that are never NULL and that together
+// identify a row, e.g. created_at followed by id
+type PageColumn struct {
+	Name string
+	Desc bool
+}
+
+// QueryStructPage runs one page of query against the provided Backender and populates result, a pointer to a slice
+// of structs. The returned token fetches the following page and is empty on the last page
+func QueryStructPage(options PageOptions, backend Backender, result interface{}, query string, args ...interface{}) (string, error) {
+	return QueryStructPageContext(context.Background(), options, backend, result, query, args...)
+}
+
+// QueryStructPageContext runs one page of query against the provided Backender and populates result. The query is
+// aborted if ctx is done
+func QueryStructPageContext(ctx context.Context, options PageOptions, backend Backender, result interface{}, query string, args ...interface{}) (string, error) {
+	resultType := reflect.TypeOf(result)
+	if !IsPointer(resultType) || !IsSlice(resultType.Elem()) || !IsStruct(resultType.Elem().Elem()) {
+		return "", errors.New("result must be a pointer to a slice of structs")
+	}
+	var plan *scanPlan
+	sliceValue := reflect.ValueOf(result).Elem()
+	return queryPage(ctx, options, backend, query, args, func(rows RowsScanner, columns []string, vals []interface{}) error {
+		if plan == nil {
+			plan = getScanPlan(columns, resultType.Elem().Elem())
+		}
+		itemValue := reflect.New(plan.itemType)
+		if err := scanStruct(rows, vals, plan.fields, itemValue.Interface()); err != nil {
+			return err
+		}
+		sliceValue.Set(reflect.Append(sliceValue, itemValue.Elem()))
+		return nil
+	})
+}
+
+// QueryJSONPage runs one page of query against the provided Backender and returns the rows as a JSON array along
+// with the token of the following page, which is empty on the last page
+func QueryJSONPage(options PageOptions, backend Backender, query string, args ...interface{}) (string, string, error) {
+	return QueryJSONPageContext(context.Background(), options, backend, query, args...)
+}
+
+// QueryJSONPageContext runs one page of query against the provided Backender and returns the rows as a JSON array
+// along with the token of the following page. The query is aborted if ctx is done
+func QueryJSONPageContext(ctx context.Context, options PageOptions, backend Backender, query string, args ...interface{}) (string, string, error) {
+	var b bytes.Buffer
+	var keys []string
+	b.WriteByte('[')
+	token, err := queryPage(ctx, options, backend, query, args, func(rows RowsScanner, columns []string, vals []interface{}) error {
+		if keys == nil {
+			keys = getJSONKeys(columns, KeyCaseColumn)
+		}
+		return scanJSON(rows, keys, vals, b.Len() > 1, &b, JSONOptions{})
+	})
+	if err != nil {
+		return "", "", err
+	}
+	b.WriteByte(']')
+	return b.String(), token, nil
+}
+
+// queryPage runs the page query and calls scan for each row of the page. It reads one row past the page to find out
+// whether there is a next page
+func queryPage(ctx context.Context, options PageOptions, backend Backender, query string, args []interface{},
+	scan func(rows RowsScanner, columns []string, vals []interface{}) error) (string, error) {
+	options.Size = getPageSize(options.Size)
+	token, err := decodePageToken(options, query, args)
+	if err != nil {
+		return "", err
+	}
+	pageQuery, err := getPageQuery(getDialect(backend), options, token, query, args)
+	if err != nil {
+		return "", err
+	}
+	rows, err := queryContext(ctx, backend, pageQuery.Query, pageQuery.Args...)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close()
+
+	columns, vals, err := getColumnNamesAndValues(rows, false)
+	if err != nil {
+		return "", err
+	}
+	keyIndexes := make([]int, len(options.OrderBy))
+	for i, column := range options.OrderBy {
+		if keyIndexes[i] = indexOfColumn(columns, column.Name); keyIndexes[i] == -1 && options.Keyset {
+			return "", fmt.Errorf("onedb: keyset column %s not found in query results", column.Name)
+		}
+	}
+
+	count := 0
+	var lastKeys []interface{}
+	for rows.Next() {
+		if count == options.Size {
+			next := &pageToken{Hash: token.Hash, Offset: token.Offset + count}
+			if options.Keyset {
+				next = &pageToken{Hash: token.Hash, Keys: lastKeys}
+			}
+			return next.encode()
+		}
+		if err := scan(rows, columns, vals); err != nil {
+			return "", err
+		}
+		count++
+		if options.Keyset {
+			lastKeys = make([]interface{}, len(keyIndexes))
+			for i, index := range keyIndexes {
+				lastKeys[i] = *vals[index].(*interface{})
+			}
+		}
+	}
+	return "", rows.Err()
+}
+
+func getPageSize(size int) int {
+	if size <= 0 {
+		return 50
+	}
+	return size
+}
+
+// getPageQuery wraps query so that it returns one more row than a page, starting after the position in token
+func getPageQuery(dialect Dialect, options PageOptions, token *pageToken, query string, args []interface{}) (*Query, error) {
+	if options.Keyset && len(options.OrderBy) == 0 {
+		return nil, errors.New("onedb: keyset pagination needs OrderBy columns")
+	}
+	for _, column := range options.OrderBy {
+		if !isIdentifier(column.Name) {
+			return nil, fmt.Errorf("onedb: invalid order by column %q", column.Name)
+		}
+	}
+	if len(token.Keys) > 0 && len(token.Keys) != len(options.OrderBy) {
+		return nil, ErrInvalidPageToken
+	}
+
+	args = append([]interface{}{}, args...)
+	var b strings.Builder
+	b.WriteString("SELECT * FROM (" + strings.TrimRight(strings.TrimSpace(query), ";") + ") onedb_page")
+	if len(token.Keys) > 0 {
+		// (a > ?) OR (a = ? AND b < ?) works for mixed directions where a row value comparison doesn't
+		b.WriteString(" WHERE ")
+		for i, column := range options.OrderBy {
+			if i > 0 {
+				b.WriteString(" OR ")
+			}
+			b.WriteByte('(')
+			for j := 0; j < i; j++ {
+				args = append(args, token.Keys[j])
+				b.WriteString(options.OrderBy[j].Name + " = " + dialect.Placeholder(len(args)) + " AND ")
+			}
+			operator := " > "
+			if column.Desc {
+				operator = " < "
+			}
+			args = append(args, token.Keys[i])
+			b.WriteString(column.Name + operator + dialect.Placeholder(len(args)) + ")")
+		}
+	}
+
+	var orderBy []string
+	for _, column := range options.OrderBy {
+		if column.Desc {
+			orderBy = append(orderBy, column.Name+" DESC")
+		} else {
+			orderBy = append(orderBy, column.Name)
+		}
+	}
+	if len(orderBy) > 0 {
+		b.WriteString(" ORDER BY " + strings.Join(orderBy, ", "))
+	}
+
+	limit := options.Size + 1
+	if dialect == SQLServer {
+		if len(orderBy) == 0 {
+			b.WriteString(" ORDER BY (SELECT NULL)") // OFFSET ... FETCH requires an ORDER BY
+		}
+		fmt.Fprintf(&b, " OFFSET %d ROWS FETCH NEXT %d ROWS ONLY", token.Offset, limit)
+	} else {
+		fmt.Fprintf(&b, " LIMIT %d", limit)
+		if token.Offset > 0 {
+			fmt.Fprintf(&b, " OFFSET %d", token.Offset)
+		}
+	}
+	return &Query{Query: b.String(), Args: args}, nil
+}
+
+// pageToken is the position of the next page. It is serialized as base64 encoded JSON. Hash ties the token to the
+// query and order it was issued for
+type pageToken struct {
+	Hash   uint32        `json:"h"`
+	Offset int           `json:"o,omitempty"`
+	Keys   []interface{} `json:"-"`
+	Values []typedValue  `json:"k,omitempty"`
+}
+
+// getPageHash hashes everything that decides which rows a page holds, including the query args, so that a token
+// can't be replayed against a different filter
+func getPageHash(options PageOptions, query string, args []interface{}) (uint32, error) {
+	h := fnv.New32a()
+	h.Write([]byte(query))
+	for _, arg := range args {
+		value, err := newTypedValue(arg)
+		if err != nil {
+			return 0, err
+		}
+		if value == nil {
+			h.Write([]byte("\x00null"))
+		} else {
+			fmt.Fprintf(h, "\x00%s %s", value.Type, value.Value)
+		}
+	}
+	for _, column := range options.OrderBy {
+		fmt.Fprintf(h, "\x00%s %t", column.Name, column.Desc)
+	}
+	fmt.Fprintf(h, "\x00%t", options.Keyset)
+	return h.Sum32(), nil
+}
+
+func decodePageToken(options PageOptions, query string, args []interface{}) (*pageToken, error) {
+	hash, err := getPageHash(options, query, args)
+	if err != nil {
+		return nil, err
+	}
+	if options.Token == "" {
+		return &pageToken{Hash: hash}, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(options.Token)
+	if err != nil {
+		return nil, ErrInvalidPageToken
+	}
+	token := &pageToken{}
+	if err := json.Unmarshal(data, token); err != nil || token.Hash != hash || token.Offset < 0 {
+		return nil, ErrInvalidPageToken
+	}
+	for _, value := range token.Values {
+		key, err := value.decode()
+		if err != nil {
+			return nil, ErrInvalidPageToken
+		}
+		token.Keys = append(token.Keys, key)
+	}
+	return token, nil
+}
+
+func (t *pageToken) encode() (string, error) {
+	t.Values = nil
+	for _, key := range t.Keys {
+		value, err := newTypedValue(key)
+		if err != nil {
+			return "", err
+		}
+		if value == nil {
+			return "", errors.New("onedb: keyset column is NULL")
+		}
+		t.Values = append(t.Values, *value)
+	}
+	data, err := json.Marshal(t)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,192 @@
+package onedb
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type pageItem struct {
+	ID      int       `db:"id"`
+	Created time.Time `db:"created"`
+}
+
+type dialectMock struct {
+	Mocker
+	dialect Dialect
+}
+
+func (d *dialectMock) Dialect() Dialect {
+	return d.dialect
+}
+
+func TestGetPageQuery(t *testing.T) {
+	created := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	keyset := PageOptions{Size: 2, Keyset: true, OrderBy: []PageColumn{{Name: "created", Desc: true}, {Name: "id"}}}
+	offset := PageOptions{Size: 2, OrderBy: []PageColumn{{Name: "id"}}}
+	tests := []struct {
+		dialect  Dialect
+		options  PageOptions
+		token    *pageToken
+		query    string
+		args     []interface{}
+		expected string
+		expArgs  []interface{}
+	}{
+		{MySQL, offset, &pageToken{}, "select * from t where a = ?;", []interface{}{1},
+			"SELECT * FROM (select * from t where a = ?) onedb_page ORDER BY id LIMIT 3", []interface{}{1}},
+		{PostgreSQL, offset, &pageToken{Offset: 4}, "select * from t",
+			nil, "SELECT * FROM (select * from t) onedb_page ORDER BY id LIMIT 3 OFFSET 4", []interface{}{}},
+		{SQLServer, offset, &pageToken{Offset: 4}, "select * from t",
+			nil, "SELECT * FROM (select * from t) onedb_page ORDER BY id OFFSET 4 ROWS FETCH NEXT 3 ROWS ONLY", []interface{}{}},
+		{SQLServer, PageOptions{}, &pageToken{}, "select * from t",
+			nil, "SELECT * FROM (select * from t) onedb_page ORDER BY (SELECT NULL) OFFSET 0 ROWS FETCH NEXT 51 ROWS ONLY", []interface{}{}},
+		{MySQL, keyset, &pageToken{}, "select * from t", nil,
+			"SELECT * FROM (select * from t) onedb_page ORDER BY created DESC, id LIMIT 3", []interface{}{}},
+		{PostgreSQL, keyset, &pageToken{Keys: []interface{}{created, int64(7)}}, "select * from t where a = $1", []interface{}{1},
+			"SELECT * FROM (select * from t where a = $1) onedb_page WHERE (created < $2) OR (created = $3 AND id > $4) ORDER BY created DESC, id LIMIT 3",
+			[]interface{}{1, created, created, int64(7)}},
+		{SQLServer, keyset, &pageToken{Keys: []interface{}{created, int64(7)}}, "select * from t", nil,
+			"SELECT * FROM (select * from t) onedb_page WHERE (created < @p1) OR (created = @p2 AND id > @p3) ORDER BY created DESC, id OFFSET 0 ROWS FETCH NEXT 3 ROWS ONLY",
+			[]interface{}{created, created, int64(7)}},
+	}
+	for i, test := range tests {
+		test.options.Size = getPageSize(test.options.Size)
+		query, err := getPageQuery(test.dialect, test.options, test.token, test.query, test.args)
+		if err != nil || query.Query != test.expected || !reflect.DeepEqual(query.Args, test.expArgs) {
+			t.Errorf("test %d: expected %q %v. Actual: %+v %v", i, test.expected, test.expArgs, query, err)
+		}
+	}
+
+	if _, err := getPageQuery(MySQL, PageOptions{Keyset: true}, &pageToken{}, "select 1", nil); err == nil {
+		t.Error("expected missing order by error")
+	}
+	if _, err := getPageQuery(MySQL, PageOptions{OrderBy: []PageColumn{{Name: "id; drop table t"}}}, &pageToken{}, "select 1", nil); err == nil {
+		t.Error("expected invalid column error")
+	}
+	if _, err := getPageQuery(MySQL, keyset, &pageToken{Keys: []interface{}{1}}, "select 1", nil); err != ErrInvalidPageToken {
+		t.Error("expected invalid token error", err)
+	}
+}
+
+func TestQueryStructPageKeyset(t *testing.T) {
+	created := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	db := &dialectMock{NewMock(nil, nil,
+		[]pageItem{{3, created}, {2, created}, {1, created}},
+		[]pageItem{{1, created}}), PostgreSQL}
+	options := PageOptions{Size: 2, Keyset: true, OrderBy: []PageColumn{{Name: "created", Desc: true}, {Name: "id", Desc: true}}}
+
+	var page []pageItem
+	token, err := QueryStructPage(options, db, &page, "select id, created from items where owner = $1", "bob")
+	if err != nil || token == "" || len(page) != 2 || page[1].ID != 2 {
+		t.Fatal("expected first page", token, page, err)
+	}
+	db.VerifyNextCommand(t, "Query", "SELECT * FROM (select id, created from items where owner = $1) onedb_page ORDER BY created DESC, id DESC LIMIT 3", "bob")
+
+	options.Token = token
+	page = nil
+	token, err = QueryStructPage(options, db, &page, "select id, created from items where owner = $1", "bob")
+	if err != nil || token != "" || len(page) != 1 || page[0].ID != 1 {
+		t.Fatal("expected last page", token, page, err)
+	}
+	db.VerifyNextCommand(t, "Query", "SELECT * FROM (select id, created from items where owner = $1) onedb_page WHERE (created < $2) OR (created = $3 AND id < $4) ORDER BY created DESC, id DESC LIMIT 3",
+		"bob", created, created, int64(2))
+
+	// token belongs to another query
+	if _, err := QueryStructPage(options, db, &page, "select * from other"); err != ErrInvalidPageToken {
+		t.Error("expected invalid token", err)
+	}
+	options.Token = "not a token!"
+	if _, err := QueryStructPage(options, db, &page, "select id, created from items where owner = $1", "bob"); err != ErrInvalidPageToken {
+		t.Error("expected invalid token", err)
+	}
+
+	var notSlice pageItem
+	if _, err := QueryStructPage(PageOptions{}, db, &notSlice, "select 1"); err == nil {
+		t.Error("expected result type error")
+	}
+	options = PageOptions{Keyset: true, OrderBy: []PageColumn{{Name: "missing"}}}
+	db = &dialectMock{NewMock(nil, nil, []pageItem{{1, created}}), MySQL}
+	if _, err := QueryStructPage(options, db, &page, "select 1"); err == nil || !strings.Contains(err.Error(), "missing") {
+		t.Error("expected missing column error", err)
+	}
+}
+
+func TestQueryJSONPageOffset(t *testing.T) {
+	db := NewMock(nil, nil, []SimpleData{{1, "a"}, {2, "b"}, {3, "c"}}, []SimpleData{{3, "c"}})
+	options := PageOptions{Size: 2, OrderBy: []PageColumn{{Name: "IntVal"}}}
+	json, token, err := QueryJSONPageContext(context.Background(), options, db, "select * from t")
+	if err != nil || json != `[{"IntVal":1,"StringVal":"a"},{"IntVal":2,"StringVal":"b"}]` || token == "" {
+		t.Fatal("expected first page", json, token, err)
+	}
+
+	options.Token = token
+	json, token, err = QueryJSONPage(options, db, "select * from t")
+	if err != nil || json != `[{"IntVal":3,"StringVal":"c"}]` || token != "" {
+		t.Error("expected last page", json, token, err)
+	}
+	db.VerifyNextCommand(t, "Query", "SELECT * FROM (select * from t) onedb_page ORDER BY IntVal LIMIT 3")
+	db.VerifyNextCommand(t, "Query", "SELECT * FROM (select * from t) onedb_page ORDER BY IntVal LIMIT 3 OFFSET 2")
+
+	if _, _, err := QueryJSONPage(options, db, "select * from t"); err == nil {
+		t.Error("expected query error")
+	}
+}
+
+func TestPageToken(t *testing.T) {
+	created := time.Date(2020, 1, 2, 3, 4, 5, 6, time.FixedZone("EST", -5*3600))
+	options := PageOptions{Keyset: true, OrderBy: []PageColumn{{Name: "a"}}}
+	keys := []interface{}{int64(-1 << 62), uint64(1 << 63), 1.5, true, created, []byte{0, 1}, "text"}
+	for _, key := range keys {
+		hash, _ := getPageHash(options, "q", nil)
+		encoded, err := (&pageToken{Hash: hash, Keys: []interface{}{key}}).encode()
+		if err != nil {
+			t.Fatal("expected token", err)
+		}
+		options.Token = encoded
+		token, err := decodePageToken(options, "q", nil)
+		if err != nil || len(token.Keys) != 1 {
+			t.Fatal("expected key", key, err)
+		}
+		if decoded, ok := token.Keys[0].(time.Time); ok && !decoded.Equal(created) || !ok && !reflect.DeepEqual(token.Keys[0], key) {
+			t.Errorf("expected %v. Actual: %v", key, token.Keys[0])
+		}
+	}
+	if _, err := (&pageToken{Keys: []interface{}{nil}}).encode(); err == nil {
+		t.Error("expected NULL key error")
+	}
+	if _, err := (typedValue{"other", "1"}).decode(); err == nil {
+		t.Error("expected unknown type error")
+	}
+}
+
+func TestPageHashArgs(t *testing.T) {
+	options := PageOptions{OrderBy: []PageColumn{{Name: "a"}}}
+	bob, _ := getPageHash(options, "q", []interface{}{"bob"})
+	sue, _ := getPageHash(options, "q", []interface{}{"sue"})
+	none, _ := getPageHash(options, "q", []interface{}{nil})
+	number, _ := getPageHash(options, "q", []interface{}{1})
+	text, _ := getPageHash(options, "q", []interface{}{"1"})
+	if bob == sue || bob == none || number == text {
+		t.Error("expected args to change the hash", bob, sue, none, number, text)
+	}
+	if again, _ := getPageHash(options, "q", []interface{}{"bob"}); again != bob {
+		t.Error("expected the same args to give the same hash", again, bob)
+	}
+	if _, err := getPageHash(options, "q", []interface{}{struct{}{}}); err == nil {
+		t.Error("expected unsupported arg error")
+	}
+
+	db := NewMock(nil, nil, []SimpleData{{1, "a"}, {2, "b"}, {3, "c"}})
+	options.Size = 2
+	_, token, err := QueryJSONPage(options, db, "select * from t where owner = ?", "bob")
+	if err != nil || token == "" {
+		t.Fatal("expected first page", token, err)
+	}
+	options.Token = token
+	if _, _, err := QueryJSONPage(options, db, "select * from t where owner = ?", "sue"); err != ErrInvalidPageToken {
+		t.Error("expected token to be rejected for different args", err)
+	}
+}
@@ -1,6 +1,9 @@
 package pgx
 
-import "gopkg.in/jackc/pgx.v2"
+import (
+	"github.com/EndFirstCorp/onedb"
+	"gopkg.in/jackc/pgx.v2"
+)
 
 // Identifier a PostgreSQL identifier or name. Identifiers can be composed of
 // multiple parts such as ["schema", "table"] or ["table", "column"].
@@ -15,9 +18,20 @@ func CopyFromRows(rows [][]interface{}) CopyFromSource {
 	return &copyFromRows{rows: rows, idx: -1}
 }
 
-// CommandTag is the result of an Exec function
+// CommandTag is the result of an Exec function. It implements onedb.Result
 type CommandTag pgx.CommandTag
 
+// RowsAffected returns the number of rows affected. If the CommandTag was not
+// for a row affecting command (such as "CREATE TABLE") then it returns 0
+func (ct CommandTag) RowsAffected() (int64, error) {
+	return pgx.CommandTag(ct).RowsAffected(), nil
+}
+
+// LastInsertId is not supported by PostgreSQL. Use an INSERT ... RETURNING query instead
+func (ct CommandTag) LastInsertId() (int64, error) {
+	return 0, onedb.ErrLastInsertIDNotSupported
+}
+
 type copyFromRows struct {
 	rows [][]interface{}
 	idx  int
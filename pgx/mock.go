@@ -1,6 +1,7 @@
 package pgx
 
 import (
+	"context"
 	"io"
 	"testing"
 
@@ -14,12 +15,14 @@ type mockBackend struct {
 	PGXer
 }
 
-// Mocker is the interface for mocking and includes all of the PGXer interface plus 3 methods to make testing easier
+// Mocker is the interface for mocking and includes all of the PGXer interface plus methods to make testing easier
 type Mocker interface {
 	PGXer
 	QueriesRun() []onedb.MethodsRun
 	SaveMethodCall(name string, arguments []interface{})
-	VerifyNextCommand(t *testing.T, name string, expected ...interface{})
+	VerifyNextCommand(t testing.TB, name string, expected ...interface{})
+	AssertCalled(t testing.TB, name string, expected ...interface{})
+	onedb.Expecter
 }
 
 // NewMock returns a Mock PGX instance from a set of parameters
@@ -30,9 +33,12 @@ func NewMock(copyFromErr, execErr error, data ...interface{}) Mocker {
 func (b *mockBackend) Close() {
 	b.SaveMethodCall("Close", []interface{}{})
 }
-func (b *mockBackend) Exec(query string, args ...interface{}) (CommandTag, error) {
+func (b *mockBackend) Dialect() onedb.Dialect {
+	return onedb.PostgreSQL
+}
+func (b *mockBackend) Exec(query string, args ...interface{}) (onedb.Result, error) {
 	b.SaveMethodCall("Exec", append([]interface{}{query}, args...))
-	return "", b.ExecErr
+	return b.execResult(query, args)
 }
 func (b *mockBackend) Query(query string, args ...interface{}) (onedb.RowsScanner, error) {
 	return b.db.Query(query, args...)
@@ -40,6 +46,30 @@ func (b *mockBackend) Query(query string, args ...interface{}) (onedb.RowsScanne
 func (b *mockBackend) QueryRow(query string, args ...interface{}) onedb.Scanner {
 	return b.db.QueryRow(query, args...)
 }
+func (b *mockBackend) ExecContext(ctx context.Context, query string, args ...interface{}) (onedb.Result, error) {
+	b.SaveMethodCall("ExecContext", append([]interface{}{query}, args...))
+	if err := ctx.Err(); err != nil {
+		return CommandTag(""), err
+	}
+	return b.execResult(query, args)
+}
+
+func (b *mockBackend) execResult(query string, args []interface{}) (onedb.Result, error) {
+	e, err := b.db.MatchExec(query, args...)
+	if err != nil {
+		return CommandTag(""), err
+	}
+	if e != nil {
+		return e.Result(), e.Err()
+	}
+	return CommandTag(""), b.ExecErr
+}
+func (b *mockBackend) QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error) {
+	return b.db.QueryContext(ctx, query, args...)
+}
+func (b *mockBackend) QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner {
+	return b.db.QueryRowContext(ctx, query, args...)
+}
 func (b *mockBackend) CopyFrom(tableName Identifier, columnNames []string, rowSrc CopyFromSource) (int, error) {
 	b.SaveMethodCall("CopyFrom", []interface{}{tableName, columnNames, rowSrc})
 	return 0, b.CopyFromErr
@@ -62,12 +92,59 @@ func (b *mockBackend) QueryStructRow(result interface{}, query string, args ...i
 func (b *mockBackend) QueryWriteCSV(w io.Writer, options onedb.CSVOptions, query string, args ...interface{}) error {
 	return onedb.QueryWriteCSV(w, options, b, query, args...)
 }
+func (b *mockBackend) QueryValuesContext(ctx context.Context, query *onedb.Query, result ...interface{}) error {
+	return onedb.QueryValuesContext(ctx, b, query, result...)
+}
+func (b *mockBackend) QueryJSONContext(ctx context.Context, query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSONContext(ctx, b, query, args...)
+}
+func (b *mockBackend) QueryJSONRowContext(ctx context.Context, query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSONRowContext(ctx, b, query, args...)
+}
+func (b *mockBackend) QueryStructContext(ctx context.Context, result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStructContext(ctx, b, result, query, args...)
+}
+func (b *mockBackend) QueryStructRowContext(ctx context.Context, result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStructRowContext(ctx, b, result, query, args...)
+}
+func (b *mockBackend) QueryWriteCSVContext(ctx context.Context, w io.Writer, options onedb.CSVOptions, query string, args ...interface{}) error {
+	return onedb.QueryWriteCSVContext(ctx, w, options, b, query, args...)
+}
 func (b *mockBackend) QueriesRun() []onedb.MethodsRun {
 	return b.db.QueriesRun()
 }
 func (b *mockBackend) SaveMethodCall(name string, arguments []interface{}) {
 	b.db.SaveMethodCall(name, arguments)
 }
-func (b *mockBackend) VerifyNextCommand(t *testing.T, name string, expected ...interface{}) {
+func (b *mockBackend) VerifyNextCommand(t testing.TB, name string, expected ...interface{}) {
+	t.Helper()
 	b.db.VerifyNextCommand(t, name, expected...)
 }
+func (b *mockBackend) AssertCalled(t testing.TB, name string, expected ...interface{}) {
+	t.Helper()
+	b.db.AssertCalled(t, name, expected...)
+}
+func (b *mockBackend) ExpectQuery(query string) *onedb.Expectation {
+	return b.db.ExpectQuery(query)
+}
+func (b *mockBackend) ExpectQueryRegex(pattern string) *onedb.Expectation {
+	return b.db.ExpectQueryRegex(pattern)
+}
+func (b *mockBackend) ExpectExec(query string) *onedb.Expectation {
+	return b.db.ExpectExec(query)
+}
+func (b *mockBackend) ExpectExecRegex(pattern string) *onedb.Expectation {
+	return b.db.ExpectExecRegex(pattern)
+}
+func (b *mockBackend) MatchExpectationsInOrder(ordered bool) {
+	b.db.MatchExpectationsInOrder(ordered)
+}
+func (b *mockBackend) ExpectationsWereMet() error {
+	return b.db.ExpectationsWereMet()
+}
+func (b *mockBackend) MatchQuery(query string, args ...interface{}) (*onedb.Expectation, error) {
+	return b.db.MatchQuery(query, args...)
+}
+func (b *mockBackend) MatchExec(query string, args ...interface{}) (*onedb.Expectation, error) {
+	return b.db.MatchExec(query, args...)
+}
@@ -1,7 +1,9 @@
 package pgx
 
 import (
+	"context"
 	"io"
+	"strings"
 
 	"github.com/EndFirstCorp/onedb"
 	pgx "gopkg.in/jackc/pgx.v2"
@@ -38,7 +40,7 @@ func newPgx(connConfig *pgx.ConnConfig) (PGXer, error) {
 		return nil, err
 	}
 
-	return &pgxBackend{db: &pgxWithReconnect{db: pgxDb}}, nil
+	return &pgxBackend{db: &pgxWithReconnect{db: pgxConnPool{pgxDb}}}, nil
 }
 
 func (b *pgxBackend) Begin() (Txer, error) {
@@ -49,7 +51,7 @@ func (b *pgxBackend) Close() {
 	b.db.Close()
 }
 
-func (b *pgxBackend) Exec(query string, args ...interface{}) (CommandTag, error) {
+func (b *pgxBackend) Exec(query string, args ...interface{}) (onedb.Result, error) {
 	return b.db.Exec(query, args...)
 }
 
@@ -61,6 +63,18 @@ func (b *pgxBackend) QueryRow(query string, args ...interface{}) onedb.Scanner {
 	return b.db.QueryRow(query, args...)
 }
 
+func (b *pgxBackend) ExecContext(ctx context.Context, query string, args ...interface{}) (onedb.Result, error) {
+	return b.db.ExecContext(ctx, query, args...)
+}
+
+func (b *pgxBackend) QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error) {
+	return b.db.QueryContext(ctx, query, args...)
+}
+
+func (b *pgxBackend) QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner {
+	return b.db.QueryRowContext(ctx, query, args...)
+}
+
 func (b *pgxBackend) CopyFrom(tableName Identifier, columnNames []string, rowSrc CopyFromSource) (int, error) {
 	return b.db.CopyFrom(tableName, columnNames, rowSrc)
 }
@@ -89,6 +103,30 @@ func (b *pgxBackend) QueryWriteCSV(w io.Writer, options onedb.CSVOptions, query
 	return onedb.QueryWriteCSV(w, options, b, query, args...)
 }
 
+func (b *pgxBackend) QueryValuesContext(ctx context.Context, query *onedb.Query, result ...interface{}) error {
+	return onedb.QueryValuesContext(ctx, b, query, result...)
+}
+
+func (b *pgxBackend) QueryJSONContext(ctx context.Context, query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSONContext(ctx, b, query, args...)
+}
+
+func (b *pgxBackend) QueryJSONRowContext(ctx context.Context, query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSONRowContext(ctx, b, query, args...)
+}
+
+func (b *pgxBackend) QueryStructContext(ctx context.Context, result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStructContext(ctx, b, result, query, args...)
+}
+
+func (b *pgxBackend) QueryStructRowContext(ctx context.Context, result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStructRowContext(ctx, b, result, query, args...)
+}
+
+func (b *pgxBackend) QueryWriteCSVContext(ctx context.Context, w io.Writer, options onedb.CSVOptions, query string, args ...interface{}) error {
+	return onedb.QueryWriteCSVContext(ctx, w, options, b, query, args...)
+}
+
 type pgxTx struct {
 	tx *pgx.Tx
 	Txer
@@ -139,11 +177,34 @@ func (t *pgxTx) Query(query string, args ...interface{}) (onedb.RowsScanner, err
 	return &pgxRows{rows: rows}, rows.Err()
 }
 
-func (t *pgxTx) Exec(query string, args ...interface{}) (CommandTag, error) {
+func (t *pgxTx) Exec(query string, args ...interface{}) (onedb.Result, error) {
 	tag, err := t.tx.Exec(query, args...)
 	return CommandTag(tag), err
 }
 
+// QueryContext checks ctx before running the query. Statements run inside a transaction can't be cancelled
+// independently, so cancellation mid-query requires rolling back the transaction
+func (t *pgxTx) QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return t.Query(query, args...)
+}
+
+func (t *pgxTx) QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner {
+	if err := ctx.Err(); err != nil {
+		return &errorRow{err}
+	}
+	return t.QueryRow(query, args...)
+}
+
+func (t *pgxTx) ExecContext(ctx context.Context, query string, args ...interface{}) (onedb.Result, error) {
+	if err := ctx.Err(); err != nil {
+		return CommandTag(""), err
+	}
+	return t.Exec(query, args...)
+}
+
 func (t *pgxTx) QueryValues(query *onedb.Query, result ...interface{}) error {
 	return onedb.QueryValues(t, query, result...)
 }
@@ -167,3 +228,53 @@ func (t *pgxTx) QueryStructRow(result interface{}, query string, args ...interfa
 func (t *pgxTx) QueryWriteCSV(w io.Writer, options onedb.CSVOptions, query string, args ...interface{}) error {
 	return onedb.QueryWriteCSV(w, options, t, query, args...)
 }
+
+func (t *pgxTx) QueryValuesContext(ctx context.Context, query *onedb.Query, result ...interface{}) error {
+	return onedb.QueryValuesContext(ctx, t, query, result...)
+}
+
+func (t *pgxTx) QueryJSONContext(ctx context.Context, query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSONContext(ctx, t, query, args...)
+}
+
+func (t *pgxTx) QueryJSONRowContext(ctx context.Context, query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSONRowContext(ctx, t, query, args...)
+}
+
+func (t *pgxTx) QueryStructContext(ctx context.Context, result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStructContext(ctx, t, result, query, args...)
+}
+
+func (t *pgxTx) QueryStructRowContext(ctx context.Context, result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStructRowContext(ctx, t, result, query, args...)
+}
+
+func (t *pgxTx) QueryWriteCSVContext(ctx context.Context, w io.Writer, options onedb.CSVOptions, query string, args ...interface{}) error {
+	return onedb.QueryWriteCSVContext(ctx, w, options, t, query, args...)
+}
+
+// Dialect returns PostgreSQL so that generated statements use $1 style placeholders
+func (b *pgxBackend) Dialect() onedb.Dialect {
+	return onedb.PostgreSQL
+}
+
+// Dialect returns PostgreSQL so that generated statements use $1 style placeholders
+func (t *pgxTx) Dialect() onedb.Dialect {
+	return onedb.PostgreSQL
+}
+
+// CopyFromer is implemented by PGXer and Txer
+type CopyFromer interface {
+	CopyFrom(tableName Identifier, columnNames []string, rowSrc CopyFromSource) (int, error)
+}
+
+// ImportCSV loads the rows of a CSV file with a header row into table using the COPY protocol. table may be
+// schema qualified. pgx encodes values using the column types of the table, so use options.Parse to convert
+// text fields for columns that aren't text. It returns the number of rows loaded
+func ImportCSV(db CopyFromer, table string, r io.Reader, options onedb.CSVImportOptions) (int, error) {
+	source, err := onedb.NewCSVSource(r, options)
+	if err != nil {
+		return 0, err
+	}
+	return db.CopyFrom(Identifier(strings.Split(table, ".")), source.Columns(), source)
+}
@@ -1,8 +1,11 @@
 package pgx
 
 import (
+	"context"
 	"math"
+	"runtime"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/EndFirstCorp/onedb"
@@ -17,10 +20,13 @@ type pgxWrapper interface {
 }
 
 type querier interface {
-	Exec(query string, args ...interface{}) (CommandTag, error)
+	Exec(query string, args ...interface{}) (onedb.Result, error)
 	Query(query string, args ...interface{}) (onedb.RowsScanner, error)
 	QueryRow(query string, args ...interface{}) onedb.Scanner
 	CopyFrom(tableName Identifier, columnNames []string, rowSrc CopyFromSource) (int, error)
+	ExecContext(ctx context.Context, query string, args ...interface{}) (onedb.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner
 }
 
 // Rower is the public interface for all the capability found in a *pgx.Rows. Note that the Close method
@@ -51,8 +57,64 @@ type pgxRower interface {
 	Values() ([]interface{}, error)
 }
 
+// connPool is the part of *pgx.ConnPool used by pgxWithReconnect
+type connPool interface {
+	Acquire() (pooledConn, error)
+	Release(conn pooledConn)
+	Begin() (*pgx.Tx, error)
+	Close()
+	CopyFrom(tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int, error)
+	Exec(sql string, arguments ...interface{}) (pgx.CommandTag, error)
+	Query(sql string, args ...interface{}) (*pgx.Rows, error)
+	QueryRow(sql string, args ...interface{}) onedb.Scanner
+}
+
+// pooledConn is a connection acquired from a connPool
+type pooledConn interface {
+	Exec(sql string, arguments ...interface{}) (pgx.CommandTag, error)
+	Query(sql string, args ...interface{}) (pgxRower, error)
+	pid() int32
+}
+
+type pgxConnPool struct {
+	*pgx.ConnPool
+}
+
+func (p pgxConnPool) Acquire() (pooledConn, error) {
+	conn, err := p.ConnPool.Acquire()
+	if err != nil {
+		return nil, err
+	}
+	return pgxConn{conn}, nil
+}
+
+func (p pgxConnPool) Release(conn pooledConn) {
+	p.ConnPool.Release(conn.(pgxConn).Conn)
+}
+
+func (p pgxConnPool) QueryRow(sql string, args ...interface{}) onedb.Scanner {
+	return p.ConnPool.QueryRow(sql, args...)
+}
+
+type pgxConn struct {
+	*pgx.Conn
+}
+
+func (c pgxConn) Query(sql string, args ...interface{}) (pgxRower, error) {
+	rows, err := c.Conn.Query(sql, args...)
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+func (c pgxConn) pid() int32 {
+	return c.Pid
+}
+
 type pgxWithReconnect struct {
-	db         *pgx.ConnPool
+	db         connPool
+	mu         sync.Mutex
 	lastRetry  time.Time
 	retryCount int
 	pgxWrapper
@@ -105,7 +167,7 @@ func (b *pgxWithReconnect) QueryRow(query string, args ...interface{}) onedb.Sca
 
 func (b *pgxWithReconnect) Query(query string, args ...interface{}) (onedb.RowsScanner, error) {
 	rows, err := b.db.Query(query, args...)
-	if (err == pgx.ErrDeadConn || err != nil && strings.HasSuffix(err.Error(), "connection reset by peer")) && b.reconnect() {
+	if isDeadConn(err) && b.reconnect() {
 		return b.Query(query)
 	} else if err != nil {
 		return nil, err
@@ -113,14 +175,123 @@ func (b *pgxWithReconnect) Query(query string, args ...interface{}) (onedb.RowsS
 	return &pgxRows{rows: rows}, rows.Err()
 }
 
-func (b *pgxWithReconnect) Exec(query string, args ...interface{}) (CommandTag, error) {
+func (b *pgxWithReconnect) Exec(query string, args ...interface{}) (onedb.Result, error) {
 	tag, err := b.db.Exec(query, args...)
-	if (err == pgx.ErrDeadConn || err != nil && strings.HasSuffix(err.Error(), "connection reset by peer")) && b.reconnect() {
+	if isDeadConn(err) && b.reconnect() {
 		return b.Exec(query, args...)
 	}
 	return CommandTag(tag), err
 }
 
+// QueryContext runs the query on a dedicated pool connection so that the statement can be cancelled if ctx is done
+// before the rows are closed. A dead connection is retried after reconnecting unless ctx is done
+func (b *pgxWithReconnect) QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error) {
+	conn, err := b.acquire(ctx)
+	if err != nil {
+		return nil, err
+	}
+	release := b.cancelOnDone(ctx, conn)
+	rows, err := conn.Query(query, args...)
+	if err != nil {
+		release()
+		if b.retryContext(ctx, err) {
+			return b.QueryContext(ctx, query, args...)
+		}
+		return nil, contextError(ctx, err)
+	}
+	rows.AfterClose(func(*pgx.Rows) { release() })
+	return &pgxRows{rows: rows, ctx: ctx}, rows.Err()
+}
+
+// QueryRowContext holds the pool connection until Scan reads the first row, as pgx's QueryRow does. A row that is
+// never scanned releases its connection when it is garbage collected
+func (b *pgxWithReconnect) QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner {
+	conn, err := b.acquire(ctx)
+	if err != nil {
+		return &errorRow{err}
+	}
+	release := b.cancelOnDone(ctx, conn)
+	rows, err := conn.Query(query, args...)
+	if err != nil {
+		release()
+		if b.retryContext(ctx, err) {
+			return b.QueryRowContext(ctx, query, args...)
+		}
+		return &errorRow{contextError(ctx, err)}
+	}
+	rows.AfterClose(func(*pgx.Rows) { release() })
+	row := &pgxRow{rows: rows, ctx: ctx}
+	runtime.SetFinalizer(row, func(row *pgxRow) { row.rows.Close() })
+	return row
+}
+
+func (b *pgxWithReconnect) ExecContext(ctx context.Context, query string, args ...interface{}) (onedb.Result, error) {
+	conn, err := b.acquire(ctx)
+	if err != nil {
+		return CommandTag(""), err
+	}
+	release := b.cancelOnDone(ctx, conn)
+	tag, err := conn.Exec(query, args...)
+	release()
+	if err != nil {
+		if b.retryContext(ctx, err) {
+			return b.ExecContext(ctx, query, args...)
+		}
+		return CommandTag(""), contextError(ctx, err)
+	}
+	return CommandTag(tag), nil
+}
+
+// retryContext reports whether a statement that failed with err should run again. It reconnects only when the
+// connection died and ctx isn't done, since a cancelled statement also breaks the connection
+func (b *pgxWithReconnect) retryContext(ctx context.Context, err error) bool {
+	return isDeadConn(err) && ctx.Err() == nil && b.reconnect()
+}
+
+func isDeadConn(err error) bool {
+	return err == pgx.ErrDeadConn || err != nil && strings.HasSuffix(err.Error(), "connection reset by peer")
+}
+
+func (b *pgxWithReconnect) acquire(ctx context.Context) (pooledConn, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return b.db.Acquire()
+}
+
+// cancelOnDone sends a cancel request for the statement running on conn if ctx is done before the returned
+// release function is called. pgx v2 has no native context support so the request is sent through another
+// pool connection. Release waits for any pending cancel request before returning conn to the pool so that
+// a late cancel can't abort a statement belonging to the connection's next user.
+func (b *pgxWithReconnect) cancelOnDone(ctx context.Context, conn pooledConn) func() {
+	stop := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		select {
+		case <-ctx.Done():
+			b.db.Exec("select pg_cancel_backend($1)", conn.pid())
+		case <-stop:
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(stop)
+			<-stopped
+			b.db.Release(conn)
+		})
+	}
+}
+
+// contextError returns the context's error when the query failed because ctx was done
+func contextError(ctx context.Context, err error) error {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		return ctxErr
+	}
+	return err
+}
+
 func (b *pgxWithReconnect) ping() error {
 	var val int
 	if err := b.db.QueryRow("select 1 + 1").Scan(&val); err != nil {
@@ -133,6 +304,8 @@ func (b *pgxWithReconnect) ping() error {
 }
 
 func (b *pgxWithReconnect) reconnect() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
 	ms := time.Millisecond * time.Duration(math.Pow10(b.retryCount)) // retry every 10^lastRetry milliseconds
 	if time.Since(b.lastRetry) > ms {
 		b.lastRetry = time.Now()
@@ -149,6 +322,7 @@ func (b *pgxWithReconnect) reconnect() bool {
 
 type pgxRows struct {
 	rows pgxRower
+	ctx  context.Context
 	Rower
 }
 
@@ -165,6 +339,20 @@ func (r *pgxRows) Columns() ([]string, error) {
 	return columns, nil
 }
 
+// DescribeColumns reports the PostgreSQL type of each column so that columnar writers don't need to infer it
+func (r *pgxRows) DescribeColumns() ([]onedb.ColumnType, error) {
+	fields := r.rows.FieldDescriptions()
+	types := make([]onedb.ColumnType, len(fields))
+	for i, field := range fields {
+		types[i] = onedb.ColumnType{Name: field.Name, DatabaseType: field.DataTypeName}
+		if field.DataTypeName == "numeric" && field.Modifier >= 4 { // typmod is ((precision << 16) | scale) + 4
+			types[i].Precision = int64((field.Modifier - 4) >> 16 & 0xffff)
+			types[i].Scale = int64((field.Modifier - 4) & 0xffff)
+		}
+	}
+	return types, nil
+}
+
 // Next prepares the next row for reading. It returns true if there is another
 // row and false if no more rows are available. It automatically closes rows
 // when all rows are read.
@@ -228,5 +416,46 @@ func (r *pgxRows) Values() ([]interface{}, error) {
 }
 
 func (r *pgxRows) Err() error {
-	return r.rows.Err()
+	err := r.rows.Err()
+	if err != nil && r.ctx != nil {
+		return contextError(r.ctx, err)
+	}
+	return err
+}
+
+type pgxRow struct {
+	rows pgxRower
+	ctx  context.Context
+}
+
+// Scan reads the first row with pgx's Scan, the same way pgx's Row does, and closes the rows to release the
+// connection
+func (r *pgxRow) Scan(dest ...interface{}) error {
+	runtime.SetFinalizer(r, nil)
+	defer r.rows.Close()
+	if err := r.rows.Err(); err != nil {
+		return contextError(r.ctx, err)
+	}
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return contextError(r.ctx, err)
+		}
+		return pgx.ErrNoRows
+	}
+	if err := r.rows.Scan(dest...); err != nil {
+		return contextError(r.ctx, err)
+	}
+	r.rows.Close()
+	if err := r.rows.Err(); err != nil {
+		return contextError(r.ctx, err)
+	}
+	return nil
+}
+
+type errorRow struct {
+	err error
+}
+
+func (r *errorRow) Scan(dest ...interface{}) error {
+	return r.err
 }
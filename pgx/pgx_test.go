@@ -1,8 +1,10 @@
 package pgx
 
 import (
+	"context"
 	"errors"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/EndFirstCorp/onedb"
@@ -55,6 +57,27 @@ func TestPgxQuery(t *testing.T) {
 	verifyArgs(t, queries[0], "query", "arg1", "arg2")
 }
 
+func TestPgxQueryContext(t *testing.T) {
+	c := newMockPgx([]SimpleData{{1, "hello"}}, nil)
+	d := &pgxBackend{db: c}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var result []SimpleData
+	if err := d.QueryStructContext(ctx, &result, "query", "arg1"); err != nil || len(result) != 1 {
+		t.Fatal("expected success", err, result)
+	}
+	queries := c.MethodsCalled["QueryContext"]
+	if len(queries) != 1 {
+		t.Fatal("expected QueryContext method to be called on backend")
+	}
+	verifyArgs(t, queries[0], "query", "arg1")
+
+	cancel()
+	if err := d.QueryStructContext(ctx, &result, "query"); err != context.Canceled {
+		t.Error("expected cancelled error", err)
+	}
+}
+
 func verifyArgs(t *testing.T, actual []interface{}, expected ...interface{}) {
 	if len(expected) != len(actual) {
 		t.Fatal("Number of arguments don't match. Expected:", len(expected), expected, "actual:", len(actual), actual)
@@ -89,16 +112,80 @@ func TestPgxCopyFrom(t *testing.T) {
 	}
 }
 
+func TestPgxImportCSV(t *testing.T) {
+	c := newMockPgx(nil, nil)
+	d := &pgxBackend{db: c}
+
+	_, err := ImportCSV(d, "public.users", strings.NewReader("Name,Skip\nbob,x\n"), onedb.CSVImportOptions{Columns: map[string]string{"Name": "user_name"}, Skip: []string{"Skip"}})
+	queries := c.MethodsCalled["CopyFrom"]
+	if err != nil || len(queries) != 1 || !reflect.DeepEqual(queries[0][0], Identifier{"public", "users"}) || !reflect.DeepEqual(queries[0][1], []string{"user_name"}) {
+		t.Fatal("expected CopyFrom to be called with mapped columns", queries, err)
+	}
+
+	if _, err = ImportCSV(d, "users", strings.NewReader(""), onedb.CSVImportOptions{}); err == nil {
+		t.Error("expected missing header error")
+	}
+}
+
 func TestPgxExec(t *testing.T) {
 	c := newMockPgx(nil, nil)
 	d := &pgxBackend{db: c}
 
-	d.Exec("query", "arg1", "arg2")
+	result, _ := d.Exec("query", "arg1", "arg2")
 	queries := c.MethodsCalled["Exec"]
 	if len(c.MethodsCalled) != 1 || len(queries) != 1 {
 		t.Fatal("expected Exec method to be called on backend")
 	}
 	verifyArgs(t, queries[0], "query", "arg1", "arg2")
+	if affected, err := result.RowsAffected(); affected != 2 || err != nil {
+		t.Error("expected rows affected from command tag", affected, err)
+	}
+	if _, err := result.LastInsertId(); err != onedb.ErrLastInsertIDNotSupported {
+		t.Error("expected LastInsertId to be unsupported", err)
+	}
+}
+
+func TestPgxContextReconnect(t *testing.T) {
+	rows := newMockPgxRows()
+	rows.HasRow = true
+	pool := &mockConnPool{conns: []pooledConn{&mockConn{err: ErrDeadConn}, &mockConn{rows: rows}}}
+	b := &pgxWithReconnect{db: pool}
+	row := b.QueryRowContext(context.Background(), "select 5")
+	if len(pool.released) != 1 || len(pool.conns) != 0 {
+		t.Fatal("expected the dead connection to be released and the query retried", len(pool.released))
+	}
+	var n int
+	if err := row.Scan(&n); err != nil || len(rows.MethodsCalled["Scan"]) != 1 || len(rows.MethodsCalled["Close"]) == 0 {
+		t.Error("expected QueryRowContext to scan the first row with pgx and close the rows", err, rows.MethodsCalled)
+	}
+	if err := b.QueryRowContext(context.Background(), "select").Scan(&n); err == nil {
+		t.Error("expected error without connections")
+	}
+
+	pool = &mockConnPool{conns: []pooledConn{&mockConn{err: errors.New("read tcp: connection reset by peer")}, &mockConn{}}}
+	b = &pgxWithReconnect{db: pool}
+	if _, err := b.ExecContext(context.Background(), "update"); err != nil || len(pool.released) != 2 {
+		t.Error("expected ExecContext to retry on a new connection", err)
+	}
+
+	pool = &mockConnPool{conns: []pooledConn{&mockConn{err: ErrDeadConn}, &mockConn{rows: newMockPgxRows()}}}
+	b = &pgxWithReconnect{db: pool}
+	if _, err := b.QueryContext(context.Background(), "select"); err != nil || len(pool.conns) != 0 {
+		t.Error("expected QueryContext to retry on a new connection", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	pool = &mockConnPool{conns: []pooledConn{&mockConn{err: ErrDeadConn, cancel: cancel}, &mockConn{}}}
+	b = &pgxWithReconnect{db: pool}
+	if _, err := b.ExecContext(ctx, "update"); err != context.Canceled || len(pool.conns) != 1 || pool.pings != 0 {
+		t.Error("expected no retry once the context is done", err)
+	}
+
+	pool = &mockConnPool{conns: []pooledConn{&mockConn{err: ErrDeadConn}}, pingErr: ErrDeadConn}
+	b = &pgxWithReconnect{db: pool}
+	if err := b.QueryRowContext(context.Background(), "select").Scan(&n); err != ErrDeadConn || len(pool.released) != 1 {
+		t.Error("expected dead connection error when reconnecting fails", err)
+	}
 }
 
 func TestPgxQueryValues(t *testing.T) {
@@ -190,6 +277,14 @@ func TestPgxRowsColumns(t *testing.T) {
 	}
 }
 
+func TestPgxRowsDescribeColumns(t *testing.T) {
+	r := &pgxRows{rows: newMockPgxRows()}
+	types, err := r.DescribeColumns()
+	if err != nil || len(types) != 2 || types[0].DatabaseType != "int4" || types[1].Precision != 10 || types[1].Scale != 2 {
+		t.Error("expected column types", types, err)
+	}
+}
+
 func TestPgxRowsNext(t *testing.T) {
 	m := newMockPgxRows()
 	r := &pgxRows{rows: m}
@@ -274,9 +369,9 @@ func (c *mockPgx) Begin() (Txer, error) {
 func (c *mockPgx) Close() {
 	c.MethodsCalled["Close"] = append(c.MethodsCalled["Close"], nil)
 }
-func (c *mockPgx) Exec(query string, args ...interface{}) (CommandTag, error) {
+func (c *mockPgx) Exec(query string, args ...interface{}) (onedb.Result, error) {
 	c.MethodsCalled["Exec"] = append(c.MethodsCalled["Exec"], append([]interface{}{query}, args...))
-	return "tag", nil
+	return CommandTag("UPDATE 2"), nil
 }
 func (c *mockPgx) Query(query string, args ...interface{}) (onedb.RowsScanner, error) {
 	c.MethodsCalled["Query"] = append(c.MethodsCalled["Query"], append([]interface{}{query}, args...))
@@ -293,8 +388,86 @@ func (c *mockPgx) CopyFrom(tableName Identifier, columnNames []string, rows Copy
 	return 0, nil
 }
 
+func (c *mockPgx) ExecContext(ctx context.Context, query string, args ...interface{}) (onedb.Result, error) {
+	c.MethodsCalled["ExecContext"] = append(c.MethodsCalled["ExecContext"], append([]interface{}{query}, args...))
+	return CommandTag("UPDATE 2"), ctx.Err()
+}
+func (c *mockPgx) QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error) {
+	c.MethodsCalled["QueryContext"] = append(c.MethodsCalled["QueryContext"], append([]interface{}{query}, args...))
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return c.QueryReturn, nil
+}
+func (c *mockPgx) QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner {
+	c.MethodsCalled["QueryRowContext"] = append(c.MethodsCalled["QueryRowContext"], append([]interface{}{query}, args...))
+	return c.QueryRowReturn
+}
+
+type mockConnPool struct {
+	conns    []pooledConn
+	released []pooledConn
+	pings    int
+	pingErr  error
+}
+
+func (p *mockConnPool) Acquire() (pooledConn, error) {
+	if len(p.conns) == 0 {
+		return nil, errors.New("no connections")
+	}
+	conn := p.conns[0]
+	p.conns = p.conns[1:]
+	return conn, nil
+}
+func (p *mockConnPool) Release(conn pooledConn) {
+	p.released = append(p.released, conn)
+}
+func (p *mockConnPool) Begin() (*pgx.Tx, error) {
+	return nil, nil
+}
+func (p *mockConnPool) Close() {}
+func (p *mockConnPool) CopyFrom(tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int, error) {
+	return 0, nil
+}
+func (p *mockConnPool) Exec(sql string, arguments ...interface{}) (pgx.CommandTag, error) {
+	return "", nil
+}
+func (p *mockConnPool) Query(sql string, args ...interface{}) (*pgx.Rows, error) {
+	return nil, nil
+}
+func (p *mockConnPool) QueryRow(sql string, args ...interface{}) onedb.Scanner {
+	p.pings++
+	if p.pingErr != nil {
+		return &errorRow{p.pingErr}
+	}
+	return onedb.NewScanner(&struct{ Value int }{2})
+}
+
+type mockConn struct {
+	rows   pgxRower
+	err    error
+	cancel context.CancelFunc
+}
+
+func (c *mockConn) Exec(sql string, arguments ...interface{}) (pgx.CommandTag, error) {
+	if c.cancel != nil {
+		c.cancel()
+	}
+	return "UPDATE 1", c.err
+}
+func (c *mockConn) Query(sql string, args ...interface{}) (pgxRower, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	return c.rows, nil
+}
+func (c *mockConn) pid() int32 {
+	return 1
+}
+
 type mockPgxRows struct {
 	MethodsCalled map[string][]interface{}
+	HasRow        bool
 	ValuesData    []interface{}
 	ValuesErr     error
 	ScanErr       error
@@ -322,12 +495,13 @@ func (r *mockPgxRows) Fatal(err error) {
 	r.MethodsCalled["Fatal"] = append(r.MethodsCalled["Fatal"], err)
 }
 func (r *mockPgxRows) Next() bool {
+	next := r.HasRow && len(r.MethodsCalled["Next"]) == 0
 	r.MethodsCalled["Next"] = append(r.MethodsCalled["Next"], nil)
-	return false
+	return next
 }
 func (r *mockPgxRows) FieldDescriptions() []pgx.FieldDescription {
 	r.MethodsCalled["FieldDescriptions"] = append(r.MethodsCalled["FieldDescriptions"], nil)
-	return []pgx.FieldDescription{{Name: "F1"}, {Name: "F2"}}
+	return []pgx.FieldDescription{{Name: "F1", DataTypeName: "int4"}, {Name: "F2", DataTypeName: "numeric", Modifier: 10<<16 | 2 + 4}}
 }
 func (r *mockPgxRows) Values() ([]interface{}, error) {
 	r.MethodsCalled["Values"] = append(r.MethodsCalled["Values"], nil)
@@ -342,3 +516,24 @@ type SimpleData struct {
 	IntVal    int
 	StringVal string
 }
+
+func TestMockExpectations(t *testing.T) {
+	m := NewMock(nil, nil)
+	m.ExpectQuery("select id from users").WillReturnRows([]struct{ ID int }{{5}})
+	m.ExpectExec("delete from users where id = $1").WithArgs(5).WillReturnResult(CommandTag("DELETE 1"))
+
+	var id int
+	if err := m.QueryRow("select id from users").Scan(&id); err != nil || id != 5 {
+		t.Error("expected id", id, err)
+	}
+	result, err := m.Exec("delete from users where id = $1", 5)
+	if affected, _ := result.RowsAffected(); err != nil || affected != 1 {
+		t.Error("expected result", affected, err)
+	}
+	if _, err := m.Exec("delete from users"); err == nil {
+		t.Error("expected unexpected exec error")
+	}
+	if err := m.ExpectationsWereMet(); err != nil {
+		t.Error("expected all expectations met", err)
+	}
+}
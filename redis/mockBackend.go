@@ -1,6 +1,7 @@
 package redis
 
 import (
+	"context"
 	"errors"
 	"reflect"
 	"testing"
@@ -17,17 +18,21 @@ type redisMock struct {
 	Rediser
 }
 
-// Mocker interface includes all the Rediser interface, plus three additional methods to help with testing
+// Mocker interface includes all the Rediser interface, plus additional methods to help with testing. Expectations
+// match the command and arguments passed to Do and return the WillReturnRows value as the reply
 type Mocker interface {
 	Rediser
 	QueriesRun() []onedb.MethodsRun
 	SaveMethodCall(name string, arguments []interface{})
-	VerifyNextCommand(t *testing.T, name string, expected ...interface{})
+	VerifyNextCommand(t testing.TB, name string, expected ...interface{})
+	AssertCalled(t testing.TB, name string, expected ...interface{})
+	onedb.Expecter
 }
 
-// NewMock is the constructor for a fake Redis connection
+// NewMock is the constructor for a fake Redis connection. Del and SetWithExpire return delErr and saveErr, and Do
+// returns doResult and doErr while no expectations are set
 func NewMock(delErr, saveErr error, doResult interface{}, doErr error) Mocker {
-	return &redisMock{db: onedb.NewMock(nil, nil, doResult)}
+	return &redisMock{db: onedb.NewMock(nil, nil, doResult), DoResult: doResult, DoErr: doErr, DelErr: delErr, SetErr: saveErr}
 }
 
 func (r *redisMock) Close() error {
@@ -64,6 +69,58 @@ func (r *redisMock) Del(key string) error {
 
 func (r *redisMock) Do(command string, args ...interface{}) (interface{}, error) {
 	r.db.SaveMethodCall("Do", append([]interface{}{command}, args...))
+	return r.doResult(command, args)
+}
+
+func (r *redisMock) GetContext(ctx context.Context, key string) (string, error) {
+	return r.db.QueryJSONContext(ctx, key)
+}
+
+func (r *redisMock) GetStructContext(ctx context.Context, key string, result interface{}) error {
+	resultType := reflect.TypeOf(result)
+	if !onedb.IsPointer(resultType) {
+		return errors.New("invalid result type")
+	}
+	if onedb.IsSlice(resultType.Elem()) {
+		return r.db.QueryStructContext(ctx, result, key)
+	} else if onedb.IsStruct(resultType.Elem()) {
+		return r.db.QueryStructRowContext(ctx, result, key)
+	}
+	return errors.New("invalid result type")
+}
+
+func (r *redisMock) SetWithExpireContext(ctx context.Context, key string, value interface{}, expireSeconds int) error {
+	r.db.SaveMethodCall("SetWithExpireContext", []interface{}{value, expireSeconds})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.SetErr
+}
+
+func (r *redisMock) DelContext(ctx context.Context, key string) error {
+	r.db.SaveMethodCall("DelContext", []interface{}{key})
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	return r.DelErr
+}
+
+func (r *redisMock) DoContext(ctx context.Context, command string, args ...interface{}) (interface{}, error) {
+	r.db.SaveMethodCall("DoContext", append([]interface{}{command}, args...))
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return r.doResult(command, args)
+}
+
+func (r *redisMock) doResult(command string, args []interface{}) (interface{}, error) {
+	e, err := r.db.MatchQuery(command, args...)
+	if err != nil {
+		return nil, err
+	}
+	if e != nil {
+		return e.Rows(), e.Err()
+	}
 	return r.DoResult, r.DoErr
 }
 
@@ -73,6 +130,35 @@ func (r *redisMock) QueriesRun() []onedb.MethodsRun {
 func (r *redisMock) SaveMethodCall(name string, arguments []interface{}) {
 	r.db.SaveMethodCall(name, arguments)
 }
-func (r *redisMock) VerifyNextCommand(t *testing.T, name string, expected ...interface{}) {
+func (r *redisMock) VerifyNextCommand(t testing.TB, name string, expected ...interface{}) {
+	t.Helper()
 	r.db.VerifyNextCommand(t, name, expected...)
 }
+func (r *redisMock) AssertCalled(t testing.TB, name string, expected ...interface{}) {
+	t.Helper()
+	r.db.AssertCalled(t, name, expected...)
+}
+func (r *redisMock) ExpectQuery(query string) *onedb.Expectation {
+	return r.db.ExpectQuery(query)
+}
+func (r *redisMock) ExpectQueryRegex(pattern string) *onedb.Expectation {
+	return r.db.ExpectQueryRegex(pattern)
+}
+func (r *redisMock) ExpectExec(query string) *onedb.Expectation {
+	return r.db.ExpectExec(query)
+}
+func (r *redisMock) ExpectExecRegex(pattern string) *onedb.Expectation {
+	return r.db.ExpectExecRegex(pattern)
+}
+func (r *redisMock) MatchExpectationsInOrder(ordered bool) {
+	r.db.MatchExpectationsInOrder(ordered)
+}
+func (r *redisMock) ExpectationsWereMet() error {
+	return r.db.ExpectationsWereMet()
+}
+func (r *redisMock) MatchQuery(query string, args ...interface{}) (*onedb.Expectation, error) {
+	return r.db.MatchQuery(query, args...)
+}
+func (r *redisMock) MatchExec(query string, args ...interface{}) (*onedb.Expectation, error) {
+	return r.db.MatchExec(query, args...)
+}
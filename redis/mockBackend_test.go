@@ -0,0 +1,76 @@
+package redis
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestMockReturnsConstructorErrors(t *testing.T) {
+	delErr, saveErr, doErr := errors.New("del"), errors.New("save"), errors.New("do")
+	r := NewMock(delErr, saveErr, "reply", doErr)
+	if err := r.Del("key"); err != delErr {
+		t.Error("expected delErr", err)
+	}
+	if err := r.SetWithExpire("key", "value", 10); err != saveErr {
+		t.Error("expected saveErr", err)
+	}
+	if reply, err := r.Do("GET", "key"); reply != "reply" || err != doErr {
+		t.Error("expected doResult and doErr", reply, err)
+	}
+	r.VerifyNextCommand(t, "Del", "key")
+	r.VerifyNextCommand(t, "SetWithExpire", "value", 10)
+	r.VerifyNextCommand(t, "Do", "GET", "key")
+
+	ctx := context.Background()
+	if err := r.DelContext(ctx, "key"); err != delErr {
+		t.Error("expected delErr", err)
+	}
+	if err := r.SetWithExpireContext(ctx, "key", "value", 10); err != saveErr {
+		t.Error("expected saveErr", err)
+	}
+	if reply, err := r.DoContext(ctx, "GET", "key"); reply != "reply" || err != doErr {
+		t.Error("expected doResult and doErr", reply, err)
+	}
+
+	r = NewMock(nil, nil, nil, nil)
+	if r.Del("key") != nil || r.SetWithExpire("key", "value", 10) != nil {
+		t.Error("expected no errors")
+	}
+	if reply, err := r.Do("PING"); reply != nil || err != nil {
+		t.Error("expected no reply", reply, err)
+	}
+}
+
+func TestMockExpectations(t *testing.T) {
+	r := NewMock(nil, nil, "default", nil)
+	r.ExpectQuery("GET").WithArgs("key").WillReturnRows("value")
+	r.ExpectQuery("INCR").WillReturnError(errors.New("fail"))
+	if reply, err := r.Do("GET", "key"); reply != "value" || err != nil {
+		t.Error("expected expectation reply", reply, err)
+	}
+	if _, err := r.Do("INCR", "counter"); err == nil || err.Error() != "fail" {
+		t.Error("expected expectation error", err)
+	}
+	if err := r.ExpectationsWereMet(); err != nil {
+		t.Error("expected all expectations to be met", err)
+	}
+	if _, err := r.Do("GET", "other"); err == nil {
+		t.Error("expected unexpected command error once expectations are set")
+	}
+}
+
+func TestMockCanceledContext(t *testing.T) {
+	r := NewMock(nil, nil, "reply", nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := r.DelContext(ctx, "key"); err != context.Canceled {
+		t.Error("expected canceled", err)
+	}
+	if err := r.SetWithExpireContext(ctx, "key", "value", 10); err != context.Canceled {
+		t.Error("expected canceled", err)
+	}
+	if _, err := r.DoContext(ctx, "GET", "key"); err != context.Canceled {
+		t.Error("expected canceled", err)
+	}
+}
@@ -1,6 +1,7 @@
 package redis
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -54,11 +55,18 @@ type Rediser interface {
 	Get(key string) (string, error)
 	GetStruct(key string, result interface{}) error
 	SetWithExpire(key string, value interface{}, expireSeconds int) error
+
+	DelContext(ctx context.Context, key string) error
+	DoContext(ctx context.Context, command string, args ...interface{}) (interface{}, error)
+	GetContext(ctx context.Context, key string) (string, error)
+	GetStructContext(ctx context.Context, key string, result interface{}) error
+	SetWithExpireContext(ctx context.Context, key string, value interface{}, expireSeconds int) error
 }
 
 type pooler interface {
 	Close() error
 	Get() redis.Conn
+	GetContext(ctx context.Context) (redis.Conn, error)
 }
 
 type redisBackend struct {
@@ -75,11 +83,19 @@ func (r *redisBackend) Close() error {
 }
 
 func (r *redisBackend) Get(key string) (string, error) {
-	return redis.String(r.Do("GET", key))
+	return r.GetContext(context.Background(), key)
+}
+
+func (r *redisBackend) GetContext(ctx context.Context, key string) (string, error) {
+	return redis.String(r.DoContext(ctx, "GET", key))
 }
 
 func (r *redisBackend) GetStruct(key string, result interface{}) error {
-	data, err := redis.Bytes(r.Do("GET", key))
+	return r.GetStructContext(context.Background(), key, result)
+}
+
+func (r *redisBackend) GetStructContext(ctx context.Context, key string, result interface{}) error {
+	data, err := redis.Bytes(r.DoContext(ctx, "GET", key))
 	if err != nil {
 		return err
 	}
@@ -87,16 +103,24 @@ func (r *redisBackend) GetStruct(key string, result interface{}) error {
 }
 
 func (r *redisBackend) SetWithExpire(key string, value interface{}, expireSeconds int) error {
+	return r.SetWithExpireContext(context.Background(), key, value, expireSeconds)
+}
+
+func (r *redisBackend) SetWithExpireContext(ctx context.Context, key string, value interface{}, expireSeconds int) error {
 	data, err := json.Marshal(value)
 	if err != nil {
 		return err
 	}
-	_, err = r.Do("SETEX", key, expireSeconds, string(data))
+	_, err = r.DoContext(ctx, "SETEX", key, expireSeconds, string(data))
 	return err
 }
 
 func (r *redisBackend) Del(key string) error {
-	_, err := r.Do("DEL", key)
+	return r.DelContext(context.Background(), key)
+}
+
+func (r *redisBackend) DelContext(ctx context.Context, key string) error {
+	_, err := r.DoContext(ctx, "DEL", key)
 	return err
 }
 
@@ -106,3 +130,41 @@ func (r *redisBackend) Do(command string, args ...interface{}) (interface{}, err
 
 	return c.Do(command, args...)
 }
+
+type doResult struct {
+	reply interface{}
+	err   error
+}
+
+// DoContext waits for a pooled connection until ctx is done and uses the context deadline as the read timeout.
+// If ctx is cancelled while the command is running, DoContext returns immediately and the connection is
+// returned to the pool once the reply arrives
+func (r *redisBackend) DoContext(ctx context.Context, command string, args ...interface{}) (interface{}, error) {
+	if ctx.Done() == nil {
+		return r.Do(command, args...)
+	}
+	c, err := r.pool.GetContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var timeout time.Duration
+	if deadline, ok := ctx.Deadline(); ok {
+		if timeout = time.Until(deadline); timeout <= 0 {
+			c.Close()
+			return nil, context.DeadlineExceeded
+		}
+	}
+
+	done := make(chan doResult, 1)
+	go func() {
+		defer c.Close()
+		reply, err := redis.DoWithTimeout(c, timeout, command, args...)
+		done <- doResult{reply, err}
+	}()
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-done:
+		return res.reply, res.err
+	}
+}
@@ -0,0 +1,305 @@
+package onedb
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// recording is the JSON fixture written by a Recorder and served by a Replayer
+type recording struct {
+	Dialect string          `json:"dialect"`
+	Queries []recordedQuery `json:"queries"`
+}
+
+// recordedQuery is one query with its arguments and the rows or error it returned. NULL values are JSON nulls
+type recordedQuery struct {
+	Query   string          `json:"query"`
+	Args    []*typedValue   `json:"args,omitempty"`
+	Columns []string        `json:"columns,omitempty"`
+	Rows    [][]*typedValue `json:"rows,omitempty"`
+	Error   string          `json:"error,omitempty"`
+	used    bool
+}
+
+// Recorder wraps a real backend and records each query, its arguments, columns and rows so that they can be saved
+// as a fixture and served by a Replayer in tests that run without a database:
+//
+//	recorder := onedb.NewRecorder(db)
+//	err := onedb.QueryStruct(recorder, &users, "select id, name from users where active = $1", true)
+//	...
+//	err = recorder.Save("testdata/users.json")
+//
+// Rows are read completely before they are returned to the caller
+type Recorder struct {
+	backend Backender
+	mu      sync.Mutex
+	queries []recordedQuery
+}
+
+// NewRecorder returns a Recorder that runs its queries against backend
+func NewRecorder(backend Backender) *Recorder {
+	return &Recorder{backend: backend}
+}
+
+// Dialect returns the Dialect of the recorded backend
+func (r *Recorder) Dialect() Dialect {
+	return getDialect(r.backend)
+}
+
+// Query runs query against the recorded backend and records the result
+func (r *Recorder) Query(query string, args ...interface{}) (RowsScanner, error) {
+	return r.QueryContext(context.Background(), query, args...)
+}
+
+// QueryRow runs query against the recorded backend and records the result. Only the first row is scanned
+func (r *Recorder) QueryRow(query string, args ...interface{}) Scanner {
+	return r.QueryRowContext(context.Background(), query, args...)
+}
+
+// QueryContext runs query against the recorded backend and records the result. The query is aborted if ctx is done
+func (r *Recorder) QueryContext(ctx context.Context, query string, args ...interface{}) (RowsScanner, error) {
+	recorded := recordedQuery{Query: query}
+	for _, arg := range args {
+		value, err := newTypedValue(arg)
+		if err != nil {
+			return nil, err
+		}
+		recorded.Args = append(recorded.Args, value)
+	}
+	rows, err := r.readRows(ctx, query, args)
+	if err == nil {
+		if recorded.Rows, err = encodeRows(rows); err != nil {
+			return nil, err // values that can't be replayed aren't recorded
+		}
+		recorded.Columns = rows.columns
+	} else {
+		recorded.Error = err.Error()
+	}
+	r.mu.Lock()
+	r.queries = append(r.queries, recorded)
+	r.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	return rows, nil
+}
+
+// QueryRowContext runs query against the recorded backend and records the result. The query is aborted if ctx is done
+func (r *Recorder) QueryRowContext(ctx context.Context, query string, args ...interface{}) Scanner {
+	rows, err := r.QueryContext(ctx, query, args...)
+	return &firstRowScanner{rows, err}
+}
+
+func (r *Recorder) readRows(ctx context.Context, query string, args []interface{}) (*Rows, error) {
+	rows, err := queryContext(ctx, r.backend, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	columns, vals, err := getColumnNamesAndValues(rows, false)
+	if err != nil {
+		return nil, err
+	}
+	result := NewRows(columns...)
+	for rows.Next() {
+		if err := rows.Scan(vals...); err != nil {
+			return nil, err
+		}
+		row := make([]interface{}, len(vals))
+		for i := range vals {
+			row[i] = *vals[i].(*interface{})
+		}
+		result.AddRow(row...)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// encodeRows converts the values of rows into their fixture form
+func encodeRows(rows *Rows) ([][]*typedValue, error) {
+	var fixtures [][]*typedValue
+	for _, row := range rows.rows {
+		fixture := make([]*typedValue, len(row))
+		for i, value := range row {
+			var err error
+			if fixture[i], err = newTypedValue(value); err != nil {
+				return nil, fmt.Errorf("onedb: column %s: %v", rows.columns[i], err)
+			}
+		}
+		fixtures = append(fixtures, fixture)
+	}
+	return fixtures, nil
+}
+
+// Save writes the recorded queries to a JSON fixture file
+func (r *Recorder) Save(path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := r.Write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Write writes the recorded queries as JSON to w
+func (r *Recorder) Write(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(recording{Dialect: r.Dialect().String(), Queries: r.queries})
+}
+
+// Replayer is a Backender that serves the queries saved by a Recorder. A query is answered by the first unused
+// recording with the same query text and arguments, or by the last matching recording once they have all been used
+type Replayer struct {
+	dialect Dialect
+	mu      sync.Mutex
+	queries []recordedQuery
+}
+
+// NewReplayer loads the fixture file saved by Recorder.Save
+func NewReplayer(path string) (*Replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadReplayer(f)
+}
+
+// ReadReplayer loads the fixture written by Recorder.Write
+func ReadReplayer(r io.Reader) (*Replayer, error) {
+	fixture := recording{}
+	if err := json.NewDecoder(r).Decode(&fixture); err != nil {
+		return nil, err
+	}
+	replayer := &Replayer{queries: fixture.Queries}
+	for _, dialect := range []Dialect{MySQL, PostgreSQL, SQLServer} {
+		if dialect.String() == fixture.Dialect {
+			replayer.dialect = dialect
+		}
+	}
+	return replayer, nil
+}
+
+// Dialect returns the Dialect of the backend the fixture was recorded from
+func (r *Replayer) Dialect() Dialect {
+	return r.dialect
+}
+
+// Query returns the recorded rows for query
+func (r *Replayer) Query(query string, args ...interface{}) (RowsScanner, error) {
+	return r.QueryContext(context.Background(), query, args...)
+}
+
+// QueryRow returns the first recorded row for query
+func (r *Replayer) QueryRow(query string, args ...interface{}) Scanner {
+	return r.QueryRowContext(context.Background(), query, args...)
+}
+
+// QueryContext returns the recorded rows for query. It fails if ctx is done
+func (r *Replayer) QueryContext(ctx context.Context, query string, args ...interface{}) (RowsScanner, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	recorded, err := r.find(query, args)
+	if err != nil {
+		return nil, err
+	}
+	if recorded.Error != "" {
+		return nil, errors.New(recorded.Error)
+	}
+	rows := NewRows(recorded.Columns...)
+	for _, fixture := range recorded.Rows {
+		row := make([]interface{}, len(fixture))
+		for i, value := range fixture {
+			if value == nil {
+				continue
+			}
+			if row[i], err = value.decode(); err != nil {
+				return nil, err
+			}
+		}
+		rows.AddRow(row...)
+	}
+	return rows, nil
+}
+
+// QueryRowContext returns the first recorded row for query. It fails if ctx is done
+func (r *Replayer) QueryRowContext(ctx context.Context, query string, args ...interface{}) Scanner {
+	rows, err := r.QueryContext(ctx, query, args...)
+	return &firstRowScanner{rows, err}
+}
+
+func (r *Replayer) find(query string, args []interface{}) (*recordedQuery, error) {
+	values := make([]*typedValue, len(args))
+	for i, arg := range args {
+		value, err := newTypedValue(arg)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var last *recordedQuery
+	for i := range r.queries {
+		recorded := &r.queries[i]
+		if recorded.Query != query || !typedValuesEqual(recorded.Args, values) {
+			continue
+		}
+		if !recorded.used {
+			recorded.used = true
+			return recorded, nil
+		}
+		last = recorded
+	}
+	if last == nil {
+		return nil, fmt.Errorf("onedb: no recording for query %q with args %v", query, args)
+	}
+	return last, nil
+}
+
+func typedValuesEqual(a, b []*typedValue) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !reflect.DeepEqual(a[i], b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// firstRowScanner scans the first of rows, the way database/sql's Row does
+type firstRowScanner struct {
+	rows RowsScanner
+	err  error
+}
+
+func (r *firstRowScanner) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.err
+	}
+	defer r.rows.Close()
+	if !r.rows.Next() {
+		return ErrEmptyResultSet
+	}
+	return r.rows.Scan(dest...)
+}
@@ -0,0 +1,115 @@
+package onedb
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type recordedUser struct {
+	ID      int64     `db:"id"`
+	Name    string    `db:"name"`
+	Active  bool      `db:"active"`
+	Created time.Time `db:"created"`
+	Manager *struct {
+		ID int64 `db:"id"`
+	} `db:"manager,prefix=manager_"`
+}
+
+func TestRecordAndReplay(t *testing.T) {
+	created := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	users := []recordedUser{{ID: 1, Name: "alice", Active: true, Created: created}, {ID: 2, Name: "bob", Created: created}}
+	users[1].Manager = &struct {
+		ID int64 `db:"id"`
+	}{1}
+	db := &dialectMock{NewMock(nil, nil, users, []SimpleData{{7, "seven"}}), PostgreSQL}
+	db.ExpectQuery("select * from users where created > $1").WithArgs(created).WillReturnRows(users)
+	db.ExpectQuery("select * from simple").WillReturnRows([]SimpleData{{7, "seven"}})
+	db.ExpectQuery("select fail").WillReturnError(errors.New("relation does not exist"))
+
+	recorder := NewRecorder(db)
+	var recorded []recordedUser
+	if err := QueryStruct(recorder, &recorded, "select * from users where created > $1", created); err != nil || len(recorded) != 2 {
+		t.Fatal("expected recorded rows", recorded, err)
+	}
+	var simple SimpleData
+	if err := recorder.QueryRow("select * from simple").Scan(&simple.IntVal, &simple.StringVal); err != nil || simple.IntVal != 7 {
+		t.Fatal("expected recorded row", simple, err)
+	}
+	if _, err := recorder.Query("select fail"); err == nil {
+		t.Fatal("expected recorded error")
+	}
+
+	path := filepath.Join(t.TempDir(), "fixture.json")
+	if err := recorder.Save(path); err != nil {
+		t.Fatal("expected saved fixture", err)
+	}
+	replayer, err := NewReplayer(path)
+	if err != nil || replayer.Dialect() != PostgreSQL {
+		t.Fatal("expected replayer", err)
+	}
+
+	var replayed []recordedUser
+	if err := QueryStruct(replayer, &replayed, "select * from users where created > $1", created); err != nil ||
+		len(replayed) != 2 || replayed[0].Name != "alice" || !replayed[0].Active || !replayed[0].Created.Equal(created) ||
+		replayed[1].Manager == nil || replayed[1].Manager.ID != 1 {
+		t.Errorf("expected replayed rows %+v. Actual: %+v %v", users, replayed, err)
+	}
+	json, err := QueryJSONContext(context.Background(), replayer, "select * from simple")
+	if err != nil || json != `[{"IntVal":7,"StringVal":"seven"}]` {
+		t.Error("expected replayed json", json, err)
+	}
+	// recordings are reused once they have all been served
+	if err := replayer.QueryRow("select * from simple").Scan(&simple.IntVal, &simple.StringVal); err != nil || simple.StringVal != "seven" {
+		t.Error("expected reused recording", simple, err)
+	}
+	if _, err := replayer.Query("select fail"); err == nil || err.Error() != "relation does not exist" {
+		t.Error("expected replayed error", err)
+	}
+	if _, err := replayer.Query("select * from users where created > $1", created.Add(time.Second)); err == nil ||
+		!strings.Contains(err.Error(), "no recording") {
+		t.Error("expected missing recording for other args", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := replayer.QueryContext(ctx, "select * from simple"); err != context.Canceled {
+		t.Error("expected canceled", err)
+	}
+}
+
+func TestRecordUnsupportedType(t *testing.T) {
+	type point struct{ X, Y int }
+	recorder := NewRecorder(NewMock(nil, nil, NewRows("location").AddRow(point{1, 2})))
+	if _, err := recorder.Query("select location from places"); err == nil || !strings.Contains(err.Error(), "unsupported value type onedb.point") {
+		t.Error("expected unsupported column type error", err)
+	}
+	if _, err := recorder.Query("select * from places where id = any($1)", []int{1, 2}); err == nil || !strings.Contains(err.Error(), "unsupported value type []int") {
+		t.Error("expected unsupported argument type error", err)
+	}
+	var b bytes.Buffer
+	if err := recorder.Write(&b); err != nil || strings.Contains(b.String(), "places") {
+		t.Error("expected nothing to be recorded", b.String(), err)
+	}
+}
+
+func TestReadReplayer(t *testing.T) {
+	if _, err := ReadReplayer(strings.NewReader("not json")); err == nil {
+		t.Error("expected decode error")
+	}
+	replayer, err := ReadReplayer(strings.NewReader(`{"queries":[{"query":"q","columns":["a"],"rows":[[{"t":"other","v":"1"}]]}]}`))
+	if err != nil || replayer.Dialect() != MySQL {
+		t.Fatal("expected default dialect", err)
+	}
+	if _, err := replayer.Query("q"); err == nil {
+		t.Error("expected unknown type error")
+	}
+
+	var b bytes.Buffer
+	if err := NewRecorder(NewMock(nil, nil)).Write(&b); err != nil || !strings.Contains(b.String(), `"dialect": "MySQL"`) {
+		t.Error("expected empty fixture", b.String(), err)
+	}
+}
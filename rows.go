@@ -0,0 +1,111 @@
+package onedb
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// Rows is a RowsScanner over rows that are built in memory. Unlike NewRowsScanner it allows any column names, NULL
+// values and failures part way through the results:
+//
+//	rows := onedb.NewRows("user_id", "name").
+//		AddRow(1, "alice").
+//		AddRow(2, nil).
+//		RowError(2, errors.New("connection reset"))
+//	db := onedb.NewMock(nil, nil, rows)
+//
+// Values are converted into the Scan destinations the same way database values are. When Rows are returned by a mock
+// each query reads them from the first row
+type Rows struct {
+	columns  []string
+	rows     [][]interface{}
+	rowErrs  map[int]error
+	closeErr error
+	current  int
+	err      error
+}
+
+// NewRows returns empty Rows with the given column names
+func NewRows(columns ...string) *Rows {
+	return &Rows{columns: columns}
+}
+
+// AddRow appends a row of values, one for each column. nil is NULL
+func (r *Rows) AddRow(values ...interface{}) *Rows {
+	r.rows = append(r.rows, values)
+	return r
+}
+
+// RowError makes Scan fail with err on the row at index row, starting from 0. Iteration stops there and Err returns
+// err. An index equal to the number of rows makes Err fail after the last row has been read
+func (r *Rows) RowError(row int, err error) *Rows {
+	if r.rowErrs == nil {
+		r.rowErrs = make(map[int]error)
+	}
+	r.rowErrs[row] = err
+	return r
+}
+
+// CloseError makes Close fail with err
+func (r *Rows) CloseError(err error) *Rows {
+	r.closeErr = err
+	return r
+}
+
+// clone returns a copy of the Rows positioned before the first row
+func (r *Rows) clone() *Rows {
+	return &Rows{columns: r.columns, rows: r.rows, rowErrs: r.rowErrs, closeErr: r.closeErr}
+}
+
+// Columns returns the column names
+func (r *Rows) Columns() ([]string, error) {
+	return r.columns, nil
+}
+
+// Next moves to the next row. It returns false after the last row or after a row that failed with RowError
+func (r *Rows) Next() bool {
+	if r.err != nil {
+		return false
+	}
+	if r.current >= len(r.rows) {
+		r.current = len(r.rows) + 1
+		r.err = r.rowErrs[len(r.rows)]
+		return false
+	}
+	r.current++
+	return true
+}
+
+// Close returns the error set by CloseError
+func (r *Rows) Close() error {
+	return r.closeErr
+}
+
+// Err returns the error of a failed row
+func (r *Rows) Err() error {
+	return r.err
+}
+
+// Scan copies the values of the current row into dest
+func (r *Rows) Scan(dest ...interface{}) error {
+	if r.current == 0 || r.current > len(r.rows) {
+		return errors.New("invalid current row")
+	}
+	if err := r.rowErrs[r.current-1]; err != nil {
+		r.err = err
+		return err
+	}
+	row := r.rows[r.current-1]
+	if len(dest) != len(r.columns) || len(row) != len(r.columns) {
+		return fmt.Errorf("expected equal number of dest values as source. Expected: %d, Actual: %d", len(r.columns), len(dest))
+	}
+	for i := range row {
+		value := row[i]
+		if err := SetValue(reflect.ValueOf(dest[i]).Elem(), &value); err != nil {
+			return fmt.Errorf("column %s: %v", r.columns[i], err)
+		}
+	}
+	return nil
+}
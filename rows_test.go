@@ -0,0 +1,83 @@
+package onedb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRowsScan(t *testing.T) {
+	rows := NewRows("user_id", "name").AddRow(1, "alice").AddRow(int64(2), nil)
+	var id int
+	var name *string
+	var names []string
+	for rows.Next() {
+		if err := rows.Scan(&id, &name); err != nil {
+			t.Fatal("expected scan", err)
+		}
+		if name == nil {
+			names = append(names, "NULL")
+		} else {
+			names = append(names, *name)
+		}
+	}
+	if rows.Err() != nil || id != 2 || len(names) != 2 || names[0] != "alice" || names[1] != "NULL" {
+		t.Error("expected rows", id, names, rows.Err())
+	}
+	if err := rows.Scan(&id, &name); err == nil {
+		t.Error("expected invalid current row")
+	}
+
+	rows = NewRows("a").AddRow(1, 2)
+	rows.Next()
+	if err := rows.Scan(&id); err == nil {
+		t.Error("expected column count error")
+	}
+	rows = NewRows("a").AddRow("text")
+	rows.Next()
+	if err := rows.Scan(&id); err == nil {
+		t.Error("expected conversion error")
+	}
+}
+
+func TestRowsErrors(t *testing.T) {
+	fail := errors.New("connection reset")
+	rows := NewRows("id").AddRow(1).AddRow(2).AddRow(3).RowError(1, fail).CloseError(errors.New("close"))
+	var ids []int
+	for rows.Next() {
+		var id int
+		if err := rows.Scan(&id); err != nil {
+			if err != fail {
+				t.Error("expected row error", err)
+			}
+			continue
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) != 1 || rows.Err() != fail || rows.Close() == nil {
+		t.Error("expected iteration to stop at the failed row", ids, rows.Err())
+	}
+
+	rows = NewRows("id").AddRow(1).RowError(1, fail)
+	var result []SimpleData
+	d := NewMock(nil, nil, NewRows("IntVal", "StringVal").AddRow(1, "a"), rows)
+	if err := d.QueryStruct(&result, "select 1"); err != nil || len(result) != 1 || result[0].StringVal != "a" {
+		t.Error("expected mock rows", result, err)
+	}
+	if _, err := QueryMaps(d, "select 2"); err != fail {
+		t.Error("expected error after last row", err)
+	}
+}
+
+func TestRowsExpectation(t *testing.T) {
+	rows := NewRows("IntVal", "StringVal").AddRow(1, "a")
+	d := NewMock(nil, nil)
+	d.ExpectQuery("select a").WillReturnRows(rows)
+	d.ExpectQuery("select a").WillReturnRows(rows)
+	for i := 0; i < 2; i++ {
+		// each query reads the rows from the start
+		var row SimpleData
+		if err := d.QueryStructRow(&row, "select a"); err != nil || row.IntVal != 1 {
+			t.Error("expected row", i, row, err)
+		}
+	}
+}
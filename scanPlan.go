@@ -0,0 +1,38 @@
+package onedb
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// scanPlan is the compiled mapping from a query's columns to the fields of a struct type
+type scanPlan struct {
+	itemType reflect.Type
+	fields   []structFieldInfo
+	unmapped *UnmappedError // nil when every column and field is mapped
+}
+
+type scanPlanKey struct {
+	itemType reflect.Type
+	columns  string
+}
+
+// scanPlans caches compiled plans so that repeated queries don't redo the field to column matching. The number of
+// entries is bounded by the distinct (struct type, column list) pairs an application queries
+var scanPlans sync.Map
+
+// getScanPlan returns the cached plan for mapping columns into itemType, compiling it on first use
+func getScanPlan(columns []string, itemType reflect.Type) *scanPlan {
+	key := scanPlanKey{itemType, strings.Join(columns, "\x00")}
+	if plan, ok := scanPlans.Load(key); ok {
+		return plan.(*scanPlan)
+	}
+	plan, _ := scanPlans.LoadOrStore(key, newScanPlan(columns, itemType))
+	return plan.(*scanPlan)
+}
+
+func newScanPlan(columns []string, itemType reflect.Type) *scanPlan {
+	fields := mapColumnsToFields(columns, itemType)
+	return &scanPlan{itemType: itemType, fields: fields, unmapped: checkUnmapped(columns, itemType, fields)}
+}
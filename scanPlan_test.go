@@ -0,0 +1,112 @@
+package onedb
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type wideRow struct {
+	Col00 int64
+	Col01 int64
+	Col02 int64
+	Col03 int64
+	Col04 int64
+	Col05 int64
+	Col06 int64
+	Col07 int64
+	Col08 int64
+	Col09 int64
+	Col10 int64
+	Col11 int64
+	Col12 int64
+	Col13 int64
+	Col14 int64
+	Col15 int64
+	Col16 int64
+	Col17 int64
+	Col18 int64
+	Col19 int64
+	Col20 int64
+	Col21 int64
+	Col22 int64
+	Col23 int64
+	Col24 int64
+	Col25 int64
+	Col26 int64
+	Col27 int64
+	Col28 int64
+	Col29 int64
+	Col30 int64
+	Col31 int64
+	Col32 int64
+	Col33 int64
+	Col34 int64
+	Col35 int64
+	Col36 int64
+	Col37 int64
+	Col38 int64
+	Col39 int64
+}
+
+func TestGetScanPlanCached(t *testing.T) {
+	columns := []string{"IntVal", "StringVal"}
+	itemType := reflect.TypeOf(SimpleData{})
+	plan := getScanPlan(columns, itemType)
+	if len(plan.fields) != 2 || plan.unmapped != nil {
+		t.Fatal("expected fully mapped plan", plan.fields, plan.unmapped)
+	}
+	if getScanPlan([]string{"IntVal", "StringVal"}, itemType) != plan {
+		t.Error("expected cached plan for the same type and columns")
+	}
+	if other := getScanPlan([]string{"IntVal"}, itemType); other == plan || other.unmapped == nil {
+		t.Error("expected a separate plan for different columns")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			getScanPlan([]string{"StringVal", "IntVal"}, itemType)
+		}()
+	}
+	wg.Wait()
+}
+
+func wideRows(n int) []wideRow {
+	rows := make([]wideRow, n)
+	for i := range rows {
+		rows[i].Col00 = int64(i)
+	}
+	return rows
+}
+
+func BenchmarkQueryStructWide(b *testing.B) {
+	data := wideRows(10)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		result := []wideRow{}
+		if err := getStruct(NewRowsScanner(data), &result); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkScanPlanCached(b *testing.B) {
+	columns, _ := NewRowsScanner(wideRows(1)).Columns()
+	itemType := reflect.TypeOf(wideRow{})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		getScanPlan(columns, itemType)
+	}
+}
+
+func BenchmarkScanPlanUncached(b *testing.B) {
+	columns, _ := NewRowsScanner(wideRows(1)).Columns()
+	itemType := reflect.TypeOf(wideRow{})
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		newScanPlan(columns, itemType)
+	}
+}
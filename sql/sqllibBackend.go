@@ -1,6 +1,7 @@
 package sql
 
 import (
+	"context"
 	sqllib "database/sql"
 	"io"
 
@@ -20,13 +21,15 @@ func sqllibOpen(driverName, dataSourceName string) (sqlLibBackender, error) {
 }
 
 type sqllibBackend struct {
-	db sqlLibBackender
+	db      sqlLibBackender
+	dialect onedb.Dialect
 	onedb.Backender
 }
 
 // SQLer is the interface containing the capability available for a database/sql database
 type SQLer interface {
 	onedb.DBer
+	Dialect() onedb.Dialect
 }
 
 type sqlLibBackender interface {
@@ -35,6 +38,9 @@ type sqlLibBackender interface {
 	Exec(query string, args ...interface{}) (sqllib.Result, error)
 	Query(query string, args ...interface{}) (*sqllib.Rows, error)
 	QueryRow(query string, args ...interface{}) *sqllib.Row
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sqllib.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sqllib.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sqllib.Row
 }
 
 // NewSqllib creates an instance of a database/sql database
@@ -47,13 +53,18 @@ func NewSqllib(driverName, connectionString string) (SQLer, error) {
 	if err != nil {
 		return nil, err
 	}
-	return &sqllibBackend{db: sqlDb}, nil
+	return &sqllibBackend{db: sqlDb, dialect: onedb.DialectFor(driverName)}, nil
 }
 
 func (b *sqllibBackend) Close() error {
 	return b.db.Close()
 }
 
+// Dialect returns the SQL dialect of the driver the database was opened with
+func (b *sqllibBackend) Dialect() onedb.Dialect {
+	return b.dialect
+}
+
 func (b *sqllibBackend) Query(query string, args ...interface{}) (onedb.RowsScanner, error) {
 	return b.db.Query(query, args...)
 }
@@ -62,9 +73,20 @@ func (b *sqllibBackend) QueryRow(query string, args ...interface{}) onedb.Scanne
 	return b.db.QueryRow(query, args...)
 }
 
-func (b *sqllibBackend) Exec(command string, args ...interface{}) error {
-	_, err := b.db.Exec(command, args...)
-	return err
+func (b *sqllibBackend) Exec(command string, args ...interface{}) (onedb.Result, error) {
+	return b.db.Exec(command, args...)
+}
+
+func (b *sqllibBackend) QueryContext(ctx context.Context, query string, args ...interface{}) (onedb.RowsScanner, error) {
+	return b.db.QueryContext(ctx, query, args...)
+}
+
+func (b *sqllibBackend) QueryRowContext(ctx context.Context, query string, args ...interface{}) onedb.Scanner {
+	return b.db.QueryRowContext(ctx, query, args...)
+}
+
+func (b *sqllibBackend) ExecContext(ctx context.Context, command string, args ...interface{}) (onedb.Result, error) {
+	return b.db.ExecContext(ctx, command, args...)
 }
 
 func (b *sqllibBackend) QueryValues(query *onedb.Query, result ...interface{}) error {
@@ -90,3 +112,27 @@ func (b *sqllibBackend) QueryStructRow(result interface{}, query string, args ..
 func (b *sqllibBackend) QueryWriteCSV(w io.Writer, options onedb.CSVOptions, query string, args ...interface{}) error {
 	return onedb.QueryWriteCSV(w, options, b, query, args...)
 }
+
+func (b *sqllibBackend) QueryValuesContext(ctx context.Context, query *onedb.Query, result ...interface{}) error {
+	return onedb.QueryValuesContext(ctx, b, query, result...)
+}
+
+func (b *sqllibBackend) QueryJSONContext(ctx context.Context, query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSONContext(ctx, b, query, args...)
+}
+
+func (b *sqllibBackend) QueryJSONRowContext(ctx context.Context, query string, args ...interface{}) (string, error) {
+	return onedb.QueryJSONRowContext(ctx, b, query, args...)
+}
+
+func (b *sqllibBackend) QueryStructContext(ctx context.Context, result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStructContext(ctx, b, result, query, args...)
+}
+
+func (b *sqllibBackend) QueryStructRowContext(ctx context.Context, result interface{}, query string, args ...interface{}) error {
+	return onedb.QueryStructRowContext(ctx, b, result, query, args...)
+}
+
+func (b *sqllibBackend) QueryWriteCSVContext(ctx context.Context, w io.Writer, options onedb.CSVOptions, query string, args ...interface{}) error {
+	return onedb.QueryWriteCSVContext(ctx, w, options, b, query, args...)
+}
@@ -1,6 +1,7 @@
 package sql
 
 import (
+	"context"
 	sqllib "database/sql"
 	"errors"
 	"reflect"
@@ -13,8 +14,8 @@ const connectionString string = "server=localhost;user id=gotest;password=go;dat
 
 func TestNewSqllibOneDB(t *testing.T) {
 	openDatabase = newSqllibMockCreator(&mockSqllibBackend{}, nil)
-	_, err := NewSqllib("mssql", connectionString)
-	if err != nil {
+	db, err := NewSqllib("mssql", connectionString)
+	if err != nil || db.Dialect() != onedb.SQLServer {
 		t.Error("expected success")
 	}
 
@@ -73,6 +74,36 @@ func TestSqllibExecute(t *testing.T) {
 	verifyArgs(t, c.MethodsRun[0].Arguments, "query", "arg1", "arg2")
 }
 
+func TestSqllibQueryContext(t *testing.T) {
+	c := newMockSqllibBackend()
+	d := &sqllibBackend{db: c}
+
+	d.QueryContext(context.Background(), "query", "arg1", "arg2")
+	if len(c.MethodsRun) != 1 || c.MethodsRun[0].MethodName != "QueryContext" {
+		t.Fatal("expected QueryContext method to be called on backend")
+	}
+	verifyArgs(t, c.MethodsRun[0].Arguments, "query", "arg1", "arg2")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var result []struct{ ID int }
+	d.QueryStructContext(ctx, &result, "query")
+	if len(c.MethodsRun) != 2 || c.MethodsRun[1].MethodName != "QueryContext" {
+		t.Fatal("expected QueryStructContext to use QueryContext on backend")
+	}
+}
+
+func TestSqllibExecContext(t *testing.T) {
+	c := newMockSqllibBackend()
+	d := &sqllibBackend{db: c}
+
+	d.ExecContext(context.Background(), "query", "arg1", "arg2")
+	if len(c.MethodsRun) != 1 || c.MethodsRun[0].MethodName != "ExecContext" {
+		t.Fatal("expected ExecContext method to be called on backend")
+	}
+	verifyArgs(t, c.MethodsRun[0].Arguments, "query", "arg1", "arg2")
+}
+
 /***************************** MOCKS ****************************/
 func newSqllibMockCreator(conn sqlLibBackender, err error) openDatabaseFunc {
 	return func(driverName, dataSourceName string) (sqlLibBackender, error) {
@@ -125,6 +156,18 @@ func (c *mockSqllibBackend) QueryRow(query string, args ...interface{}) *sqllib.
 	c.SaveMethodCall("QueryRow", append([]interface{}{query}, args...))
 	return nil
 }
+func (c *mockSqllibBackend) ExecContext(ctx context.Context, query string, args ...interface{}) (sqllib.Result, error) {
+	c.SaveMethodCall("ExecContext", append([]interface{}{query}, args...))
+	return nil, ctx.Err()
+}
+func (c *mockSqllibBackend) QueryContext(ctx context.Context, query string, args ...interface{}) (*sqllib.Rows, error) {
+	c.SaveMethodCall("QueryContext", append([]interface{}{query}, args...))
+	return nil, ctx.Err()
+}
+func (c *mockSqllibBackend) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sqllib.Row {
+	c.SaveMethodCall("QueryRowContext", append([]interface{}{query}, args...))
+	return nil
+}
 
 func verifyArgs(t *testing.T, actual []interface{}, expected ...interface{}) {
 	if len(expected) != len(actual) {
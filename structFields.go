@@ -0,0 +1,208 @@
+package onedb
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// structColumn is a struct field that can be populated from (or rendered to) a database column
+type structColumn struct {
+	Name   string       // column name as declared by the db tag or the field name
+	Field  string       // name of the Go field, dotted for fields of prefixed nested structs
+	Type   reflect.Type // type of the Go field
+	Index  []int        // index path used with fieldByIndex to reach nested and embedded fields
+	Tagged bool         // Name came from a db tag and must match the column exactly
+	Auto   bool         // value is generated by the database so it is left out of INSERT and UPDATE statements
+	Key    bool         // identifies the row when joined rows are merged into one struct
+}
+
+// structRelation is a slice of child structs that is populated from the prefixed columns of a joined query
+type structRelation struct {
+	Field     string       // name of the slice field
+	Index     []int        // index path of the slice field
+	Prefix    string       // prefix of the child columns
+	ElemType  reflect.Type // struct type of the children
+	ElemPtr   bool         // the slice holds pointers to the children
+	KeyColumn string       // column identifying a child, from the key= option
+}
+
+// getStructColumns returns the fields of a struct type that map to database columns. Fields are matched using
+// the `db` struct tag:
+//
+//	Field string `db:"column_name"`        // maps to column_name
+//	Field string `db:"-"`                  // never mapped
+//	Addr  Address `db:"addr,prefix=addr_"` // fields of Address map to addr_street, addr_city, etc.
+//	ID    int    `db:"id,auto"`             // generated by the database, so never inserted or updated
+//	ID    int    `db:"id,key"`              // identifies the struct when joined rows are merged
+//	Lines []Line `db:"lines,prefix=line_"`  // one-to-many children, see getStructRelations
+//
+// Anonymous embedded structs are flattened into the parent. Fields of shallower structs are listed first so that
+// they take precedence over embedded fields with the same column name.
+func getStructColumns(structType reflect.Type) []structColumn {
+	columns := appendStructColumns(nil, structType, nil, "", "")
+	sort.SliceStable(columns, func(i, j int) bool {
+		return len(columns[i].Index) < len(columns[j].Index)
+	})
+	return columns
+}
+
+func appendStructColumns(columns []structColumn, structType reflect.Type, index []int, path, prefix string) []structColumn {
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := parseDBTag(field.Tag.Get("db"))
+		name := tag.Name
+		if name == "-" {
+			continue
+		}
+		fieldIndex := append(append([]int{}, index...), i)
+		fieldType := field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if _, ok := getRelationElem(field.Type); ok && tag.HasPrefix {
+			continue
+		}
+		if tag.HasPrefix && fieldType.Kind() == reflect.Struct {
+			columns = appendStructColumns(columns, fieldType, fieldIndex, path+field.Name+".", prefix+tag.Prefix)
+			continue
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			columns = appendStructColumns(columns, fieldType, fieldIndex, path, prefix)
+			continue
+		}
+		if field.PkgPath != "" { // unexported
+			continue
+		}
+
+		column := structColumn{Name: prefix + field.Name, Field: path + field.Name, Type: field.Type, Index: fieldIndex, Auto: tag.Auto, Key: tag.Key}
+		if name != "" {
+			column.Name = prefix + name
+			column.Tagged = true
+		}
+		columns = append(columns, column)
+	}
+	return columns
+}
+
+// getStructRelations returns the slice of struct fields tagged with a prefix, including those of embedded structs:
+//
+//	Lines []Line `db:"lines,prefix=line_"`             // Line fields map to line_sku, line_quantity, etc.
+//	Lines []Line `db:"lines,prefix=line_,key=line_id"` // line_id identifies each Line
+//
+// Without a key= option children are identified by their key tagged fields, or by all of their columns
+func getStructRelations(structType reflect.Type) []structRelation {
+	var relations []structRelation
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		tag := parseDBTag(field.Tag.Get("db"))
+		if tag.Name == "-" {
+			continue
+		}
+		if field.Anonymous && tag.Name == "" && field.Type.Kind() == reflect.Struct {
+			for _, relation := range getStructRelations(field.Type) {
+				relation.Index = append([]int{i}, relation.Index...)
+				relations = append(relations, relation)
+			}
+			continue
+		}
+		elemType, ok := getRelationElem(field.Type)
+		if !ok || !tag.HasPrefix || field.PkgPath != "" {
+			continue
+		}
+		relations = append(relations, structRelation{Field: field.Name, Index: []int{i}, Prefix: tag.Prefix, ElemType: elemType,
+			ElemPtr: field.Type.Elem().Kind() == reflect.Ptr, KeyColumn: tag.KeyColumn})
+	}
+	return relations
+}
+
+// getRelationElem returns the struct type held by a []T or []*T field. time.Time isn't a relation
+func getRelationElem(fieldType reflect.Type) (reflect.Type, bool) {
+	if fieldType.Kind() != reflect.Slice {
+		return nil, false
+	}
+	elemType := fieldType.Elem()
+	if elemType.Kind() == reflect.Ptr {
+		elemType = elemType.Elem()
+	}
+	return elemType, elemType.Kind() == reflect.Struct && elemType != timeType
+}
+
+type dbTag struct {
+	Name      string
+	Prefix    string
+	HasPrefix bool
+	Auto      bool
+	Key       bool
+	KeyColumn string
+}
+
+// parseDBTag splits a tag like "addr,prefix=addr_" into its column name and options
+func parseDBTag(tag string) dbTag {
+	parts := strings.Split(tag, ",")
+	result := dbTag{Name: parts[0]}
+	for _, option := range parts[1:] {
+		switch {
+		case strings.HasPrefix(option, "prefix="):
+			result.Prefix = strings.TrimPrefix(option, "prefix=")
+			result.HasPrefix = true
+		case option == "auto":
+			result.Auto = true
+		case option == "key":
+			result.Key = true
+		case strings.HasPrefix(option, "key="):
+			result.KeyColumn = strings.TrimPrefix(option, "key=")
+		}
+	}
+	return result
+}
+
+// getColumnIndex returns the index of the database column that populates the struct column or -1 if there isn't one.
+// Tagged columns must match case insensitively. Untagged fields, including those of prefixed structs, also match
+// snake_case columns, so UserID matches user_id and ZipCode with prefix addr_ matches addr_zip_code
+func getColumnIndex(column structColumn, dbColumns []string, claimed []bool) int {
+	name := strings.ToLower(column.Name)
+	for i, dbColumn := range dbColumns {
+		if !claimed[i] && strings.ToLower(dbColumn) == name {
+			return i
+		}
+	}
+	if column.Tagged {
+		return -1
+	}
+	name = strings.Replace(name, "_", "", -1)
+	for i, dbColumn := range dbColumns {
+		if !claimed[i] && strings.ToLower(strings.Replace(dbColumn, "_", "", -1)) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// fieldByIndex returns the nested field, allocating any nil embedded or nested struct pointers along the way
+func fieldByIndex(value reflect.Value, index []int) reflect.Value {
+	for i, fieldIndex := range index {
+		if i > 0 && value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
+		}
+		value = value.Field(fieldIndex)
+	}
+	return value
+}
+
+// readFieldByIndex returns the nested field without allocating. ok is false if a nil pointer is found along the way
+func readFieldByIndex(value reflect.Value, index []int) (field reflect.Value, ok bool) {
+	for i, fieldIndex := range index {
+		if i > 0 && value.Kind() == reflect.Ptr {
+			if value.IsNil() {
+				return reflect.Value{}, false
+			}
+			value = value.Elem()
+		}
+		value = value.Field(fieldIndex)
+	}
+	return value, true
+}
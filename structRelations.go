@@ -0,0 +1,226 @@
+package onedb
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// relationPlan is the compiled mapping from the columns of a joined query to a struct type and its child slices.
+// Parents are merged by their key columns and each child relation is a relationPlan of its own for the columns that
+// carry its prefix
+type relationPlan struct {
+	itemType reflect.Type
+	fields   []structFieldInfo // DBIndex refers to the full column list
+	key      []int             // columns identifying an item
+	children []relationChild
+	unmapped *UnmappedError // nil when every column and field is mapped
+}
+
+type relationChild struct {
+	relation structRelation
+	plan     *relationPlan
+}
+
+// relationState tracks the items already added to one slice so that later rows are merged into them
+type relationState struct {
+	index    map[string]int
+	children [][]*relationState // per item, per child relation
+}
+
+var relationPlans sync.Map
+
+// getRelationPlan returns the cached plan for hydrating itemType from columns, or nil if itemType has no child slices
+func getRelationPlan(columns []string, itemType reflect.Type) *relationPlan {
+	if itemType.Kind() != reflect.Struct || len(getStructRelations(itemType)) == 0 {
+		return nil
+	}
+	key := scanPlanKey{itemType, strings.Join(columns, "\x00")}
+	if plan, ok := relationPlans.Load(key); ok {
+		return plan.(*relationPlan)
+	}
+	indexes := make([]int, len(columns))
+	for i := range indexes {
+		indexes[i] = i
+	}
+	plan := newRelationPlan(columns, columns, indexes, itemType, "", "")
+	actual, _ := relationPlans.LoadOrStore(key, plan)
+	return actual.(*relationPlan)
+}
+
+// newRelationPlan maps the visible columns (names with the parent prefixes removed, indexes into allColumns) to
+// itemType. Columns starting with a relation prefix are left to that relation
+func newRelationPlan(allColumns, visible []string, indexes []int, itemType reflect.Type, keyColumn, path string) *relationPlan {
+	plan := &relationPlan{itemType: itemType}
+	relations := getStructRelations(itemType)
+
+	var own []string
+	var ownIndexes []int
+	for i, column := range visible {
+		if getRelationFor(column, relations) == -1 {
+			own = append(own, column)
+			ownIndexes = append(ownIndexes, indexes[i])
+		}
+	}
+	fields := mapColumnsToFields(own, itemType)
+	ownUnmapped := checkUnmapped(own, itemType, fields)
+	for i := range fields {
+		fields[i].DBIndex = ownIndexes[fields[i].DBIndex]
+		fields[i].Column = allColumns[fields[i].DBIndex]
+	}
+	plan.fields = fields
+	plan.key = getRelationKey(allColumns, itemType, fields, keyColumn)
+
+	unmapped := &UnmappedError{}
+	if ownUnmapped != nil {
+		unmapped.Columns = ownUnmapped.Columns
+		for _, field := range ownUnmapped.Fields {
+			unmapped.Fields = append(unmapped.Fields, path+field)
+		}
+	}
+
+	for r, relation := range relations {
+		var childVisible []string
+		var childIndexes []int
+		for i, column := range visible {
+			if getRelationFor(column, relations) == r {
+				childVisible = append(childVisible, column[len(relation.Prefix):])
+				childIndexes = append(childIndexes, indexes[i])
+			}
+		}
+		child := newRelationPlan(allColumns, childVisible, childIndexes, relation.ElemType, relation.KeyColumn, path+relation.Field+".")
+		plan.children = append(plan.children, relationChild{relation: relation, plan: child})
+		if child.unmapped != nil {
+			unmapped.Columns = append(unmapped.Columns, child.unmapped.Columns...)
+			unmapped.Fields = append(unmapped.Fields, child.unmapped.Fields...)
+		}
+	}
+	if len(unmapped.Columns) > 0 || len(unmapped.Fields) > 0 {
+		plan.unmapped = unmapped
+	}
+	return plan
+}
+
+// getRelationFor returns the index of the relation with the longest prefix matching column, or -1
+func getRelationFor(column string, relations []structRelation) int {
+	match := -1
+	for i, relation := range relations {
+		if len(column) > len(relation.Prefix) && strings.EqualFold(column[:len(relation.Prefix)], relation.Prefix) &&
+			(match == -1 || len(relation.Prefix) > len(relations[match].Prefix)) {
+			match = i
+		}
+	}
+	return match
+}
+
+// getRelationKey returns the columns identifying an item: the key= column of the relation, the key tagged fields or
+// all of the mapped fields
+func getRelationKey(allColumns []string, itemType reflect.Type, fields []structFieldInfo, keyColumn string) []int {
+	if keyColumn != "" {
+		for i, column := range allColumns {
+			if strings.EqualFold(column, keyColumn) {
+				return []int{i}
+			}
+		}
+	}
+
+	keyFields := make(map[string]bool)
+	for _, column := range getStructColumns(itemType) {
+		if column.Key {
+			keyFields[column.Field] = true
+		}
+	}
+	var key, all []int
+	for _, field := range fields {
+		if keyFields[field.Field] {
+			key = append(key, field.DBIndex)
+		}
+		all = append(all, field.DBIndex)
+	}
+	if len(key) > 0 {
+		return key
+	}
+	return all
+}
+
+// hydrate scans the remaining rows into sliceValue, merging rows with the same key into one item and appending
+// their children. When current is true the row rows is positioned on is scanned first. When single is true
+// hydration stops at the first row belonging to a second item
+func (p *relationPlan) hydrate(rows RowsScanner, vals []interface{}, sliceValue reflect.Value, current, single bool) error {
+	state := &relationState{index: make(map[string]int)}
+	for current || rows.Next() {
+		current = false
+		if err := rows.Scan(vals...); err != nil {
+			return err
+		}
+		if _, ok := state.index[p.rowKey(vals)]; single && !ok && len(state.index) > 0 {
+			return nil
+		}
+		if err := p.merge(sliceValue, false, state, vals); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+// merge adds the item in vals to sliceValue, or finds the item already added with the same key, and then merges
+// the children of the row into it
+func (p *relationPlan) merge(sliceValue reflect.Value, elemPtr bool, state *relationState, vals []interface{}) error {
+	key := p.rowKey(vals)
+	pos, ok := state.index[key]
+	if !ok {
+		itemValue := reflect.New(p.itemType)
+		for _, fieldInfo := range p.fields {
+			src := vals[fieldInfo.DBIndex].(*interface{})
+			if err := setField(fieldByIndex(itemValue.Elem(), fieldInfo.FieldIndex), src, fieldInfo.Convert); err != nil {
+				return &ConversionError{Column: fieldInfo.Column, Field: fieldInfo.Field, SrcType: reflect.TypeOf(*src), DestType: fieldInfo.Type, Err: err}
+			}
+		}
+		if elemPtr {
+			sliceValue.Set(reflect.Append(sliceValue, itemValue))
+		} else {
+			sliceValue.Set(reflect.Append(sliceValue, itemValue.Elem()))
+		}
+		pos = sliceValue.Len() - 1
+		state.index[key] = pos
+		children := make([]*relationState, len(p.children))
+		for i := range children {
+			children[i] = &relationState{index: make(map[string]int)}
+		}
+		state.children = append(state.children, children)
+	}
+
+	item := sliceValue.Index(pos)
+	if elemPtr {
+		item = item.Elem()
+	}
+	for i, child := range p.children {
+		if child.plan.isNull(vals) {
+			continue // outer join without a matching child
+		}
+		childSlice := fieldByIndex(item, child.relation.Index)
+		if err := child.plan.merge(childSlice, child.relation.ElemPtr, state.children[pos][i], vals); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isNull returns true when all of the key columns are NULL
+func (p *relationPlan) isNull(vals []interface{}) bool {
+	for _, index := range p.key {
+		if *vals[index].(*interface{}) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+func (p *relationPlan) rowKey(vals []interface{}) string {
+	b := &strings.Builder{}
+	for _, index := range p.key {
+		fmt.Fprintf(b, "%T:%v\x00", *vals[index].(*interface{}), *vals[index].(*interface{}))
+	}
+	return b.String()
+}
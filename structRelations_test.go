@@ -0,0 +1,166 @@
+package onedb
+
+import (
+	"reflect"
+	"testing"
+)
+
+type relationOrderRow struct {
+	ID       int              `db:"id"`
+	Customer string           `db:"customer"`
+	Line     *relationLineRow `db:"line,prefix=line_"`
+}
+
+type relationLineRow struct {
+	ID   int              `db:"id"`
+	SKU  string           `db:"sku"`
+	Note *relationNoteRow `db:"note,prefix=note_"`
+}
+
+type relationNoteRow struct {
+	ID   int    `db:"id"`
+	Text string `db:"text"`
+}
+
+type relationOrder struct {
+	ID       int            `db:"id,key"`
+	Customer string         `db:"customer"`
+	Lines    []relationLine `db:"lines,prefix=line_,key=line_id"`
+}
+
+type relationLine struct {
+	ID    int             `db:"id"`
+	SKU   string          `db:"sku"`
+	Notes []*relationNote `db:"notes,prefix=note_"`
+}
+
+type relationNote struct {
+	ID   int    `db:"id,key"`
+	Text string `db:"text"`
+}
+
+func relationRows() []relationOrderRow {
+	return []relationOrderRow{
+		{1, "alice", &relationLineRow{10, "a", &relationNoteRow{100, "gift"}}},
+		{1, "alice", &relationLineRow{10, "a", &relationNoteRow{101, "wrap"}}},
+		{1, "alice", &relationLineRow{11, "b", nil}},
+		{2, "bob", nil},
+		{3, "carol", &relationLineRow{12, "c", nil}},
+		{1, "alice", &relationLineRow{10, "a", &relationNoteRow{100, "gift"}}},
+	}
+}
+
+func TestGetStructRelations(t *testing.T) {
+	relations := getStructRelations(reflect.TypeOf(relationOrder{}))
+	if len(relations) != 1 || relations[0].Field != "Lines" || relations[0].Prefix != "line_" || relations[0].KeyColumn != "line_id" ||
+		relations[0].ElemType != reflect.TypeOf(relationLine{}) || relations[0].ElemPtr {
+		t.Fatal("expected Lines relation", relations)
+	}
+	relations = getStructRelations(reflect.TypeOf(relationLine{}))
+	if len(relations) != 1 || relations[0].Field != "Notes" || !relations[0].ElemPtr {
+		t.Fatal("expected Notes relation", relations)
+	}
+
+	// relations aren't columns
+	for _, column := range getStructColumns(reflect.TypeOf(relationOrder{})) {
+		if column.Field == "Lines" {
+			t.Error("expected relation to be skipped", column)
+		}
+	}
+	if columns := getStructColumns(reflect.TypeOf(relationNote{})); !columns[0].Key || columns[1].Key {
+		t.Error("expected key column", columns)
+	}
+}
+
+func TestGetStructHydrate(t *testing.T) {
+	result := []relationOrder{}
+	err := getStruct(NewRowsScanner(relationRows()), &result)
+	if err != nil || len(result) != 3 {
+		t.Fatal("expected 3 orders", err, result)
+	}
+	alice, bob, carol := result[0], result[1], result[2]
+	if alice.ID != 1 || alice.Customer != "alice" || len(alice.Lines) != 2 || alice.Lines[0].ID != 10 || alice.Lines[0].SKU != "a" ||
+		alice.Lines[1].ID != 11 || alice.Lines[1].SKU != "b" {
+		t.Error("expected merged lines", alice)
+	}
+	if len(alice.Lines[0].Notes) != 2 || alice.Lines[0].Notes[0].ID != 100 || alice.Lines[0].Notes[1].Text != "wrap" || len(alice.Lines[1].Notes) != 0 {
+		t.Error("expected merged notes without duplicates", alice.Lines)
+	}
+	if bob.ID != 2 || bob.Lines != nil {
+		t.Error("expected outer join without lines", bob)
+	}
+	if carol.ID != 3 || len(carol.Lines) != 1 || carol.Lines[0].SKU != "c" {
+		t.Error("expected one line", carol)
+	}
+
+	// strict
+	type order struct {
+		ID    int            `db:"id,key"`
+		Lines []relationLine `db:"lines,prefix=line_"`
+	}
+	orders := []order{}
+	err = getStructWithOptions(NewRowsScanner(relationRows()), &orders, StructOptions{Strict: true})
+	if unmapped, ok := err.(*UnmappedError); !ok || !reflect.DeepEqual(unmapped.Columns, []string{"customer"}) || unmapped.Fields != nil {
+		t.Error("expected unmapped customer column", err)
+	}
+
+	// conversion error
+	type badNote struct {
+		Text int `db:"text"`
+	}
+	type badLine struct {
+		ID    int       `db:"id"`
+		Notes []badNote `db:"notes,prefix=note_"`
+	}
+	type badOrder struct {
+		ID    int       `db:"id,key"`
+		Lines []badLine `db:"lines,prefix=line_,key=line_id"`
+	}
+	bad := []badOrder{}
+	err = getStruct(NewRowsScanner(relationRows()), &bad)
+	if conversion, ok := err.(*ConversionError); !ok || conversion.Column != "line_note_text" {
+		t.Error("expected conversion error", err)
+	}
+}
+
+func TestGetStructHydrateSiblings(t *testing.T) {
+	type tag struct {
+		Name string `db:"name"`
+	}
+	type order struct {
+		ID    int            `db:"id,key"`
+		Lines []relationLine `db:"lines,prefix=line_,key=line_id"`
+		Tags  []tag          `db:"tags,prefix=tag_"`
+	}
+	// joining lines and tags repeats every line for every tag
+	rows := NewRows("id", "line_id", "line_sku", "tag_name").
+		AddRow(1, 10, "a", "red").
+		AddRow(1, 10, "a", "big").
+		AddRow(1, 11, "b", "red").
+		AddRow(1, 11, "b", "big").
+		AddRow(2, 12, "c", nil)
+	var result []order
+	if err := getStruct(rows, &result); err != nil || len(result) != 2 {
+		t.Fatal("expected 2 orders", result, err)
+	}
+	if lines, tags := result[0].Lines, result[0].Tags; len(lines) != 2 || lines[0].ID != 10 || lines[1].ID != 11 ||
+		!reflect.DeepEqual(tags, []tag{{"red"}, {"big"}}) {
+		t.Error("expected sibling children without duplicates", result[0])
+	}
+	if len(result[1].Lines) != 1 || result[1].Tags != nil {
+		t.Error("expected one line and no tags", result[1])
+	}
+}
+
+func TestGetStructRowHydrate(t *testing.T) {
+	result := relationOrder{}
+	err := getStructRow(NewRowsScanner(relationRows()), &result)
+	if err != nil || result.ID != 1 || len(result.Lines) != 2 || len(result.Lines[0].Notes) != 2 {
+		t.Error("expected first order", err, result)
+	}
+
+	err = getStructRow(NewRowsScanner([]relationOrderRow{}), &result)
+	if err != ErrEmptyResultSet {
+		t.Error("expected empty result set", err)
+	}
+}
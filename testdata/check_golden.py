@@ -0,0 +1,54 @@
+"""Reads the golden files written by TestWriteParquetGolden and TestWriteArrowGolden with pyarrow, the reference
+implementation of both formats, and checks that they decode to getColumnarGoldenRows. TestGoldenPyarrow runs it
+when pyarrow is installed.
+
+    pip install pyarrow
+    python3 testdata/check_golden.py
+"""
+import datetime
+import os
+from decimal import Decimal
+
+import pyarrow.ipc
+import pyarrow.parquet
+
+created = datetime.datetime(2020, 1, 2, 3, 4, 5, 6)
+expected = {
+    "ID": [1, 2, 3],
+    "Name": ["bob", None, "sue"],
+    "Price": [1.5, 2.5, 3.5],
+    "Active": [True, False, True],
+    "Created": [created, created, created],
+    "Data": [b"\x01\x02", b"", b"\x03"],  # a nil []byte is empty, not NULL
+    "Amount": [Decimal("12.34"), Decimal("-0.05"), Decimal("100")],
+    "Balance": [Decimal("-1234567890123456.7890"), None, Decimal("0.0001")],
+    "Quantity": [None, 7, None],
+    "Ratio": [0.25, None, None],
+    "Updated": [None, datetime.datetime(2021, 6, 7, 8, 9, 10, 11), None],
+}
+decimals = {"Amount": (10, 2), "Balance": (20, 4)}
+
+
+def check(name, table, batches):
+    for column, (precision, scale) in decimals.items():
+        field = table.schema.field(column)
+        assert field.type == pyarrow.decimal128(precision, scale), "%s: expected %s to be decimal(%d, %d). Actual: %s" % (
+            name, column, precision, scale, field.type)
+    columns = table.to_pydict()
+    for column in ("Created", "Updated"):
+        columns[column] = [value and value.replace(tzinfo=None) for value in columns[column]]
+    assert columns == expected, "%s: expected %r. Actual: %r" % (name, expected, columns)
+    assert batches == [2, 1], "%s: expected batches of 2 and 1 rows. Actual: %r" % (name, batches)
+    print("%s: ok" % name)
+
+
+here = os.path.dirname(os.path.abspath(__file__))
+
+parquet = pyarrow.parquet.ParquetFile(os.path.join(here, "columnar.parquet"))
+groups = [parquet.metadata.row_group(i).num_rows for i in range(parquet.metadata.num_row_groups)]
+check("columnar.parquet", parquet.read(), groups)
+
+with open(os.path.join(here, "columnar.arrows"), "rb") as f:
+    reader = pyarrow.ipc.open_stream(f)
+    batches = list(reader)
+check("columnar.arrows", pyarrow.Table.from_batches(batches), [batch.num_rows for batch in batches])
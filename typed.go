@@ -0,0 +1,97 @@
+package onedb
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/pkg/errors"
+)
+
+// ErrTypeNotStruct occurs when a typed query is asked to map rows into a type that is not a struct.
+var ErrTypeNotStruct = errors.New("type parameter must be a struct")
+
+// ErrNoColumns occurs when a query used to populate a column returns no columns.
+var ErrNoColumns = errors.New("query returned no columns")
+
+// All runs a query against the provided Backender and returns every row mapped into a T
+func All[T any](backend Backender, query string, args ...interface{}) ([]T, error) {
+	return AllContext[T](context.Background(), backend, query, args...)
+}
+
+// AllContext runs a query against the provided Backender and returns every row mapped into a T. The query is aborted if ctx is done
+func AllContext[T any](ctx context.Context, backend Backender, query string, args ...interface{}) ([]T, error) {
+	if !IsStruct(reflect.TypeOf((*T)(nil)).Elem()) {
+		return nil, ErrTypeNotStruct
+	}
+	rows, err := queryContext(ctx, backend, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := []T{}
+	if err := getStruct(rows, &result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// One runs a query against the provided Backender and returns the first row mapped into a T
+func One[T any](backend Backender, query string, args ...interface{}) (T, error) {
+	return OneContext[T](context.Background(), backend, query, args...)
+}
+
+// OneContext runs a query against the provided Backender and returns the first row mapped into a T. The query is aborted if ctx is done
+func OneContext[T any](ctx context.Context, backend Backender, query string, args ...interface{}) (T, error) {
+	var result T
+	if !IsStruct(reflect.TypeOf(result)) {
+		return result, ErrTypeNotStruct
+	}
+	rows, err := queryContext(ctx, backend, query, args...)
+	if err != nil {
+		return result, err
+	}
+	defer rows.Close()
+
+	err = getStructRow(rows, &result)
+	return result, err
+}
+
+// Column runs a query against the provided Backender and returns the first column of every row as a T
+func Column[T any](backend Backender, query string, args ...interface{}) ([]T, error) {
+	return ColumnContext[T](context.Background(), backend, query, args...)
+}
+
+// ColumnContext runs a query against the provided Backender and returns the first column of every row as a T. The query is aborted if ctx is done
+func ColumnContext[T any](ctx context.Context, backend Backender, query string, args ...interface{}) ([]T, error) {
+	rows, err := queryContext(ctx, backend, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return getColumn[T](rows)
+}
+
+func getColumn[T any](rows RowsScanner) ([]T, error) {
+	columns, vals, err := getColumnNamesAndValues(rows, false)
+	if err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, ErrNoColumns
+	}
+
+	result := []T{}
+	for rows.Next() {
+		if err := rows.Scan(vals...); err != nil {
+			return nil, err
+		}
+		var item T
+		if err := SetValue(reflect.ValueOf(&item).Elem(), vals[0].(*interface{})); err != nil {
+			return nil, err
+		}
+		result = append(result, item)
+	}
+	return result, rows.Err()
+}
@@ -0,0 +1,60 @@
+package onedb
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestAll(t *testing.T) {
+	db := &mockBackend{Rows: NewRowsScanner([]SimpleData{{1, "hello"}, {2, "world"}})}
+	data, err := All[SimpleData](db, "query")
+	if err != nil || len(data) != 2 || data[0].IntVal != 1 || data[1].StringVal != "world" {
+		t.Error("expected success", err, data)
+	}
+
+	// not a struct
+	_, err = All[int](db, "query")
+	if err != ErrTypeNotStruct {
+		t.Error("expected type error", err)
+	}
+
+	// query error
+	db = &mockBackend{QueryErr: errors.New("fail")}
+	if _, err = All[SimpleData](db, "query"); err == nil {
+		t.Error("expected error")
+	}
+}
+
+func TestOne(t *testing.T) {
+	db := &mockBackend{Rows: NewRowsScanner([]SimpleData{{1, "hello"}})}
+	data, err := One[SimpleData](db, "query")
+	if err != nil || data.IntVal != 1 || data.StringVal != "hello" {
+		t.Error("expected success", err, data)
+	}
+
+	// empty result
+	db = &mockBackend{Rows: NewRowsScanner([]SimpleData{})}
+	if _, err = One[SimpleData](db, "query"); err == nil {
+		t.Error("expected error")
+	}
+
+	// not a struct
+	if _, err = One[*SimpleData](db, "query"); err != ErrTypeNotStruct {
+		t.Error("expected type error", err)
+	}
+}
+
+func TestColumn(t *testing.T) {
+	db := &mockBackend{Rows: NewRowsScanner([]SimpleData{{1, "hello"}, {2, "world"}})}
+	data, err := Column[int](db, "query")
+	if err != nil || len(data) != 2 || data[0] != 1 || data[1] != 2 {
+		t.Error("expected success", err, data)
+	}
+
+	// scan error
+	rows := NewRowsScanner([]SimpleData{{1, "hello"}})
+	rows.(*mockRowsScanner).ScanErr = errors.New("fail")
+	if _, err = Column[int](&mockBackend{Rows: rows}, "query"); err == nil {
+		t.Error("expected error")
+	}
+}
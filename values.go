@@ -0,0 +1,149 @@
+package onedb
+
+import (
+	"database/sql/driver"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// defaultTimeLayout is used by the text and JSON writers when no other layout is requested
+const defaultTimeLayout = "2006-01-02 15:04:05.999"
+
+// resolveValuer replaces a driver.Valuer with the value it reports so that custom types such as sql.NullString
+// or money types are rendered the same way as the primitive they wrap
+func resolveValuer(pval *interface{}) error {
+	valuer, ok := (*pval).(driver.Valuer)
+	if !ok {
+		return nil
+	}
+	value, err := valuer.Value()
+	if err != nil {
+		return err
+	}
+	*pval = value
+	return nil
+}
+
+// textFormat controls how values are rendered as text by the CSV, XML and HTML writers
+type textFormat struct {
+	DateOnly   bool
+	TimeLayout string         // overrides DateOnly
+	Location   *time.Location // times are converted to this time zone before they are formatted
+	Null       string         // text written for NULL values
+}
+
+func formatText(pval *interface{}, format textFormat) string {
+	switch v := (*pval).(type) {
+	case nil:
+		return format.Null
+	case bool:
+		if v {
+			return "true"
+		}
+		return "false"
+	case time.Time:
+		timeFormat := defaultTimeLayout
+		if format.DateOnly {
+			timeFormat = "2006-01-02"
+		}
+		if format.TimeLayout != "" {
+			timeFormat = format.TimeLayout
+		}
+		if format.Location != nil {
+			v = v.In(format.Location)
+		}
+		return v.Format(timeFormat)
+	case string:
+		return string(v)
+	case []byte:
+		return string(v)
+	case fmt.Stringer:
+		return v.String()
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// getColumnIndexes returns the indexes of the query columns to write, in the order they are written. include
+// defaults to every column in query order
+func getColumnIndexes(columns, include, exclude []string) ([]int, error) {
+	var indexes []int
+	if len(include) == 0 {
+		for i := range columns {
+			indexes = append(indexes, i)
+		}
+	} else {
+		for _, name := range include {
+			index := indexOfColumn(columns, name)
+			if index == -1 {
+				return nil, fmt.Errorf("onedb: column %s not found in query results", name)
+			}
+			indexes = append(indexes, index)
+		}
+	}
+
+	result := indexes[:0]
+	for _, index := range indexes {
+		if indexOfColumn(exclude, columns[index]) == -1 {
+			result = append(result, index)
+		}
+	}
+	return result, nil
+}
+
+// typedValue is a database value serialized as text along with its type so that it is read back as the same type.
+// It is used by page tokens and recorded query fixtures
+type typedValue struct {
+	Type  string `json:"t"`
+	Value string `json:"v"`
+}
+
+// newTypedValue returns nil for NULL and an error for types that couldn't be decoded back into the same value
+func newTypedValue(value interface{}) (*typedValue, error) {
+	if err := resolveValuer(&value); err != nil {
+		return nil, err
+	}
+	switch v := value.(type) {
+	case nil:
+		return nil, nil
+	case int64, int32, int16, int8, int:
+		return &typedValue{"int", fmt.Sprint(v)}, nil
+	case uint64, uint32, uint16, uint8, uint:
+		return &typedValue{"uint", fmt.Sprint(v)}, nil
+	case float64:
+		return &typedValue{"float", strconv.FormatFloat(v, 'g', -1, 64)}, nil
+	case float32:
+		return &typedValue{"float", strconv.FormatFloat(float64(v), 'g', -1, 32)}, nil
+	case bool:
+		return &typedValue{"bool", strconv.FormatBool(v)}, nil
+	case time.Time:
+		return &typedValue{"time", v.Format(time.RFC3339Nano)}, nil
+	case []byte:
+		return &typedValue{"bytes", base64.StdEncoding.EncodeToString(v)}, nil
+	case string:
+		return &typedValue{"string", v}, nil
+	}
+	return nil, fmt.Errorf("onedb: unsupported value type %T", value)
+}
+
+func (v typedValue) decode() (interface{}, error) {
+	switch v.Type {
+	case "int":
+		return strconv.ParseInt(v.Value, 10, 64)
+	case "uint":
+		return strconv.ParseUint(v.Value, 10, 64)
+	case "float":
+		return strconv.ParseFloat(v.Value, 64)
+	case "bool":
+		return strconv.ParseBool(v.Value)
+	case "time":
+		return time.Parse(time.RFC3339Nano, v.Value)
+	case "bytes":
+		return base64.StdEncoding.DecodeString(v.Value)
+	case "string":
+		return v.Value, nil
+	}
+	return nil, fmt.Errorf("unknown value type %s", v.Type)
+}